@@ -2,10 +2,13 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/journal"
 	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/fafeitsch/Tukan/tukan/phonebook"
 	"github.com/urfave/cli"
 	"io/ioutil"
 	"net/http"
@@ -17,58 +20,206 @@ import (
 	"time"
 )
 
-func createConnector(context *cli.Context) *tukan.Connector {
+func createConnector(context *cli.Context) (*tukan.Connector, error) {
+	return createConnectorFor(context, context.Args())
+}
+
+// createConnectorFor is like createConnector, but uses the passed arguments as address
+// specifications instead of the command's arguments.
+func createConnectorFor(context *cli.Context, args []string) (*tukan.Connector, error) {
 	login := context.GlobalString(loginFlagName)
 	password := context.GlobalString(passwordFlagName)
 	timeout := context.GlobalInt(timeoutFlagName)
-	connector := tukan.Connector{Client: &http.Client{Timeout: time.Duration(timeout) * time.Second}, UserName: login, Password: password}
-	addresses := tukan.ExpandAddresses("http", context.Args()...)
+	scheme := context.GlobalString(schemeFlagName)
+	if scheme == "" {
+		scheme = "http"
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme \"%s\", want \"http\" or \"https\"", scheme)
+	}
+	if output := context.GlobalString(outputFlagName); !isOutputFormat(output) {
+		return nil, fmt.Errorf("unsupported output format \"%s\", want one of %s", output, strings.Join(outputFormats, ", "))
+	}
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	if scheme == "https" {
+		transport, err := createTLSTransport(context)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = transport
+	}
+	connector := tukan.Connector{
+		Client:   client,
+		UserName: login,
+		Password: password,
+		Retry: tukan.RetryPolicy{
+			MaxAttempts:    context.GlobalInt(maxAttemptsFlagName),
+			InitialBackoff: context.GlobalDuration(retryBackoffFlagName),
+			MaxBackoff:     context.GlobalDuration(retryMaxBackoffFlagName),
+			Jitter:         context.GlobalFloat64(retryJitterFlagName),
+		},
+		Parallel:       context.GlobalInt(parallelFlagName),
+		SubnetParallel: context.GlobalInt(subnetParallelFlagName),
+		SubnetPrefix:   context.GlobalInt(subnetPrefixFlagName),
+		ReadOnly:       context.GlobalBool(dryRunFlagName),
+	}
+	specs, err := addressSpecs(context, args)
+	if err != nil {
+		return nil, err
+	}
+	inventorySpecs, credentials, err := inventoryTargets(context)
+	if err != nil {
+		return nil, err
+	}
+	specs = append(specs, inventorySpecs...)
+	for _, exclude := range context.GlobalStringSlice(excludeFlagName) {
+		specs = append(specs, "!"+exclude)
+	}
+	addresses, err := parseAddresses(context, specs...)
+	if err != nil {
+		return nil, err
+	}
 	connector.Addresses = addresses
-	return &connector
+	connector.Credentials = credentials
+	return &connector, nil
+}
+
+// parseAddresses expands the address specifications with the scheme and the default port given by the global flags.
+func parseAddresses(context *cli.Context, specs ...string) ([]string, error) {
+	scheme := context.GlobalString(schemeFlagName)
+	if scheme == "" {
+		scheme = "http"
+	}
+	return tukan.ParseAddressesWithPort(scheme, context.GlobalInt(portFlagName), specs...)
+}
+
+func addressSpecs(context *cli.Context, args []string) ([]string, error) {
+	specs := append([]string{}, args...)
+	if hostsFile := context.GlobalString(hostsFileFlagName); hostsFile != "" {
+		file, err := os.Open(hostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read hosts file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		fromFile, err := tukan.ReadAddressSpecs(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read hosts file: %v", err)
+		}
+		specs = append(specs, fromFile...)
+	}
+	return specs, nil
 }
 
-func scan(context *cli.Context) {
+func createTLSTransport(context *cli.Context) (*http.Transport, error) {
+	options := tukan.TLSOptions{
+		AllowSelfSigned: context.GlobalBool(allowSelfSignedFlagName),
+		Fingerprints:    make(map[string]string),
+	}
+	if caFile := context.GlobalString(caCertFlagName); caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA certificates: %v", err)
+		}
+		options.RootCAs = data
+	}
+	for _, pin := range context.GlobalStringSlice(pinFlagName) {
+		separator := strings.LastIndex(pin, "=")
+		if separator == -1 {
+			return nil, fmt.Errorf("pinned fingerprint \"%s\" must have the format address=fingerprint", pin)
+		}
+		// a pin without port applies to the host on every port
+		address := strings.TrimSuffix(strings.TrimPrefix(pin[:separator], "https://"), "/")
+		options.Fingerprints[address] = pin[separator+1:]
+	}
+	return options.Transport()
+}
+
+func scan(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	channel := make(chan commentedResult)
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).Run(actionLogin.handler(channel), func(p *tukan.Phone) {}, actionLogout.handler(channel))
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel), func(p *tukan.Phone) {}, actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	return summary.exitError()
 }
 
-func reset(context *cli.Context) {
+func reset(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	dryRun := context.GlobalBool(dryRunFlagName)
+	// Ask before the interrupt handler is installed, so that Ctrl-C still aborts the prompt
+	if !dryRun {
+		_, _ = fmt.Fprintf(context.App.Writer, "Do you really want to reset %d phones? Type YES: ", len(connector.Addresses))
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		if input != "YES\n" {
+			return nil
+		}
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "reset", channel)
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
 	handler := actionReset.handler(channel)
 	resetPhone := func(p *tukan.Phone) {
-		err := p.Reset()
-		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		if dryRun {
+			reportPlan(channel, p.Address, actionReset, "Would reset the phone")
+			return
+		}
+		if !recorder.save(ctx, p, journal.Snapshot{}) {
+			return
+		}
+		err := p.ResetContext(ctx)
+		handler(p.Result(err))
 	}
 	// Do nothing with logout because it fails nonetheless (the phone immediately resets itself)
 	logoutCallback := func(p *tukan.PhoneResult) {}
-	connector := createConnector(context)
-	addresses := connector.Addresses
-	_, _ = fmt.Fprintf(context.App.Writer, "Do you really want to reset %d phones? Type YES: ", len(addresses))
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	if input != "YES\n" {
-		close(channel)
-		wg.Wait()
-		return
-	}
-	connector.Run(actionLogin.handler(channel), resetPhone, logoutCallback)
+	if dryRun {
+		logoutCallback = actionLogout.handler(channel)
+	}
+	connector.RunContext(ctx, actionLogin.handler(channel), resetPhone, logoutCallback)
 	close(channel)
 	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
 }
 
-func uploadPhoneBook(context *cli.Context) {
+func uploadPhoneBook(context *cli.Context) error {
+	if (context.String(sourceDirFlagName) == "") == (context.String(sharedFlagName) == "") {
+		return cli.NewExitError(fmt.Sprintf("either --%s or --%s is required", sourceDirFlagName, sharedFlagName), exitCodeError)
+	}
+	if context.String(sharedFlagName) != "" {
+		return uploadSharedPhoneBook(context)
+	}
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	sourceDirectory := context.String(sourceDirFlagName)
 	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "pb-up", channel)
 
 	uploadHandler := actionUploadPhoneBook.handler(channel)
+	downloadHandler := actionDownloadPhoneBook.handler(channel)
+	dryRun := context.GlobalBool(dryRunFlagName)
+	check := context.Bool(checkFlagName)
 	upload := func(p *tukan.Phone) {
 		fileName := phoneBookFileName(p.Address)
 		path := filepath.Join(sourceDirectory, fileName)
@@ -78,52 +229,94 @@ func uploadPhoneBook(context *cli.Context) {
 			uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
 			return
 		}
-		err = p.UploadPhoneBook(string(content))
-		uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		if check {
+			err = checkPhoneBook(content)
+			if err != nil {
+				uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: fmt.Errorf("%s: %v", path, err)})
+				return
+			}
+		}
+		if dryRun {
+			book, err := p.DownloadPhoneBookContext(ctx)
+			downloadHandler(p.Result(err))
+			if err != nil {
+				return
+			}
+			if *book == string(content) {
+				reportPlan(channel, p.Address, actionUploadPhoneBook, "Phone book unchanged")
+			} else {
+				reportPlan(channel, p.Address, actionUploadPhoneBook, fmt.Sprintf("Would upload the phone book %s", path))
+			}
+			return
+		}
+		if !recorder.savePhoneBook(ctx, p) {
+			return
+		}
+		err = p.UploadPhoneBookContext(ctx, string(content))
+		uploadHandler(p.Result(err))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel),
 		upload,
 		actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+// checkPhoneBook parses the phone book and validates it (see phonebook.Phonebook.Validate).
+func checkPhoneBook(content []byte) error {
+	book, err := phonebook.Parse(content)
+	if err != nil {
+		return err
+	}
+	return book.Validate()
 }
 
-func downloadPhoneBook(context *cli.Context) {
+func downloadPhoneBook(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	targetDirectory := context.String(targetDirFlagName)
-	err := os.MkdirAll(targetDirectory, os.ModePerm)
+	err = os.MkdirAll(targetDirectory, os.ModePerm)
 	if err != nil {
-		_, _ = fmt.Fprintf(context.App.Writer, "could not create target directory: %v", err)
-		return
+		return cli.NewExitError(fmt.Sprintf("could not create target directory: %v", err), exitCodeError)
 	}
 	channel := make(chan commentedResult)
 
 	handler := actionDownloadPhoneBook.handler(channel)
 	download := func(p *tukan.Phone) {
-		book, err := p.DownloadPhoneBook()
-		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		book, err := p.DownloadPhoneBookContext(ctx)
+		handler(p.Result(err))
 		if err == nil && book != nil {
 			fileName := phoneBookFileName(p.Address)
 			path := filepath.Join(targetDirectory, fileName)
 			err := ioutil.WriteFile(path, []byte(*book), os.ModePerm)
 			if err != nil {
 				comment := fmt.Sprintf("Downloaded content could not be written to file:%v", err)
-				channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address, Error: err}, comment: comment}
+				channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address, Error: err}, action: actionDownloadPhoneBook, comment: comment}
 			}
 		}
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel),
 		download,
 		actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	return summary.exitError()
 }
 
 func phoneBookFileName(address string) string {
@@ -133,73 +326,95 @@ func phoneBookFileName(address string) string {
 	return "phonebook_" + result + ".xml"
 }
 
-func saveConfig(context *cli.Context) {
+func saveConfig(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	targetDirectory := context.String(targetDirFlagName)
-	err := os.MkdirAll(targetDirectory, os.ModePerm)
+	err = os.MkdirAll(targetDirectory, os.ModePerm)
 	if err != nil {
-		_, _ = fmt.Fprintf(context.App.Writer, "could not create target directory: %v", err)
-		return
+		return cli.NewExitError(fmt.Sprintf("could not create target directory: %v", err), exitCodeError)
 	}
 	channel := make(chan commentedResult)
 
 	handler := actionDownloadParameters.handler(channel)
 	download := func(p *tukan.Phone) {
-		parameters, err := p.DownloadParameters()
+		parameters, err := p.DownloadParametersContext(ctx)
 		if err == nil && parameters != nil {
 			fileName := parametersFileName(p.Address)
 			bytes, _ := json.MarshalIndent(&parameters, "", "  ")
 			err = ioutil.WriteFile(filepath.Join(targetDirectory, fileName), bytes, os.ModePerm)
 		}
-		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		handler(p.Result(err))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).
-		Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
 			download,
 			actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	return summary.exitError()
 }
 
-func backup(context *cli.Context) {
+func backup(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	targetDirectory := context.String(targetDirFlagName)
-	err := os.MkdirAll(targetDirectory, os.ModePerm)
+	err = os.MkdirAll(targetDirectory, os.ModePerm)
 	if err != nil {
-		_, _ = fmt.Fprintf(context.App.Writer, "could not create target directory: %v", err)
-		return
+		return cli.NewExitError(fmt.Sprintf("could not create target directory: %v", err), exitCodeError)
 	}
 	channel := make(chan commentedResult)
 
 	handler := actionBackup.handler(channel)
 	backup := func(p *tukan.Phone) {
-		data, err := p.Backup()
+		data, err := p.BackupContext(ctx)
 		if err == nil && data != nil {
 			fileName := backupFileName(p.Address)
 			fileName = filepath.Join(targetDirectory, fileName)
 			err = ioutil.WriteFile(fileName, data, os.ModePerm)
 		}
-		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		handler(p.Result(err))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).
-		Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
 			backup,
 			actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	return summary.exitError()
 }
 
-func restore(context *cli.Context) {
+func restore(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
 	sourceDirectory := context.String(sourceDirFlagName)
 	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "restore", channel)
 
 	handler := actionUploadParameters.handler(channel)
+	dryRun := context.GlobalBool(dryRunFlagName)
 	upload := func(p *tukan.Phone) {
 		fileName := backupFileName(p.Address)
 		data, err := ioutil.ReadFile(filepath.Join(sourceDirectory, fileName))
@@ -207,82 +422,375 @@ func restore(context *cli.Context) {
 			handler(&tukan.PhoneResult{Address: p.Address, Error: err})
 			return
 		}
-		err = p.Restore(data)
-		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		if dryRun {
+			reportPlan(channel, p.Address, actionUploadParameters, fmt.Sprintf("Would restore the backup %s (%d bytes)", filepath.Join(sourceDirectory, fileName), len(data)))
+			return
+		}
+		if !recorder.save(ctx, p, journal.Snapshot{}) {
+			return
+		}
+		err = p.RestoreContext(ctx, data)
+		handler(p.Result(err))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).
-		Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
 			upload,
 			actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
 }
 
-func replaceFunctionKeys(context *cli.Context) {
+func replaceFunctionKeys(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
 	original := context.String(originalFlagName)
 	replace := context.String(replaceFlagName)
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "fnkeys-replace", channel)
+	replaceOperation := functionKeysOperation(ctx, context, channel, recorder, actionReplaceFunctionKeys, func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		keys, changed := current.FunctionKeys.Transform(params.ReplaceDisplayName(original, replace))
+		return keys, changed, nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			replaceOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+func SipOverrideDisplayNames(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	replace := context.String(replaceFlagName)
+	variables, err := loadPhoneVariables(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
 
 	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "sip-override", channel)
 
 	downloadHandler := actionDownloadParameters.handler(channel)
-	uploadHandler := actionUploadParameters.handler(channel)
+	uploadHandler := actionSipOverrideDisplayName.handler(channel)
+	dryRun := context.GlobalBool(dryRunFlagName)
 	replaceOperation := func(p *tukan.Phone) {
-		parameters, err := p.DownloadParameters()
-		downloadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		parameters, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
 		if err != nil {
 			return
 		}
-		upload, changed := parameters.FunctionKeys.Transform(params.ReplaceDisplayName(original, replace))
-		comment := fmt.Sprintf("%s (changed keys): %v", actionReplaceFunctionKeys.String(), changed)
-		channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address, Error: err}, comment: comment}
-		err = p.UploadParameters(params.Parameters{FunctionKeys: upload})
-		uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		name, err := expandTemplate(replace, variables.lookup(p.Address, parameters))
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		upload, changed := parameters.Sip.Transform(params.SipOverrideDisplayName(name))
+		comment := fmt.Sprintf("%s (changed sip): %v", actionSipOverrideDisplayName.String(), changed)
+		channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address, Error: err}, action: actionSipOverrideDisplayName, comment: comment}
+		err = parameters.ValidatePatch(&params.Parameters{Sip: upload})
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		if dryRun {
+			changes := params.Diff(&params.Parameters{Sip: parameters.Sip}, &params.Parameters{Sip: upload})
+			reportChanges(channel, p.Address, actionSipOverrideDisplayName, changes, true)
+			return
+		}
+		if !recorder.save(ctx, p, journal.Snapshot{Parameters: parameters}) {
+			return
+		}
+		err = p.UploadParametersContext(ctx, params.Parameters{Sip: upload})
+		uploadHandler(p.Result(err))
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).
-		Run(actionLogin.handler(channel),
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
 			replaceOperation,
 			actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
 }
 
-func SipOverrideDisplayNames(context *cli.Context) {
-	replace := context.String(replaceFlagName)
-
+func diffParameters(context *cli.Context) error {
+	files, specs := splitFileArguments(context.Args())
+	connector, err := createConnectorFor(context, specs)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	reference, err := loadReferenceParameters(ctx, context, connector, context.String(againstFlagName))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not load reference parameters: %v", err), exitCodeError)
+	}
+	patchDirectory := context.String(patchDirFlagName)
+	if patchDirectory != "" {
+		err = os.MkdirAll(patchDirectory, os.ModePerm)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("could not create patch directory: %v", err), exitCodeError)
+		}
+	}
 	channel := make(chan commentedResult)
 
+	report := func(name string, parameters *params.Parameters) {
+		var changes []params.Change
+		if context.Bool(templateFlagName) {
+			changes = params.DiffTemplate(parameters, reference)
+		} else {
+			changes = params.Diff(parameters, reference)
+		}
+		if patchDirectory != "" {
+			bytes, _ := json.MarshalIndent(params.JSONPatch(changes), "", "  ")
+			err := ioutil.WriteFile(filepath.Join(patchDirectory, patchFileName(name)), bytes, os.ModePerm)
+			if err != nil {
+				comment := fmt.Sprintf("Patch could not be written to file: %v", err)
+				channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: name, Error: err}, action: actionDiff, comment: comment}
+			}
+		}
+		if len(changes) == 0 {
+			channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: name}, action: actionDiff, comment: "No differences"}
+		}
+		for _, change := range changes {
+			channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: name}, action: actionDiff, comment: change.Redacted().String()}
+		}
+	}
 	downloadHandler := actionDownloadParameters.handler(channel)
-	uploadHandler := actionSipOverrideDisplayName.handler(channel)
-	replaceOperation := func(p *tukan.Phone) {
-		parameters, err := p.DownloadParameters()
-		downloadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+	diffOperation := func(p *tukan.Phone) {
+		parameters, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err == nil {
+			report(p.Address, parameters)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	for _, file := range files {
+		parameters, err := readParametersFile(file)
 		if err != nil {
-			return
+			channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: file, Error: err}, action: actionDiff, comment: fmt.Sprintf("Reading parameters returned error: %v", err)}
+			continue
 		}
-		upload, changed := parameters.Sip.Transform(params.SipOverrideDisplayName(replace))
-		comment := fmt.Sprintf("%s (changed sip): %v", actionSipOverrideDisplayName.String(), changed)
-		channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address, Error: err}, comment: comment}
-		err = p.UploadParameters(params.Parameters{Sip: upload})
-		uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		report(file, parameters)
 	}
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			diffOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	return summary.exitError()
+}
+
+func applyParameters(context *cli.Context) error {
+	// apply had its own --dry-run before the global one existed, which is kept as an alias
+	if context.Bool(dryRunFlagName) {
+		_ = context.GlobalSet(dryRunFlagName, "true")
+	}
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	state, err := readDesiredState(context.String(stateFileFlagName))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not read desired state: %v", err), exitCodeError)
+	}
+	fleet, err := loadInventory(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	variables, err := loadPhoneVariables(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "apply", channel)
+
+	applyOperation := patchOperation(ctx, context, channel, recorder, actionApply, nil, func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error) {
+		desired := state.For(fleet.tags(p.Address)...)
+		values := variables.lookup(p.Address, current)
+		return desired.MapStrings(func(value string) (string, error) {
+			return expandTemplate(value, values)
+		})
+	})
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go handleResults(&wg, channel, context)
-	createConnector(context).
-		Run(actionLogin.handler(channel),
-			replaceOperation,
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			applyOperation,
 			actionLogout.handler(channel))
 	close(channel)
 	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+// uploadHook is called by patchOperation after the changes of a phone have been validated and right before
+// they are uploaded. If it returns an error, nothing is uploaded; otherwise, the returned function is called
+// with the result of the upload.
+type uploadHook func(p *tukan.Phone) (func(err error), error)
+
+// patchOperation returns an operation which downloads the parameters of a phone and uploads only those fields
+// of the phone's template which differ from them. The template is determined for every phone after its parameters
+// have been downloaded. The changes are checked and validated before, and reported afterwards. The hook may be nil.
+func patchOperation(ctx context.Context, context *cli.Context, channel chan<- commentedResult, recorder *journalRecorder, a action, hook uploadHook, template func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error)) func(p *tukan.Phone) {
+	dryRun := context.GlobalBool(dryRunFlagName)
+	downloadHandler := actionDownloadParameters.handler(channel)
+	uploadHandler := actionUploadParameters.handler(channel)
+	return func(p *tukan.Phone) {
+		current, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		desired, err := template(p, current)
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		patch, changes := params.Patch(current, &desired)
+		if len(changes) == 0 {
+			reportPlan(channel, p.Address, a, "Unchanged")
+			return
+		}
+		err = current.CheckChanges(changes)
+		if err == nil {
+			err = current.ValidatePatch(&patch)
+		}
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		if !dryRun {
+			if !recorder.save(ctx, p, journal.Snapshot{Parameters: current}) {
+				return
+			}
+			uploaded := func(error) {}
+			if hook != nil {
+				uploaded, err = hook(p)
+				if err != nil {
+					uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+					return
+				}
+			}
+			err = p.UploadParametersContext(ctx, patch)
+			uploaded(err)
+			uploadHandler(p.Result(err))
+			if err != nil {
+				return
+			}
+		}
+		reportChanges(channel, p.Address, a, changes, dryRun)
+	}
+}
+
+func readDesiredState(path string) (*params.DesiredState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := params.DesiredState{}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse desired state: %v", err)
+	}
+	return &state, nil
+}
+
+// splitFileArguments separates the arguments which name existing files from
+// the arguments which are address specifications.
+func splitFileArguments(args []string) ([]string, []string) {
+	files := make([]string, 0)
+	specs := make([]string, 0)
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			files = append(files, arg)
+		} else {
+			specs = append(specs, arg)
+		}
+	}
+	return files, specs
+}
+
+// loadReferenceParameters reads the parameters from the source, which is either a file
+// or the address of a single telephone.
+func loadReferenceParameters(ctx context.Context, context *cli.Context, connector *tukan.Connector, source string) (*params.Parameters, error) {
+	if files, _ := splitFileArguments([]string{source}); len(files) == 1 {
+		return readParametersFile(source)
+	}
+	addresses, err := parseAddresses(context, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != 1 {
+		return nil, fmt.Errorf("\"%s\" must be a file or the address of exactly one telephone", source)
+	}
+	phone, err := connector.SingleConnectContext(ctx, addresses[0])
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = phone.Logout() }()
+	return phone.DownloadParametersContext(ctx)
+}
+
+func readParametersFile(path string) (*params.Parameters, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parameters := params.Parameters{}
+	err = json.Unmarshal(data, &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse parameters file: %v", err)
+	}
+	return &parameters, nil
+}
+
+func patchFileName(name string) string {
+	if strings.HasSuffix(name, ".json") {
+		return "patch_" + filepath.Base(name)
+	}
+	regex := regexp.MustCompile("https?://")
+	result := regex.ReplaceAllString(name, "")
+	result = strings.ReplaceAll(result, ":", "_")
+	return "patch_" + result + ".json"
 }
 
 func parametersFileName(address string) string {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/journal"
 	"github.com/fafeitsch/Tukan/tukan/mock"
 	"github.com/fafeitsch/Tukan/tukan/params"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 	"github.com/urfave/cli"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
@@ -25,7 +27,15 @@ var password = "admin123"
 
 func TestMain(m *testing.M) {
 	rand.Seed(time.Now().UnixNano())
-	os.Exit(m.Run())
+	// the journal is saved in the home directory by default
+	home, err := ioutil.TempDir("", "tukan-home")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("HOME", home)
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
 }
 
 func TestScan(t *testing.T) {
@@ -100,6 +110,29 @@ func TestUploadPhoneBook(t *testing.T) {
 		uploadPhoneBook(ctx)
 		assert.Contains(t, buff.String(), "Uploading Phone Book returned error: open "+filepath.Join(tmpDir, "not_existing", phoneBookFileName(server1.URL))+": no such file or directory", "result message in case of error wrong")
 	})
+	t.Run("check", func(t *testing.T) {
+		valid := "<LocalPhonebook><Entry><LastName>Doe</LastName><Number type=\"office\">20</Number></Entry></LocalPhonebook>"
+		err := ioutil.WriteFile(filepath.Join(tmpDir, phoneBookFileName(server1.URL)), []byte(valid), os.ModePerm)
+		require.NoError(t, err, "no error expected")
+		var buff bytes.Buffer
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.String(sourceDirFlagName, tmpDir, "")
+		flags.Bool(checkFlagName, true, "")
+		flags.String(loginFlagName, username, "")
+		flags.String(passwordFlagName, password, "")
+		_ = flags.Parse([]string{server1.URL})
+		ctx := cli.NewContext(&cli.App{Writer: &buff}, flags, nil)
+		_ = uploadPhoneBook(ctx)
+		assert.Equal(t, valid+"\n", phone1.Phonebook, "valid phone book should be uploaded")
+
+		invalid := "<LocalPhonebook><Entry><LastName>Doe</LastName></Entry></LocalPhonebook>"
+		err = ioutil.WriteFile(filepath.Join(tmpDir, phoneBookFileName(server1.URL)), []byte(invalid), os.ModePerm)
+		require.NoError(t, err, "no error expected")
+		buff.Reset()
+		_ = uploadPhoneBook(ctx)
+		assert.Contains(t, buff.String(), "Uploading Phone Book returned error: "+filepath.Join(tmpDir, phoneBookFileName(server1.URL))+": invalid phone book: Entry[0]: the entry has no number", "invalid phone book should be refused")
+		assert.Equal(t, valid+"\n", phone1.Phonebook, "invalid phone book must not be uploaded")
+	})
 }
 
 func TestDownloadPhoneBook(t *testing.T) {
@@ -345,3 +378,479 @@ func TestBackup(t *testing.T) {
 	require.NoError(t, err, "reading the file should not give an error")
 	assert.Equal(t, phone1.Backup, fileContent, "downloaded cfg not correct")
 }
+
+func TestCreateConnector(t *testing.T) {
+	number := rand.Int()
+	hostsFile := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d.txt", number))
+	err := ioutil.WriteFile(hostsFile, []byte("# floor 3\n10.20.30.0/29\n\n10.20.40.1:8080 # reception\n"), os.ModePerm)
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.Remove(hostsFile) }()
+
+	t.Run("success", func(t *testing.T) {
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.String(hostsFileFlagName, hostsFile, "")
+		excludes := cli.StringSlice{"10.20.30.2-10.20.30.5"}
+		flags.Var(&excludes, excludeFlagName, "")
+		_ = flags.Parse([]string{"10.20.50.1"})
+		connector, err := createConnector(cli.NewContext(nil, flags, nil))
+		require.NoError(t, err, "no error expected")
+		want := []string{"http://10.20.50.1:80", "http://10.20.30.1:80", "http://10.20.30.6:80", "http://10.20.40.1:8080"}
+		assert.Equal(t, want, connector.Addresses, "addresses are wrong")
+	})
+	t.Run("port", func(t *testing.T) {
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.Int(portFlagName, 8080, "")
+		_ = flags.Parse([]string{"10.20.50.1", "10.20.50.2:80"})
+		connector, err := createConnector(cli.NewContext(nil, flags, nil))
+		require.NoError(t, err, "no error expected")
+		assert.Equal(t, []string{"http://10.20.50.1:8080", "http://10.20.50.2:80"}, connector.Addresses, "addresses without port should use --port")
+	})
+	t.Run("invalid address", func(t *testing.T) {
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.String(loginFlagName, username, "")
+		flags.String(passwordFlagName, password, "")
+		_ = flags.Parse([]string{"10.20.50.1", "10.20.50.1:http"})
+		var buff bytes.Buffer
+		err := scan(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+		assert.EqualError(t, err, "could not create connector: invalid address \"10.20.50.1:http\": port must be a number between 1 and 65535", "error is wrong")
+		assert.Empty(t, buff.String(), "errors must not be printed to the output")
+	})
+}
+
+func TestScan_Inventory(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, "secret1")
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, _ := mock.CreatePhone("root", "secret2")
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+	handler3, _ := mock.CreatePhone(username, password)
+	server3 := httptest.NewServer(handler3)
+	defer server3.Close()
+
+	fleet := fmt.Sprintf(`phones:
+  - address: %s
+    name: Reception
+    password: secret1
+    tags: [floor3]
+  - address: %s
+    login: root
+    password: secret2
+    tags: [floor3]
+  - address: %s
+    tags: [floor4]
+`, server1.URL, server2.URL, server3.URL)
+	inventoryFile := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d.yaml", rand.Int()))
+	err := ioutil.WriteFile(inventoryFile, []byte(fleet), os.ModePerm)
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.Remove(inventoryFile) }()
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(inventoryFlagName, inventoryFile, "")
+	tags := cli.StringSlice{"floor3"}
+	flags.Var(&tags, tagFlagName, "")
+	_ = flags.Parse([]string{})
+
+	var buff bytes.Buffer
+	scan(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+	got := buff.String()
+
+	assert.Contains(t, got, server1.URL+" (Reception):\n\tLogin successful\n\tLogout successful\n", "first phone should use its own password and show its name")
+	assert.Contains(t, got, server2.URL+":\n\tLogin successful\n\tLogout successful\n", "second phone should use its own login and password")
+	assert.NotContains(t, got, server3.URL, "third phone should not be selected")
+	assert.Nil(t, phone1.Token, "phone should be logged out")
+
+	buff.Reset()
+	flags.String(outputFlagName, outputJSON, "")
+	scan(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+	var records []outputRecord
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &records), "output should be a JSON array")
+	for _, record := range records {
+		if record.Address == server1.URL {
+			assert.Equal(t, "Reception", record.Name, "name of the first phone is wrong")
+		} else {
+			assert.Empty(t, record.Name, "phones without name should have no name")
+		}
+	}
+}
+
+func TestScan_ExitCodes(t *testing.T) {
+	handler, _ := mock.CreatePhone(username, password)
+	reachable := httptest.NewServer(handler)
+	defer reachable.Close()
+	unreachable := httptest.NewServer(handler)
+	unreachable.Close()
+	otherPassword, _ := mock.CreatePhone(username, "secret")
+	wrongCredentials := httptest.NewServer(otherPassword)
+	defer wrongCredentials.Close()
+
+	tests := []struct {
+		name      string
+		addresses []string
+		want      int
+	}{
+		{name: "success", addresses: []string{reachable.URL}, want: 0},
+		{name: "partial failure", addresses: []string{reachable.URL, unreachable.URL}, want: exitCodePartialFailure},
+		{name: "all failed", addresses: []string{unreachable.URL}, want: exitCodeAllFailed},
+		{name: "authentication failure", addresses: []string{reachable.URL, unreachable.URL, wrongCredentials.URL}, want: exitCodeAuthFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("", flag.PanicOnError)
+			flags.String(loginFlagName, username, "")
+			flags.String(passwordFlagName, password, "")
+			_ = flags.Parse(tt.addresses)
+
+			var buff bytes.Buffer
+			err := scan(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+			if tt.want == 0 {
+				assert.NoError(t, err, "no error expected")
+				return
+			}
+			exitErr, ok := err.(cli.ExitCoder)
+			require.True(t, ok, "error should have an exit code")
+			assert.Equal(t, tt.want, exitErr.ExitCode(), "exit code is wrong")
+		})
+	}
+}
+
+func TestScan_FailFast(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	addresses := []string{unreachable.URL}
+	for i := 0; i < 3; i++ {
+		handler, _ := mock.CreatePhone(username, password)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		addresses = append(addresses, server.URL)
+	}
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.Int(parallelFlagName, 1, "")
+	flags.Bool(failFastFlagName, true, "")
+	_ = flags.Parse(addresses)
+
+	var buff bytes.Buffer
+	err := scan(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.Error(t, err, "an error is expected")
+	assert.Contains(t, []int{exitCodeAllFailed, exitCodePartialFailure}, err.(cli.ExitCoder).ExitCode(), "exit code is wrong")
+	// the phone scheduled right after the failure may still be processed, but not the last one
+	last := addresses[len(addresses)-1]
+	assert.Contains(t, buff.String(), last+":\n\tLogin returned error: skipped because the run was stopped", "last phone should be skipped")
+}
+
+func TestDiffParameters(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Parameters = params.Parameters{
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: []params.FunctionKey{{DisplayName: "Linda", PhoneNumber: "89-IN"}},
+		Sip:          params.Sips{{AuthenticationPassword: "new secret"}},
+	}
+	handler2, phone2 := mock.CreatePhone(username, password)
+	phone2.Parameters = params.Parameters{
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: []params.FunctionKey{{DisplayName: "John", PhoneNumber: "89-IN"}, {DisplayName: "Hugh"}},
+		Sip:          params.Sips{{AuthenticationPassword: "old secret"}},
+	}
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	tmpDir := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d", rand.Int()))
+	require.NoError(t, os.Mkdir(tmpDir, os.ModePerm), "no error expected")
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	file := filepath.Join(tmpDir, "parameters_phone1.json")
+	data, _ := json.Marshal(phone1.Parameters)
+	require.NoError(t, ioutil.WriteFile(file, data, os.ModePerm), "no error expected")
+	patchDir := filepath.Join(tmpDir, "patches")
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(againstFlagName, server1.URL, "")
+	flags.String(patchDirFlagName, patchDir, "")
+	_ = flags.Parse([]string{file, server2.URL})
+
+	var buff bytes.Buffer
+	err := diffParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+	require.NoError(t, err, "no error expected")
+
+	got := buff.String()
+	assert.Contains(t, got, file+":\n\tNo differences\n", "file should not differ from the reference")
+	assert.Contains(t, got, "\tFunctionKeys[0].DisplayName: \"John\" -> \"Linda\"\n", "changed display name is missing")
+	assert.Contains(t, got, "\tFunctionKeys[1]: {\"DisplayName\":\"Hugh\"} -> (unset)\n", "removed key is missing")
+	assert.Contains(t, got, "\tSIP[0].AuthenticationPassword: \"********\" -> \"********\"\n", "changed password should be redacted")
+	assert.NotContains(t, got, "secret", "passwords must not be printed")
+
+	patch, err := ioutil.ReadFile(filepath.Join(patchDir, patchFileName(server2.URL)))
+	require.NoError(t, err, "patch file should exist")
+	want := `[{"op":"replace","path":"/FunctionKeys/0/DisplayName","value":"Linda"},{"op":"remove","path":"/FunctionKeys/1"},` +
+		`{"op":"replace","path":"/SIP/0/AuthenticationPassword","value":"new secret"}]`
+	assert.JSONEq(t, want, string(patch), "patch is wrong")
+}
+
+func TestApplyParameters(t *testing.T) {
+	createPhones := func() ([]*mock.Telephone, []string, func()) {
+		phones := make([]*mock.Telephone, 0)
+		urls := make([]string, 0)
+		servers := make([]*httptest.Server, 0)
+		for _, language := range []string{"en", "de"} {
+			handler, phone := mock.CreatePhone(username, password)
+			phone.Parameters = params.Parameters{PhoneLanguage: language, TimeFormat: "24h", Sip: params.Sips{{DisplayName: "Office"}}}
+			server := httptest.NewServer(handler)
+			phones = append(phones, phone)
+			urls = append(urls, server.URL)
+			servers = append(servers, server)
+		}
+		return phones, urls, func() {
+			for _, server := range servers {
+				server.Close()
+			}
+		}
+	}
+	tmpDir := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d", rand.Int()))
+	require.NoError(t, os.Mkdir(tmpDir, os.ModePerm), "no error expected")
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	stateFile := filepath.Join(tmpDir, "state.json")
+	state := `{"parameters": {"PhoneLanguage": "de"}, "overrides": [{"tags": ["sales"], "parameters": {"SIP": [{"DisplayName": "Sales"}]}}]}`
+	require.NoError(t, ioutil.WriteFile(stateFile, []byte(state), os.ModePerm), "no error expected")
+
+	run := func(urls []string, dryRun bool) string {
+		fleet := fmt.Sprintf("phones:\n  - address: %s\n    tags: [sales]\n", urls[1])
+		inventoryFile := filepath.Join(tmpDir, "inventory.yaml")
+		require.NoError(t, ioutil.WriteFile(inventoryFile, []byte(fleet), os.ModePerm), "no error expected")
+
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.String(loginFlagName, username, "")
+		flags.String(passwordFlagName, password, "")
+		flags.String(inventoryFlagName, inventoryFile, "")
+		flags.String(stateFileFlagName, stateFile, "")
+		flags.Bool(dryRunFlagName, dryRun, "")
+		_ = flags.Parse([]string{urls[0]})
+
+		var buff bytes.Buffer
+		err := applyParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+		require.NoError(t, err, "no error expected")
+		return buff.String()
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		phones, urls, closeAll := createPhones()
+		defer closeAll()
+
+		got := run(urls, true)
+
+		assert.Contains(t, got, urls[0]+":\n\tLogin successful\n\tDownloading Parameters successful\n\tWould change PhoneLanguage: \"en\" -> \"de\"\n", "first phone should be reported")
+		assert.Contains(t, got, urls[1]+":\n\tLogin successful\n\tDownloading Parameters successful\n\tWould change SIP[0].DisplayName: \"Office\" -> \"Sales\"\n", "second phone should be reported")
+		assert.Equal(t, "en", phones[0].Parameters.PhoneLanguage, "parameters must not be changed")
+		assert.Equal(t, "Office", phones[1].Parameters.Sip[0].DisplayName, "parameters must not be changed")
+	})
+	t.Run("apply", func(t *testing.T) {
+		phones, urls, closeAll := createPhones()
+		defer closeAll()
+
+		got := run(urls, false)
+
+		assert.Contains(t, got, "\tUploading Parameters successful\n\tChanged PhoneLanguage: \"en\" -> \"de\"\n", "change of first phone should be reported")
+		assert.Equal(t, params.Parameters{PhoneLanguage: "de"}, phones[0].Parameters, "only the differing field should be uploaded")
+		assert.Equal(t, params.Parameters{Sip: params.Sips{{DisplayName: "Sales"}}}, phones[1].Parameters, "only the differing field should be uploaded")
+	})
+	t.Run("unchanged", func(t *testing.T) {
+		phones, urls, closeAll := createPhones()
+		defer closeAll()
+		phones[1].Parameters.Sip[0].DisplayName = "Sales"
+
+		got := run([]string{urls[1], urls[1]}, false)
+
+		assert.Contains(t, got, "\tUnchanged\n", "phone should be reported as unchanged")
+		assert.NotContains(t, got, "Uploading", "nothing should be uploaded")
+	})
+}
+
+func TestReplaceFunctionKeys_DryRun(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	original := params.Parameters{
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: []params.FunctionKey{{DisplayName: "John"}, {DisplayName: "Linda", PhoneNumber: "89-IN"}},
+	}
+	phone.Parameters = original
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(originalFlagName, "Linda", "")
+	flags.String(replaceFlagName, "Eva", "")
+	flags.Bool(dryRunFlagName, true, "")
+	_ = flags.Parse([]string{server.URL})
+
+	var buff bytes.Buffer
+	err := replaceFunctionKeys(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, buff.String(), "\tWould change FunctionKeys[1].DisplayName: \"Linda\" -> \"Eva\"\n\tLogout successful\n", "plan is wrong")
+	assert.NotContains(t, buff.String(), "Uploading", "nothing should be uploaded")
+	assert.Equal(t, original, phone.Parameters, "parameters must not be changed")
+	assert.Nil(t, phone.Token, "phone should be logged out")
+}
+
+func TestUploadPhoneBook_DryRun(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Phonebook = "old book"
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	phone2.Phonebook = "same book"
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	tmpDir := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d", rand.Int()))
+	require.NoError(t, os.Mkdir(tmpDir, os.ModePerm), "no error expected")
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	book1 := filepath.Join(tmpDir, phoneBookFileName(server1.URL))
+	require.NoError(t, ioutil.WriteFile(book1, []byte("new book"), os.ModePerm), "no error expected")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, phoneBookFileName(server2.URL)), []byte("same book"), os.ModePerm), "no error expected")
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(sourceDirFlagName, tmpDir, "")
+	flags.Bool(dryRunFlagName, true, "")
+	_ = flags.Parse([]string{server1.URL, server2.URL})
+
+	var buff bytes.Buffer
+	err := uploadPhoneBook(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, buff.String(), server1.URL+":\n\tLogin successful\n\tDownloading Phone Book successful\n\tWould upload the phone book "+book1+"\n", "plan of first phone is wrong")
+	assert.Contains(t, buff.String(), server2.URL+":\n\tLogin successful\n\tDownloading Phone Book successful\n\tPhone book unchanged\n", "plan of second phone is wrong")
+	assert.Equal(t, "old book", phone1.Phonebook, "phone book must not be changed")
+}
+
+func TestRollback(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: []params.FunctionKey{{DisplayName: "John"}, {DisplayName: "Linda", PhoneNumber: "89-IN"}},
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	journalDir := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d", rand.Int()))
+	defer func() { _ = os.RemoveAll(journalDir) }()
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(originalFlagName, "Linda", "")
+	flags.String(replaceFlagName, "Eva", "")
+	flags.String(journalFlagName, journalDir, "")
+	_ = flags.Parse([]string{server.URL})
+	var errBuff bytes.Buffer
+	err := replaceFunctionKeys(cli.NewContext(&cli.App{Writer: ioutil.Discard, ErrWriter: &errBuff}, flags, nil))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "Eva", phone.Parameters.FunctionKeys[1].DisplayName, "function key should have been replaced")
+
+	runs, err := journal.New(journalDir).Runs()
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 1, len(runs), "one run expected")
+	assert.Equal(t, "Saved the previous state of 1 phones, roll back with: tukan rollback "+runs[0].ID+"\n", errBuff.String(), "run ID should be reported")
+
+	flags = flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(journalFlagName, journalDir, "")
+	_ = flags.Parse([]string{runs[0].ID})
+	var buff bytes.Buffer
+	err = rollback(cli.NewContext(&cli.App{Writer: &buff, ErrWriter: ioutil.Discard}, flags, nil))
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, buff.String(), "\tChanged FunctionKeys[1].DisplayName: \"Eva\" -> \"Linda\"\n", "rollback should be reported")
+	assert.Equal(t, "Linda", phone.Parameters.FunctionKeys[1].DisplayName, "function key should have been rolled back")
+}
+
+func TestApplyParameters_DryRunAlias(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{PhoneLanguage: "en"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	stateFile := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d.json", rand.Int()))
+	defer func() { _ = os.Remove(stateFile) }()
+	require.NoError(t, ioutil.WriteFile(stateFile, []byte(`{"parameters": {"PhoneLanguage": "de"}}`), os.ModePerm), "no error expected")
+
+	globalFlags := flag.NewFlagSet("", flag.PanicOnError)
+	globalFlags.String(loginFlagName, username, "")
+	globalFlags.String(passwordFlagName, password, "")
+	globalFlags.Bool(dryRunFlagName, false, "")
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(stateFileFlagName, stateFile, "")
+	flags.Bool(dryRunFlagName, false, "")
+	_ = flags.Parse([]string{"--" + dryRunFlagName, server.URL})
+	var buff bytes.Buffer
+	app := &cli.App{Writer: &buff}
+	err := applyParameters(cli.NewContext(app, flags, cli.NewContext(app, globalFlags, nil)))
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, buff.String(), "Would change PhoneLanguage: \"en\" -> \"de\"", "planned change should be reported")
+	assert.Equal(t, "en", phone.Parameters.PhoneLanguage, "nothing should be uploaded with --dry-run of the command")
+}
+
+func TestApplyParameters_Invalid(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{VLANIdentifierLAN: 1}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	stateFile := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d.json", rand.Int()))
+	defer func() { _ = os.Remove(stateFile) }()
+	require.NoError(t, ioutil.WriteFile(stateFile, []byte(`{"parameters": {"VLANIdentifierLAN": 9000}}`), os.ModePerm), "no error expected")
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(stateFileFlagName, stateFile, "")
+	_ = flags.Parse([]string{server.URL})
+	var buff bytes.Buffer
+	err := applyParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.Error(t, err, "error expected")
+	assert.Equal(t, exitCodeAllFailed, err.(cli.ExitCoder).ExitCode(), "exit code is wrong")
+	assert.Contains(t, buff.String(), "invalid parameters: VLANIdentifierLAN: VLAN ID 9000 is not between 1 and 4094", "validation error should be reported")
+	assert.Equal(t, 1, phone.Parameters.VLANIdentifierLAN, "invalid parameters must not be uploaded")
+}
+
+func TestRollback_EmptyFields(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{FunctionKeys: params.FunctionKeys{{DisplayName: "John"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	journalDir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(journalDir) }()
+
+	_, err = runCommand(setParameters, func(flags *flag.FlagSet) {
+		flags.String(journalFlagName, journalDir, "")
+	}, "FunctionKeys[1].PhoneNumber=20", "TimeServer=ntp.example.com", "CallDivertNoAnswer[0].Target=30", server.URL)
+	require.NoError(t, err, "no error expected")
+	runs, err := journal.New(journalDir).Runs()
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 1, len(runs), "one run expected")
+
+	got, err := runCommand(rollback, func(flags *flag.FlagSet) {
+		flags.String(journalFlagName, journalDir, "")
+	}, runs[0].ID)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tChanged TimeServer: \"ntp.example.com\" -> (unset)\n", "rollback of time server should be reported")
+	assert.Equal(t, "", phone.Parameters.TimeServer, "time server should be emptied again")
+	require.Equal(t, 2, len(phone.Parameters.FunctionKeys), "number of function keys is wrong")
+	assert.Equal(t, "John", phone.Parameters.FunctionKeys[0].DisplayName, "first key should be restored")
+	assert.Equal(t, params.ClearedKey, phone.Parameters.FunctionKeys[1], "second key should be cleared")
+	require.Equal(t, 1, len(phone.Parameters.CallDivertNoAnswer), "number of call diverts is wrong")
+	assert.Equal(t, "", phone.Parameters.CallDivertNoAnswer[0].Target, "target of embedded struct should be emptied again")
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/journal"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// keyEdit determines the new function keys of a phone and the indices of the changed keys.
+type keyEdit func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error)
+
+// functionKeysOperation returns an operation which downloads the parameters of a phone, edits its function keys
+// and uploads them. The indices of the changed keys are reported; phones without changes are not uploaded.
+func functionKeysOperation(ctx context.Context, context *cli.Context, channel chan<- commentedResult, recorder *journalRecorder, a action, edit keyEdit) func(p *tukan.Phone) {
+	dryRun := context.GlobalBool(dryRunFlagName)
+	downloadHandler := actionDownloadParameters.handler(channel)
+	uploadHandler := actionUploadParameters.handler(channel)
+	return func(p *tukan.Phone) {
+		parameters, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		upload, changed, err := edit(p, parameters)
+		if err == nil {
+			upload, changed, err = limitKeys(p, parameters.FunctionKeys, upload)
+		}
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		comment := fmt.Sprintf("%s (changed keys): %v", a.String(), changed)
+		channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: p.Address}, action: a, comment: comment}
+		if len(changed) == 0 {
+			return
+		}
+		changes := params.Diff(&params.Parameters{FunctionKeys: parameters.FunctionKeys}, &params.Parameters{FunctionKeys: upload})
+		err = parameters.CheckChanges(changes)
+		if err == nil {
+			err = parameters.ValidatePatch(&params.Parameters{FunctionKeys: upload})
+		}
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		if dryRun {
+			reportChanges(channel, p.Address, a, changes, true)
+			return
+		}
+		if !recorder.save(ctx, p, journal.Snapshot{Parameters: parameters}) {
+			return
+		}
+		err = p.UploadParametersContext(ctx, params.Parameters{FunctionKeys: upload})
+		uploadHandler(p.Result(err))
+	}
+}
+
+// limitKeys cuts the edited keys to the number of function keys of the phone. An error is returned if keys
+// which are not empty would be cut off, e.g. because they were moved behind the last key, so that they are not lost.
+func limitKeys(p *tukan.Phone, current params.FunctionKeys, keys params.FunctionKeys) (params.FunctionKeys, []int, error) {
+	keys, dropped := keys.Limit(p.FunctionKeys())
+	if len(dropped) == 0 {
+		return keys, params.ChangedKeys(current, keys), nil
+	}
+	names := make([]string, 0, len(dropped))
+	for _, key := range dropped {
+		name := key.DisplayName
+		if name == "" {
+			name = key.PhoneNumber
+		}
+		names = append(names, fmt.Sprintf("\"%s\"", name))
+	}
+	return nil, nil, fmt.Errorf("the phone has only %d function keys, the keys %s would be dropped", p.FunctionKeys(), strings.Join(names, ", "))
+}
+
+// runFunctionKeysEdit runs the edit on all phones of the connector and records it in the journal under the command's name.
+func runFunctionKeysEdit(context *cli.Context, connector *tukan.Connector, command string, edit keyEdit) error {
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, command, channel)
+	operation := functionKeysOperation(ctx, context, channel, recorder, actionEditFunctionKeys, edit)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			operation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+// parseIndex parses the index of a function key or a SIP account, which starts at 0. The noun names
+// what is indexed in error messages.
+func parseIndex(value string, noun string) (int, error) {
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("\"%s\" is not the index of a %s", value, noun)
+	}
+	return index, nil
+}
+
+// parseIndices parses a comma separated list of indices and index ranges, e.g. "0,3-5".
+func parseIndices(value string, noun string) ([]int, error) {
+	result := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseIndex(bounds[0], noun)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = parseIndex(bounds[1], noun)
+			if err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("\"%s\" is not a valid range of indices", part)
+			}
+		}
+		for index := first; index <= last; index++ {
+			result = append(result, index)
+		}
+	}
+	return result, nil
+}
+
+// checkKeyAssignments checks that the assignments name fields of a function key and that their values fit.
+func checkKeyAssignments(assignments map[string]string) error {
+	for field, value := range assignments {
+		path := "FunctionKeys[0]." + field
+		var err error
+		if isTemplate(value) {
+			_, err = (&params.Parameters{}).Get(path)
+			if err == nil {
+				_, err = parseTemplate(value)
+			}
+		} else {
+			err = (&params.Parameters{}).Set(path, value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid assignment %s=%s: %v", field, value, err)
+		}
+	}
+	return nil
+}
+
+// applyKeyAssignments returns the key with the assigned fields, in which templated values are expanded with the variables.
+func applyKeyAssignments(key params.FunctionKey, assignments map[string]string, variables map[string]string) (params.FunctionKey, error) {
+	parameters := params.Parameters{FunctionKeys: params.FunctionKeys{key}}
+	for field, value := range assignments {
+		value, err := expandTemplate(value, variables)
+		if err != nil {
+			return key, err
+		}
+		err = parameters.Set("FunctionKeys[0]."+field, value)
+		if err != nil {
+			return key, err
+		}
+	}
+	return parameters.FunctionKeys[0], nil
+}
+
+// keyArguments parses the arguments of the set and insert commands: the index, the assignments, and the address specifications.
+func keyArguments(context *cli.Context) (int, map[string]string, []string, error) {
+	if context.NArg() == 0 {
+		return 0, nil, nil, fmt.Errorf("the index of the function key is required")
+	}
+	index, err := parseIndex(context.Args().First(), "function key")
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	assignments, specs := splitAssignments(context.Args().Tail())
+	if len(assignments) == 0 {
+		return 0, nil, nil, fmt.Errorf("at least one argument of the form field=value is required")
+	}
+	return index, assignments, specs, checkKeyAssignments(assignments)
+}
+
+func setFunctionKey(context *cli.Context) error {
+	return editKeyWithAssignments(context, "fnkeys-set", false)
+}
+
+func insertFunctionKey(context *cli.Context) error {
+	return editKeyWithAssignments(context, "fnkeys-insert", true)
+}
+
+// editKeyWithAssignments runs the set or the insert command. The set command changes the assigned fields of
+// the key at the index, the insert command inserts a key with the assigned fields at the index.
+func editKeyWithAssignments(context *cli.Context, command string, insert bool) error {
+	index, assignments, specs, err := keyArguments(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	variables, err := loadPhoneVariables(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, specs)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	return runFunctionKeysEdit(context, connector, command, func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		var key params.FunctionKey
+		if !insert && index < len(current.FunctionKeys) && !current.FunctionKeys[index].IsEmpty() {
+			key = current.FunctionKeys[index]
+		}
+		key, err := applyKeyAssignments(key, assignments, variables.lookup(p.Address, current))
+		if err != nil {
+			return nil, nil, err
+		}
+		if insert {
+			keys, changed := current.FunctionKeys.Insert(index, key)
+			return keys, changed, nil
+		}
+		keys, changed := current.FunctionKeys.Set(index, key)
+		return keys, changed, nil
+	})
+}
+
+func clearFunctionKeys(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the indices of the function keys are required", exitCodeError)
+	}
+	indices, err := parseIndices(context.Args().First(), "function key")
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	return runFunctionKeysEdit(context, connector, "fnkeys-clear", func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		keys, changed := current.FunctionKeys.Clear(indices...)
+		return keys, changed, nil
+	})
+}
+
+func shiftFunctionKeys(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the index of the first function key to shift is required", exitCodeError)
+	}
+	index, err := parseIndex(context.Args().First(), "function key")
+	offset := context.Int(byFlagName)
+	if err == nil && index+offset < 0 {
+		err = fmt.Errorf("the function key %d cannot be shifted by %d", index, offset)
+	}
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	return runFunctionKeysEdit(context, connector, "fnkeys-shift", func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		keys, changed := current.FunctionKeys.Shift(index, offset)
+		return keys, changed, nil
+	})
+}
+
+// parseExtensions parses a comma separated list of extensions with optional display names, e.g. "20=Linda,21".
+func parseExtensions(value string) ([][2]string, error) {
+	result := make([][2]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		extension := strings.SplitN(part, "=", 2)
+		number := strings.TrimSpace(extension[0])
+		if number == "" {
+			return nil, fmt.Errorf("\"%s\" does not contain an extension", value)
+		}
+		name := ""
+		if len(extension) == 2 {
+			name = strings.TrimSpace(extension[1])
+		}
+		result = append(result, [2]string{number, name})
+	}
+	return result, nil
+}
+
+func createBLFKeys(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the extensions are required", exitCodeError)
+	}
+	extensions, err := parseExtensions(context.Args().First())
+	if err == nil && context.Int(indexFlagName) < 0 {
+		err = fmt.Errorf("\"%d\" is not the index of a function key", context.Int(indexFlagName))
+	}
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	start := context.Int(indexFlagName)
+	return runFunctionKeysEdit(context, connector, "fnkeys-blf", func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		pickupCode := current.BLFCallPickupCode
+		if context.IsSet(pickupCodeFlagName) {
+			pickupCode = context.String(pickupCodeFlagName)
+		}
+		url := current.BLFURL
+		if context.IsSet(urlFlagName) {
+			url = context.String(urlFlagName)
+		}
+		keys := current.FunctionKeys
+		for position, extension := range extensions {
+			keys, _ = keys.Set(start+position, params.BLFKey(extension[0], extension[1], pickupCode, url))
+		}
+		return keys, params.ChangedKeys(current.FunctionKeys, keys), nil
+	})
+}
+
+func copyFunctionKeys(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	reference, err := loadReferenceParameters(ctx, context, connector, context.String(fromFlagName))
+	stop()
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not load function keys: %v", err), exitCodeError)
+	}
+	return runFunctionKeysEdit(context, connector, "fnkeys-copy", func(p *tukan.Phone, current *params.Parameters) (params.FunctionKeys, []int, error) {
+		keys, changed := current.FunctionKeys.Replace(reference.FunctionKeys)
+		return keys, changed, nil
+	})
+}
+
+func listFunctionKeys(context *cli.Context) error {
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+
+	downloadHandler := actionDownloadParameters.handler(channel)
+	listOperation := func(p *tukan.Phone) {
+		parameters, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		for index, key := range parameters.FunctionKeys {
+			if !key.IsEmpty() {
+				reportPlan(channel, p.Address, actionListFunctionKeys, fmt.Sprintf("FunctionKeys[%d] = %s", index, formatSetting(key)))
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	if format := context.GlobalString(outputFlagName); format != "" && format != outputText {
+		go handleResults(&wg, channel, context, summary)
+	} else {
+		go printFunctionKeysTable(&wg, channel, context, summary)
+	}
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			listOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	return summary.exitError()
+}
+
+// keyTypeNames are the names of the function key types shown by the list command.
+var keyTypeNames = map[params.KeyType]string{
+	params.KeyTypeSpeedDial: "speed dial",
+	params.KeyTypeBLF:       "BLF",
+}
+
+// printFunctionKeysTable prints the keys reported by the list command as table with one row per key.
+// If a phone failed, its only row contains the first error.
+func printFunctionKeysTable(wg *sync.WaitGroup, channel chan commentedResult, context *cli.Context, summary *runSummary) {
+	defer wg.Done()
+	rows := make(map[string][]string)
+	failures := make(map[string]string)
+	for result := range channel {
+		summary.add(result)
+		if _, present := rows[result.Address]; !present {
+			rows[result.Address] = make([]string, 0)
+		}
+		if result.Error != nil && failures[result.Address] == "" {
+			failures[result.Address] = result.comment
+		}
+		if result.action != actionListFunctionKeys {
+			continue
+		}
+		parts := strings.SplitN(result.comment, " = ", 2)
+		var key params.FunctionKey
+		_ = json.Unmarshal([]byte(parts[1]), &key)
+		index := strings.TrimSuffix(strings.TrimPrefix(parts[0], "FunctionKeys["), "]")
+		keyType := string(key.Type)
+		if name, ok := keyTypeNames[key.Type]; ok {
+			keyType = name
+		}
+		rows[result.Address] = append(rows[result.Address], strings.Join([]string{index, keyType, key.DisplayName, key.PhoneNumber, key.CallPickupCode}, "\t"))
+	}
+	addresses := make([]string, 0, len(rows))
+	for address := range rows {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	writer := tabwriter.NewWriter(context.App.Writer, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "Address\tKey\tType\tName\tNumber\tPickup Code\n")
+	for _, address := range addresses {
+		if failure, failed := failures[address]; failed {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\n", address, failure)
+			continue
+		}
+		for _, row := range rows[address] {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\n", address, row)
+		}
+	}
+	_ = writer.Flush()
+}
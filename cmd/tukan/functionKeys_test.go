@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// runCommand runs the command against the mock phones with the flags defined by setup and returns its output.
+func runCommand(command func(*cli.Context) error, setup func(flags *flag.FlagSet), args ...string) (string, error) {
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	if setup != nil {
+		setup(flags)
+	}
+	_ = flags.Parse(args)
+	var buff bytes.Buffer
+	err := command(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+	return buff.String(), err
+}
+
+func testFunctionKeys() params.FunctionKeys {
+	return params.FunctionKeys{
+		{Type: params.KeyTypeSpeedDial, DisplayName: "John", PhoneNumber: "20"},
+		{Type: params.KeyTypeSpeedDial, DisplayName: "Linda", PhoneNumber: "21"},
+	}
+}
+
+func TestListFunctionKeys(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Parameters = params.Parameters{FunctionKeys: params.FunctionKeys{{Type: params.KeyTypeBLF, DisplayName: "Linda", PhoneNumber: "21", CallPickupCode: "*8"}, {}, {Type: "7", PhoneNumber: "22"}}}
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, _ := mock.CreatePhone(username, password)
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	got, err := runCommand(listFunctionKeys, nil, server1.URL, server2.URL)
+
+	require.NoError(t, err, "no error expected")
+	rows := make([][]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	assert.Equal(t, [][]string{
+		{"Address", "Key", "Type", "Name", "Number", "Pickup", "Code"},
+		{server1.URL, "0", "BLF", "Linda", "21", "*8"},
+		{server1.URL, "2", "7", "22"},
+	}, rows, "table is wrong")
+}
+
+func TestSetFunctionKey(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{FunctionKeys: testFunctionKeys()}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	got, err := runCommand(setFunctionKey, nil, "1", "DisplayName=Eva", server.URL, "PhoneNumber=22")
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tEditing Function Keys (changed keys): [1]\n", "changed keys should be reported")
+	assert.Equal(t, params.FunctionKey{Type: params.KeyTypeSpeedDial, DisplayName: "Eva", PhoneNumber: "22"}, phone.Parameters.FunctionKeys[1], "key is wrong")
+	assert.Equal(t, testFunctionKeys()[0], phone.Parameters.FunctionKeys[0], "first key must not be changed")
+
+	got, err = runCommand(setFunctionKey, nil, "1", "Colour=red", server.URL)
+	assert.EqualError(t, err, "invalid assignment Colour=red: unknown field \"Colour\" in path \"FunctionKeys[0].Colour\"", "message is wrong")
+
+	got, err = runCommand(setFunctionKey, nil, "--", "-1", "DisplayName=Eva", server.URL)
+	assert.EqualError(t, err, "\"-1\" is not the index of a function key", "message is wrong")
+}
+
+func TestClearFunctionKeys(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{FunctionKeys: testFunctionKeys()}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	got, err := runCommand(clearFunctionKeys, nil, "0,3-4", server.URL)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tEditing Function Keys (changed keys): [0]\n", "changed keys should be reported")
+	assert.Equal(t, params.FunctionKeys{params.ClearedKey, testFunctionKeys()[1]}, phone.Parameters.FunctionKeys, "keys are wrong")
+}
+
+func TestInsertAndShiftFunctionKeys(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{FunctionKeys: append(testFunctionKeys(), params.ClearedKey)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	keys := testFunctionKeys()
+
+	got, err := runCommand(insertFunctionKey, nil, "0", "PhoneNumber=19", server.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tEditing Function Keys (changed keys): [0 1 2]\n", "changed keys should be reported")
+	assert.Equal(t, params.FunctionKeys{{PhoneNumber: "19"}, keys[0], keys[1]}, phone.Parameters.FunctionKeys, "keys are wrong")
+
+	by := func(offset string) func(*flag.FlagSet) {
+		return func(flags *flag.FlagSet) {
+			flags.Int(byFlagName, 1, "")
+			_ = flags.Set(byFlagName, offset)
+		}
+	}
+	got, err = runCommand(shiftFunctionKeys, by("-1"), "1", server.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tEditing Function Keys (changed keys): [0 1 2]\n", "changed keys should be reported")
+	assert.Equal(t, params.FunctionKeys{keys[0], keys[1], params.ClearedKey}, phone.Parameters.FunctionKeys, "keys are wrong")
+
+	got, err = runCommand(shiftFunctionKeys, by("-2"), "1", server.URL)
+	assert.EqualError(t, err, "the function key 1 cannot be shifted by -2", "message is wrong")
+
+	got, err = runCommand(shiftFunctionKeys, by("2"), "0", server.URL)
+	assert.Error(t, err, "error expected")
+	assert.Contains(t, got, "the phone has only 3 function keys, the keys \"Linda\" would be dropped", "dropped keys should be reported")
+	assert.Equal(t, params.FunctionKeys{keys[0], keys[1], params.ClearedKey}, phone.Parameters.FunctionKeys, "nothing should be uploaded")
+}
+
+func TestCreateBLFKeys(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{BLFCallPickupCode: "*8", BLFURL: "http://pbx.example.com/blf", FunctionKeys: append(testFunctionKeys(), params.FunctionKey{})}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	got, err := runCommand(createBLFKeys, func(flags *flag.FlagSet) {
+		flags.Int(indexFlagName, 0, "")
+		flags.String(pickupCodeFlagName, "", "")
+		flags.String(urlFlagName, "", "")
+	}, "-index", "1", "-pickup-code", "*9", "30=Max, 31", server.URL)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tEditing Function Keys (changed keys): [1 2]\n", "changed keys should be reported")
+	assert.Equal(t, params.FunctionKeys{
+		testFunctionKeys()[0],
+		{Type: params.KeyTypeBLF, DisplayName: "Max", PhoneNumber: "30", CallPickupCode: "*9", Url: "http://pbx.example.com/blf"},
+		{Type: params.KeyTypeBLF, DisplayName: "31", PhoneNumber: "31", CallPickupCode: "*9", Url: "http://pbx.example.com/blf"},
+	}, phone.Parameters.FunctionKeys, "keys are wrong")
+}
+
+func TestCopyFunctionKeys(t *testing.T) {
+	sourceHandler, source := mock.CreatePhone(username, password)
+	source.Parameters = params.Parameters{FunctionKeys: params.FunctionKeys{{Type: params.KeyTypeBLF, PhoneNumber: "30"}}}
+	sourceServer := httptest.NewServer(sourceHandler)
+	defer sourceServer.Close()
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Parameters = params.Parameters{FunctionKeys: testFunctionKeys()}
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	phone2.Parameters = params.Parameters{FunctionKeys: source.Parameters.FunctionKeys}
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	got, err := runCommand(copyFunctionKeys, func(flags *flag.FlagSet) {
+		flags.String(fromFlagName, sourceServer.URL, "")
+	}, server1.URL, server2.URL)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, server1.URL+":\n\tLogin successful\n\tDownloading Parameters successful\n\tEditing Function Keys (changed keys): [0 1]\n", "changed keys should be reported")
+	assert.Contains(t, got, server2.URL+":\n\tLogin successful\n\tDownloading Parameters successful\n\tEditing Function Keys (changed keys): []\n\tLogout successful\n", "unchanged phone should not be uploaded")
+	assert.Equal(t, params.FunctionKeys{source.Parameters.FunctionKeys[0], params.ClearedKey}, phone1.Parameters.FunctionKeys, "keys are wrong")
+	assert.Nil(t, source.Token, "source phone should be logged out")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/inventory"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+)
+
+// phoneInventory holds the devices of the --inventory file together with the addresses they expand to.
+type phoneInventory struct {
+	devices   []inventory.Device
+	addresses [][]string
+	byAddress map[string]*inventory.Device
+}
+
+// loadInventory reads the --inventory file and expands the addresses of its devices. The inventory is read
+// only once per command and kept in the metadata of the app. Without --inventory, the inventory is empty.
+func loadInventory(context *cli.Context) (*phoneInventory, error) {
+	if context.App != nil {
+		if loaded, ok := context.App.Metadata[inventoryFlagName].(*phoneInventory); ok {
+			return loaded, nil
+		}
+	}
+	result := &phoneInventory{byAddress: make(map[string]*inventory.Device)}
+	if path := context.GlobalString(inventoryFlagName); path != "" {
+		fleet, err := inventory.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load inventory: %v", err)
+		}
+		result.devices = fleet.Devices
+		result.addresses = make([][]string, len(fleet.Devices))
+		for index := range result.devices {
+			device := &result.devices[index]
+			addresses, err := parseAddresses(context, device.Address)
+			if err != nil {
+				name := device.Name
+				if name == "" {
+					name = device.Address
+				}
+				return nil, fmt.Errorf("phone \"%s\" of the inventory: %v", name, err)
+			}
+			result.addresses[index] = addresses
+			for _, address := range addresses {
+				result.byAddress[address] = device
+			}
+		}
+	}
+	if context.App != nil {
+		if context.App.Metadata == nil {
+			context.App.Metadata = make(map[string]interface{})
+		}
+		context.App.Metadata[inventoryFlagName] = result
+	}
+	return result, nil
+}
+
+// device returns the device of the inventory with the address. If several devices expand to
+// the address, the last one is returned.
+func (p *phoneInventory) device(address string) (*inventory.Device, bool) {
+	device, ok := p.byAddress[address]
+	return device, ok
+}
+
+// name returns the name of the device with the address, or an empty string.
+func (p *phoneInventory) name(address string) string {
+	if device, ok := p.device(address); ok {
+		return device.Name
+	}
+	return ""
+}
+
+// tags returns the tags of the device with the address.
+func (p *phoneInventory) tags(address string) []string {
+	if device, ok := p.device(address); ok {
+		return device.Tags
+	}
+	return nil
+}
+
+// inventoryTargets returns the address specifications of the inventory's devices selected by
+// the tag flags, as well as the credentials of the addresses these specifications expand to.
+func inventoryTargets(context *cli.Context) ([]string, map[string]params.Credentials, error) {
+	tags := context.GlobalStringSlice(tagFlagName)
+	if context.GlobalString(inventoryFlagName) == "" {
+		if len(tags) != 0 {
+			return nil, nil, fmt.Errorf("--%s requires --%s", tagFlagName, inventoryFlagName)
+		}
+		return nil, nil, nil
+	}
+	fleet, err := loadInventory(context)
+	if err != nil {
+		return nil, nil, err
+	}
+	specs := make([]string, 0, len(fleet.devices))
+	credentials := make(map[string]params.Credentials)
+	for index, device := range fleet.devices {
+		if !device.HasTags(tags...) {
+			continue
+		}
+		specs = append(specs, device.Address)
+		for _, address := range fleet.addresses[index] {
+			own, ok := fleet.device(address)
+			if !ok || (own.Login == "" && own.Password == "") {
+				continue
+			}
+			login, password := own.Login, own.Password
+			if login == "" {
+				login = context.GlobalString(loginFlagName)
+			}
+			if password == "" {
+				password = context.GlobalString(passwordFlagName)
+			}
+			credentials[address] = params.Credentials{Login: login, Password: password}
+		}
+	}
+	return specs, credentials, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeInventory(t *testing.T, content string) string {
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d.yaml", rand.Int()))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), os.ModePerm), "no error expected")
+	t.Cleanup(func() { _ = os.Remove(path) })
+	return path
+}
+
+func TestLoadInventory(t *testing.T) {
+	path := writeInventory(t, `phones:
+  - address: 10.20.30.40+1
+    name: Reception
+    tags: [floor3]
+  - address: 10.20.30.50
+`)
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(inventoryFlagName, path, "")
+	context := cli.NewContext(&cli.App{Writer: &bytes.Buffer{}}, flags, nil)
+
+	fleet, err := loadInventory(context)
+
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, "Reception", fleet.name("http://10.20.30.41:80"), "name is wrong")
+	assert.Equal(t, []string{"floor3"}, fleet.tags("http://10.20.30.40:80"), "tags are wrong")
+	assert.Equal(t, "", fleet.name("http://10.20.30.50:80"), "device without name should have no name")
+	require.NoError(t, os.Remove(path), "no error expected")
+	again, err := loadInventory(context)
+	require.NoError(t, err, "inventory should not be read again")
+	assert.Same(t, fleet, again, "inventory should be loaded once per command")
+}
+
+func TestLoadInventory_InvalidAddress(t *testing.T) {
+	path := writeInventory(t, "phones:\n  - address: 10.20.30.40:http\n    name: Reception\n")
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(inventoryFlagName, path, "")
+
+	_, err := loadInventory(cli.NewContext(&cli.App{}, flags, nil))
+
+	assert.EqualError(t, err, "phone \"Reception\" of the inventory: invalid address \"10.20.30.40:http\": port must be a number between 1 and 65535", "error is wrong")
+}
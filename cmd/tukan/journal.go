@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/journal"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// journalRecorder saves the state of the phones into the journal before a command changes them.
+// In a dry run, nothing is saved.
+type journalRecorder struct {
+	run     *journal.Run
+	backup  bool
+	handler func(*tukan.PhoneResult)
+}
+
+func newJournalRecorder(context *cli.Context, command string, channel chan<- commentedResult) *journalRecorder {
+	recorder := &journalRecorder{backup: context.GlobalBool(journalBackupFlagName), handler: actionSaveSnapshot.handler(channel)}
+	if !context.GlobalBool(dryRunFlagName) {
+		recorder.run = journal.New(journalDir(context)).Start(command)
+	}
+	return recorder
+}
+
+// save records the snapshot of the phone. If the snapshot contains neither parameters nor
+// a phone book, the parameters are downloaded; with --journal-backup, a backup is downloaded as well.
+// If false is returned, the snapshot could not be saved and the phone must not be changed.
+func (j *journalRecorder) save(ctx context.Context, p *tukan.Phone, snapshot journal.Snapshot) bool {
+	if j.run == nil {
+		return true
+	}
+	snapshot.Address = p.Address
+	var err error
+	if snapshot.Parameters == nil && snapshot.PhoneBook == nil {
+		snapshot.Parameters, err = p.DownloadParametersContext(ctx)
+	}
+	if err == nil && j.backup {
+		snapshot.Backup, err = p.BackupContext(ctx)
+	}
+	if err == nil {
+		err = j.run.Save(snapshot)
+	}
+	if err != nil {
+		j.handler(p.Result(err))
+		return false
+	}
+	return true
+}
+
+// savePhoneBook is like save, but records the phone book of the phone.
+func (j *journalRecorder) savePhoneBook(ctx context.Context, p *tukan.Phone) bool {
+	if j.run == nil {
+		return true
+	}
+	book, err := p.DownloadPhoneBookContext(ctx)
+	if err != nil {
+		j.handler(p.Result(err))
+		return false
+	}
+	return j.save(ctx, p, journal.Snapshot{PhoneBook: book})
+}
+
+// finish tells the user the ID of the run, which is needed for a rollback.
+func (j *journalRecorder) finish(context *cli.Context) {
+	if j.run == nil || j.run.Len() == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(errWriter(context), "Saved the previous state of %d phones, roll back with: tukan rollback %s\n", j.run.Len(), j.run.ID())
+}
+
+func journalDir(context *cli.Context) string {
+	if dir := context.GlobalString(journalFlagName); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".tukan", "journal")
+	}
+	return filepath.Join(home, ".tukan", "journal")
+}
+
+func errWriter(context *cli.Context) io.Writer {
+	if context.App.ErrWriter != nil {
+		return context.App.ErrWriter
+	}
+	return os.Stderr
+}
+
+func rollback(context *cli.Context) error {
+	runs := journal.New(journalDir(context))
+	if context.NArg() == 0 {
+		infos, err := runs.Runs()
+		if err != nil {
+			return cli.NewExitError(err, exitCodeError)
+		}
+		for _, info := range infos {
+			_, _ = fmt.Fprintf(context.App.Writer, "%s\t%s\t%d phones\n", info.ID, info.Command, info.Phones)
+		}
+		return nil
+	}
+	id := context.Args().First()
+	snapshots, err := runs.Snapshots(id)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not read journal: %v", err), exitCodeError)
+	}
+	connector, err := createConnectorFor(context, nil)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	byAddress := make(map[string]journal.Snapshot)
+	connector.Addresses = make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		byAddress[snapshot.Address] = snapshot
+		connector.Addresses = append(connector.Addresses, snapshot.Address)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	dryRun := context.GlobalBool(dryRunFlagName)
+	fromBackup := context.Bool(fromBackupFlagName)
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "rollback "+id, channel)
+
+	downloadBookHandler := actionDownloadPhoneBook.handler(channel)
+	uploadBookHandler := actionUploadPhoneBook.handler(channel)
+	downloadHandler := actionDownloadParameters.handler(channel)
+	uploadHandler := actionUploadParameters.handler(channel)
+	rollbackOperation := func(p *tukan.Phone) {
+		snapshot := byAddress[p.Address]
+		if snapshot.PhoneBook != nil {
+			book, err := p.DownloadPhoneBookContext(ctx)
+			downloadBookHandler(p.Result(err))
+			if err != nil {
+				return
+			}
+			if *book == *snapshot.PhoneBook {
+				reportPlan(channel, p.Address, actionRollback, "Phone book unchanged")
+			} else if dryRun {
+				reportPlan(channel, p.Address, actionRollback, "Would restore the phone book")
+			} else if recorder.save(ctx, p, journal.Snapshot{PhoneBook: book}) {
+				err = p.UploadPhoneBookContext(ctx, *snapshot.PhoneBook)
+				uploadBookHandler(p.Result(err))
+			}
+		}
+		if fromBackup && snapshot.Backup != nil {
+			if dryRun {
+				reportPlan(channel, p.Address, actionRollback, "Would restore the backup")
+				return
+			}
+			if !recorder.save(ctx, p, journal.Snapshot{}) {
+				return
+			}
+			err := p.RestoreContext(ctx, snapshot.Backup)
+			uploadHandler(p.Result(err))
+			return
+		}
+		if snapshot.Parameters == nil {
+			return
+		}
+		current, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		restore, changes := params.Restore(current, snapshot.Parameters)
+		if len(changes) == 0 {
+			reportPlan(channel, p.Address, actionRollback, "Unchanged")
+			return
+		}
+		err = current.ValidateRestore(&restore)
+		if err != nil {
+			uploadHandler(p.Result(err))
+			return
+		}
+		if !dryRun {
+			if !recorder.save(ctx, p, journal.Snapshot{Parameters: current}) {
+				return
+			}
+			err = p.UploadParametersContext(ctx, restore)
+			uploadHandler(p.Result(err))
+			if err != nil {
+				return
+			}
+		}
+		reportChanges(channel, p.Address, actionRollback, changes, dryRun)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			rollbackOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
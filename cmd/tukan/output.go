@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/fafeitsch/Tukan/tukan"
+	"io"
+	"sort"
+	"strconv"
+)
+
+const outputText = "text"
+const outputJSON = "json"
+const outputJSONLines = "jsonl"
+const outputCSV = "csv"
+
+var outputFormats = []string{outputText, outputJSON, outputJSONLines, outputCSV}
+
+var csvHeader = []string{"address", "action", "success", "errorClass", "error", "durationMs", "attempts", "message", "name"}
+
+func isOutputFormat(format string) bool {
+	for _, candidate := range outputFormats {
+		if candidate == format {
+			return true
+		}
+	}
+	return format == ""
+}
+
+// outputRecord is the machine-readable form of a commentedResult.
+type outputRecord struct {
+	Address    string           `json:"address"`
+	Action     string           `json:"action"`
+	Success    bool             `json:"success"`
+	ErrorClass tukan.ErrorClass `json:"errorClass,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	DurationMs int64            `json:"durationMs"`
+	Attempts   int              `json:"attempts"`
+	Message    string           `json:"message"`
+	Name       string           `json:"name,omitempty"`
+}
+
+func newOutputRecord(result commentedResult, name string) outputRecord {
+	record := outputRecord{
+		Address:    result.Address,
+		Action:     result.action.id(),
+		Success:    result.Error == nil,
+		ErrorClass: tukan.Classify(result.Error),
+		DurationMs: result.Duration.Milliseconds(),
+		Attempts:   result.Attempts,
+		Message:    result.comment,
+		Name:       name,
+	}
+	if result.Error != nil {
+		record.Error = result.Error.Error()
+	}
+	return record
+}
+
+func (o outputRecord) csv() []string {
+	return []string{o.Address, o.Action, strconv.FormatBool(o.Success), string(o.ErrorClass), o.Error,
+		strconv.FormatInt(o.DurationMs, 10), strconv.Itoa(o.Attempts), o.Message, o.Name}
+}
+
+// writeRecords writes one record per result in the given format, carrying the inventory name
+// of the phone if there is one. JSON lines are written as soon as a result arrives; the other
+// formats are written sorted by address when the channel is closed.
+func writeRecords(writer io.Writer, format string, channel <-chan commentedResult, fleet *phoneInventory, summary *runSummary) {
+	records := make([]outputRecord, 0)
+	encoder := json.NewEncoder(writer)
+	for result := range channel {
+		summary.add(result)
+		record := newOutputRecord(result, fleet.name(result.Address))
+		if format == outputJSONLines {
+			_ = encoder.Encode(record)
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Address < records[j].Address
+	})
+	switch format {
+	case outputJSON:
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(records)
+	case outputCSV:
+		csvWriter := csv.NewWriter(writer)
+		_ = csvWriter.Write(csvHeader)
+		for _, record := range records {
+			_ = csvWriter.Write(record.csv())
+		}
+		csvWriter.Flush()
+	}
+}
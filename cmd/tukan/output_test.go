@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func runScanWithOutput(t *testing.T, format string) (string, string, string, error) {
+	handler1, _ := mock.CreatePhone(username, password)
+	server1 := httptest.NewServer(handler1)
+	t.Cleanup(server1.Close)
+
+	handler2, _ := mock.CreatePhone(username, "secret")
+	server2 := httptest.NewServer(handler2)
+	t.Cleanup(server2.Close)
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(outputFlagName, format, "")
+	_ = flags.Parse([]string{server1.URL, server2.URL})
+
+	var buff bytes.Buffer
+	ctx := cli.NewContext(&cli.App{Writer: &buff}, flags, nil)
+	err := scan(ctx)
+	return buff.String(), server1.URL, server2.URL, err
+}
+
+func TestOutput_JSON(t *testing.T) {
+	got, url1, url2, _ := runScanWithOutput(t, outputJSON)
+
+	var records []outputRecord
+	require.NoError(t, json.Unmarshal([]byte(got), &records), "output should be a JSON array")
+	require.Equal(t, 3, len(records), "number of records is wrong")
+	byAddress := make(map[string][]outputRecord)
+	for _, record := range records {
+		byAddress[record.Address] = append(byAddress[record.Address], record)
+	}
+	require.Equal(t, 2, len(byAddress[url1]), "login and logout of the first phone expected")
+	assert.Equal(t, "login", byAddress[url1][0].Action, "action is wrong")
+	assert.True(t, byAddress[url1][0].Success, "login should be successful")
+	assert.Equal(t, tukan.ErrorClassNone, byAddress[url1][0].ErrorClass, "error class is wrong")
+	assert.Equal(t, "logout", byAddress[url1][1].Action, "action is wrong")
+	require.Equal(t, 1, len(byAddress[url2]), "only a login of the second phone expected")
+	assert.False(t, byAddress[url2][0].Success, "login should fail")
+	assert.Equal(t, tukan.ErrorClassAuth, byAddress[url2][0].ErrorClass, "error class is wrong")
+	assert.Contains(t, byAddress[url2][0].Error, "authentication error", "error is wrong")
+	assert.Equal(t, 1, byAddress[url2][0].Attempts, "attempts are wrong")
+}
+
+func TestOutput_JSONLines(t *testing.T) {
+	got, _, _, _ := runScanWithOutput(t, outputJSONLines)
+
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	require.Equal(t, 3, len(lines), "one line per record expected")
+	for _, line := range lines {
+		var record outputRecord
+		assert.NoError(t, json.Unmarshal([]byte(line), &record), "each line should be a JSON object")
+	}
+}
+
+func TestOutput_CSV(t *testing.T) {
+	got, _, url2, _ := runScanWithOutput(t, outputCSV)
+
+	rows, err := csv.NewReader(strings.NewReader(got)).ReadAll()
+	require.NoError(t, err, "output should be valid CSV")
+	require.Equal(t, 4, len(rows), "header and three records expected")
+	assert.Equal(t, csvHeader, rows[0], "header is wrong")
+	var failed []string
+	for _, row := range rows[1:] {
+		if row[2] == "false" {
+			failed = row
+		}
+	}
+	require.NotNil(t, failed, "a failed record is expected")
+	assert.Equal(t, []string{url2, "login", "false", "auth"}, failed[:4], "failed record is wrong")
+}
+
+func TestOutput_Invalid(t *testing.T) {
+	got, _, _, err := runScanWithOutput(t, "xml")
+
+	assert.EqualError(t, err, "could not create connector: unsupported output format \"xml\", want one of text, json, jsonl, csv", "error is wrong")
+	assert.Empty(t, got, "errors must not be printed to the output")
+}
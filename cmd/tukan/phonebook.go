@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/fafeitsch/Tukan/tukan/phonebook"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var countryCodePattern = regexp.MustCompile(`^\+?[0-9]+$`)
+
+// readImport reads the contacts of the --file in the format of --from, or guessed from the file's extension.
+func readImport(context *cli.Context) (*phonebook.Import, error) {
+	path := context.String(fileFlagName)
+	format := strings.ToLower(context.String(fromFlagName))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	mapping, err := phonebook.ParseMapping(context.String(mapFlagName))
+	if err != nil {
+		return nil, err
+	}
+	if format != "csv" && len(mapping) != 0 {
+		return nil, fmt.Errorf("--%s is only supported for CSV files", mapFlagName)
+	}
+	importers := map[string]func(file *os.File) (*phonebook.Import, error){
+		"csv":   func(file *os.File) (*phonebook.Import, error) { return phonebook.ImportCSV(file, mapping) },
+		"vcf":   func(file *os.File) (*phonebook.Import, error) { return phonebook.ImportVCard(file) },
+		"vcard": func(file *os.File) (*phonebook.Import, error) { return phonebook.ImportVCard(file) },
+		"ldif":  func(file *os.File) (*phonebook.Import, error) { return phonebook.ImportLDIF(file) },
+	}
+	importer, ok := importers[format]
+	if !ok {
+		return nil, fmt.Errorf("the format \"%s\" is unknown, expected csv, vcf or ldif", format)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return importer(file)
+}
+
+// normalizerFor returns the normalizer for the numbers of the phone. The country and area codes are taken from the
+// flags, or else from the area code settings of the phone: the country code from AreaCodesCountry if it is numeric,
+// otherwise from AreaCodesIntCode, and the area code from AreaCodesLocalCode.
+func normalizerFor(context *cli.Context, current *params.Parameters) phonebook.Normalizer {
+	normalizer := phonebook.Normalizer{
+		CountryCode:         context.String(countryCodeFlagName),
+		AreaCode:            context.String(areaCodeFlagName),
+		InternationalPrefix: current.AreaCodesIntPrefix,
+		TrunkPrefix:         current.AreaCodesLocalPrefix,
+		ExtensionLength:     context.Int(extensionLengthFlagName),
+	}
+	if normalizer.CountryCode == "" {
+		for _, code := range []string{current.AreaCodesCountry, current.AreaCodesIntCode} {
+			if countryCodePattern.MatchString(code) {
+				normalizer.CountryCode = code
+				break
+			}
+		}
+	}
+	if normalizer.AreaCode == "" {
+		normalizer.AreaCode = current.AreaCodesLocalCode
+	}
+	if normalizer.InternationalPrefix == "" {
+		normalizer.InternationalPrefix = "00"
+	}
+	if normalizer.TrunkPrefix == "" {
+		normalizer.TrunkPrefix = "0"
+	}
+	return normalizer
+}
+
+// phonebookOperation uploads the phone book to the phone after it has been validated. In a dry run, the phone book of
+// the phone is downloaded and it is only reported whether it would change. Otherwise, the current phone book is saved
+// into the journal before.
+func phonebookOperation(ctx context.Context, context *cli.Context, channel chan<- commentedResult, recorder *journalRecorder, a action, p *tukan.Phone, book *phonebook.Phonebook) {
+	handler := a.handler(channel)
+	err := book.Validate()
+	if err != nil {
+		handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+		return
+	}
+	if context.GlobalBool(dryRunFlagName) {
+		content, err := book.Serialize()
+		if err != nil {
+			handler(&tukan.PhoneResult{Address: p.Address, Error: err})
+			return
+		}
+		current, err := p.DownloadPhoneBookContext(ctx)
+		actionDownloadPhoneBook.handler(channel)(p.Result(err))
+		if err != nil {
+			return
+		}
+		if samePhonebook(*current, content) {
+			reportPlan(channel, p.Address, a, "Phone book unchanged")
+		} else {
+			reportPlan(channel, p.Address, a, fmt.Sprintf("Would upload the phone book with %d entries", len(book.Entries)))
+		}
+		return
+	}
+	if !recorder.savePhoneBook(ctx, p) {
+		return
+	}
+	err = p.UploadLocalPhonebookContext(ctx, book)
+	handler(p.Result(err))
+}
+
+// samePhonebook returns true if the downloaded phone book has the same content as the serialized one,
+// regardless of its formatting.
+func samePhonebook(downloaded string, serialized []byte) bool {
+	book, err := phonebook.Parse([]byte(downloaded))
+	if err != nil {
+		return false
+	}
+	content, err := book.Serialize()
+	return err == nil && bytes.Equal(content, serialized)
+}
+
+// overlayFileName returns the name of the file with the overlay for the phones carrying the tag.
+func overlayFileName(tag string) string {
+	return "tag_" + tag + ".xml"
+}
+
+func readPhonebookFile(path string) (*phonebook.Phonebook, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	book, err := phonebook.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return book, nil
+}
+
+// phoneOverlays reads the overlays of the phone from the directory: first those of its tags in the order of the tags,
+// then the one of the phone itself. Missing files are skipped, as well as all overlays if the directory is empty.
+func phoneOverlays(directory string, address string, tags []string) ([]*phonebook.Phonebook, error) {
+	if directory == "" {
+		return nil, nil
+	}
+	names := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		names = append(names, overlayFileName(tag))
+	}
+	names = append(names, phoneBookFileName(address))
+	overlays := make([]*phonebook.Phonebook, 0, len(names))
+	for _, name := range names {
+		book, err := readPhonebookFile(filepath.Join(directory, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, book)
+	}
+	return overlays, nil
+}
+
+func uploadSharedPhoneBook(context *cli.Context) error {
+	shared, err := readPhonebookFile(context.String(sharedFlagName))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not read shared phone book: %v", err), exitCodeError)
+	}
+	fleet, err := loadInventory(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "pb-up", channel)
+
+	overlayDirectory := context.String(overlayDirFlagName)
+	uploadHandler := actionUploadPhoneBook.handler(channel)
+	upload := func(p *tukan.Phone) {
+		overlays, err := phoneOverlays(overlayDirectory, p.Address, fleet.tags(p.Address))
+		if err != nil {
+			uploadHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+			return
+		}
+		book := phonebook.Overlay(shared, overlays...)
+		if removed := book.Deduplicate(); removed != 0 {
+			reportPlan(channel, p.Address, actionUploadPhoneBook, fmt.Sprintf("Removed %d duplicate numbers", removed))
+		}
+		phonebookOperation(ctx, context, channel, recorder, actionUploadPhoneBook, p, book)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel),
+		upload,
+		actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+func importPhoneBook(context *cli.Context) error {
+	imported, err := readImport(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not import phone book: %v", err), exitCodeError)
+	}
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "pb-import", channel)
+
+	downloadHandler := actionDownloadParameters.handler(channel)
+	upload := func(p *tukan.Phone) {
+		current, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		book, skipped := imported.Phonebook(normalizerFor(context, current))
+		for _, record := range skipped {
+			reportPlan(channel, p.Address, actionImportPhoneBook, fmt.Sprintf("Skipped %s", record))
+		}
+		phonebookOperation(ctx, context, channel, recorder, actionImportPhoneBook, p, book)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel),
+		upload,
+		actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+func mergePhoneBook(context *cli.Context) error {
+	rule := phonebook.ConflictRule(context.String(conflictFlagName))
+	if !rule.Valid() {
+		return cli.NewExitError(fmt.Sprintf("the conflict rule \"%s\" is unknown, expected %s, %s or %s", rule, phonebook.CentralWins, phonebook.LocalWins, phonebook.KeepBoth), exitCodeError)
+	}
+	central, err := readPhonebookFile(context.String(centralFlagName))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not read central directory: %v", err), exitCodeError)
+	}
+	previous := &phonebook.Phonebook{}
+	if path := context.String(previousFlagName); path != "" {
+		previous, err = readPhonebookFile(path)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("could not read previous central directory: %v", err), exitCodeError)
+		}
+	}
+	connector, err := createConnector(context)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "pb-merge", channel)
+
+	downloadHandler := actionDownloadPhoneBook.handler(channel)
+	mergeHandler := actionMergePhoneBook.handler(channel)
+	merge := func(p *tukan.Phone) {
+		local, err := p.DownloadLocalPhonebookContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		original, err := local.Serialize()
+		if err != nil {
+			mergeHandler(&tukan.PhoneResult{Address: p.Address, Error: err})
+			return
+		}
+		local.Remove(previous)
+		book, conflicts := phonebook.Merge(central, local, rule)
+		if conflicts != 0 {
+			reportPlan(channel, p.Address, actionMergePhoneBook, fmt.Sprintf("Found %d numbers both in the central directory and in entries of the phone (rule: %s)", conflicts, rule))
+		}
+		if merged, err := book.Serialize(); err == nil && bytes.Equal(merged, original) {
+			reportPlan(channel, p.Address, actionMergePhoneBook, "Phone book unchanged")
+			return
+		}
+		phonebookOperation(ctx, context, channel, recorder, actionMergePhoneBook, p, book)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.RunContext(ctx, actionLogin.handler(channel),
+		merge,
+		actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
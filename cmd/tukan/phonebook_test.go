@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/fafeitsch/Tukan/tukan/phonebook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func importFlags(file string, from string) func(flags *flag.FlagSet) {
+	return func(flags *flag.FlagSet) {
+		flags.String(fileFlagName, file, "")
+		flags.String(fromFlagName, from, "")
+		flags.String(mapFlagName, "", "")
+		flags.String(countryCodeFlagName, "", "")
+		flags.String(areaCodeFlagName, "", "")
+		flags.Int(extensionLengthFlagName, 4, "")
+	}
+}
+
+func TestImportPhoneBook(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{AreaCodesCountry: "Other", AreaCodesIntCode: "49", AreaCodesLocalCode: "30"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	file := filepath.Join(dir, "contacts.csv")
+	contacts := "LastName,FirstName,Office,Mobile\nDoe,John,1234567,0170 1234567\nMajor,Mary,,\nReception,,20,\n"
+	require.NoError(t, ioutil.WriteFile(file, []byte(contacts), os.ModePerm), "no error expected")
+
+	got, err := runCommand(importPhoneBook, importFlags(file, ""), server.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tDownloading Parameters successful\n\tSkipped record 2 (Major, Mary): the entry has no number\n\tImporting Phone Book successful\n", "output is wrong")
+	book, err := phonebook.Parse([]byte(phone.Phonebook))
+	require.NoError(t, err, "uploaded phone book should be parseable")
+	require.Equal(t, 2, len(book.Entries), "number of entries is wrong")
+	assert.Equal(t, []phonebook.Number{{Type: phonebook.NumberOffice, Number: "+49301234567"}, {Type: phonebook.NumberMobile, Number: "+491701234567"}}, book.Entries[0].Numbers, "numbers should be normalized")
+	assert.Equal(t, []phonebook.Number{{Type: phonebook.NumberOffice, Number: "20"}}, book.Entries[1].Numbers, "extension should be kept")
+
+	t.Run("dry run", func(t *testing.T) {
+		got, err := runCommand(importPhoneBook, func(flags *flag.FlagSet) {
+			importFlags(file, "csv")(flags)
+			flags.Bool(dryRunFlagName, true, "")
+		}, server.URL)
+		require.NoError(t, err, "no error expected")
+		assert.Contains(t, got, "\tDownloading Phone Book successful\n\tPhone book unchanged\n", "phone book should be unchanged")
+	})
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := runCommand(importPhoneBook, importFlags(file, "xlsx"), server.URL)
+		assert.EqualError(t, err, "could not import phone book: the format \"xlsx\" is unknown, expected csv, vcf or ldif", "error is wrong")
+	})
+}
+
+func TestUploadSharedPhoneBook(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), os.ModePerm), "no error expected")
+		return path
+	}
+	shared := write("directory.xml", `<LocalPhonebook><Entry><LastName>Doe</LastName><Number type="office">20</Number></Entry><Entry><Company>Reception</Company><Number type="office">10</Number></Entry></LocalPhonebook>`)
+	overlays := filepath.Join(dir, "overlays")
+	require.NoError(t, os.Mkdir(overlays, os.ModePerm), "no error expected")
+	write(filepath.Join("overlays", overlayFileName("sales")), `<LocalPhonebook><Group name="Sales"/><Entry><Company>Hotline</Company><Number type="office">30</Number><Group>Sales</Group></Entry></LocalPhonebook>`)
+	write(filepath.Join("overlays", phoneBookFileName(server1.URL)), `<LocalPhonebook><Entry><FirstName>John</FirstName><LastName>Doe</LastName><Number type="office">20</Number></Entry></LocalPhonebook>`)
+	inventoryFile := write("inventory.yaml", "phones:\n  - address: "+server2.URL+"\n    tags: [sales]\n")
+
+	setup := func(flags *flag.FlagSet) {
+		flags.String(sourceDirFlagName, "", "")
+		flags.String(sharedFlagName, shared, "")
+		flags.String(overlayDirFlagName, overlays, "")
+		flags.String(inventoryFlagName, inventoryFile, "")
+	}
+	got, err := runCommand(uploadPhoneBook, setup, server1.URL, server2.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, server1.URL+":\n\tLogin successful\n\tRemoved 1 duplicate numbers\n\tUploading Phone Book successful\n", "output of first phone is wrong")
+	assert.Contains(t, got, server2.URL+":\n\tLogin successful\n\tUploading Phone Book successful\n", "output of second phone is wrong")
+
+	book1, err := phonebook.Parse([]byte(phone1.Phonebook))
+	require.NoError(t, err, "no error expected")
+	names := func(book *phonebook.Phonebook) []string {
+		result := make([]string, 0)
+		for _, entry := range book.Entries {
+			result = append(result, entry.Name())
+		}
+		return result
+	}
+	assert.Equal(t, []string{"Reception", "Doe, John"}, names(book1), "entries of first phone are wrong")
+	book2, err := phonebook.Parse([]byte(phone2.Phonebook))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []string{"Doe", "Reception", "Hotline"}, names(book2), "entries of second phone are wrong")
+	assert.Equal(t, []phonebook.Group{{Name: "Sales"}}, book2.Groups, "groups of second phone are wrong")
+
+	t.Run("source and shared", func(t *testing.T) {
+		_, err := runCommand(uploadPhoneBook, func(flags *flag.FlagSet) {
+			setup(flags)
+			_ = flags.Set(sourceDirFlagName, dir)
+		}, server1.URL)
+		assert.EqualError(t, err, "either --sourceDir or --shared is required", "error is wrong")
+	})
+}
+
+func TestMergePhoneBook(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Phonebook = `<LocalPhonebook><Entry><LastName>Doe</LastName><Number type="office">20</Number></Entry><Entry><LastName>Old</LastName><Number type="office">25</Number></Entry>` +
+		`<Entry><LastName>Mom</LastName><Number type="home">0301111</Number></Entry><Entry><LastName>Front desk</LastName><Number type="office">10</Number></Entry></LocalPhonebook>`
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	central := filepath.Join(dir, "central.xml")
+	require.NoError(t, ioutil.WriteFile(central, []byte(`<LocalPhonebook><Entry><LastName>Doe</LastName><Number type="office">20</Number></Entry><Entry><Company>Reception</Company><Number type="office">10</Number></Entry></LocalPhonebook>`), os.ModePerm), "no error expected")
+	previous := filepath.Join(dir, "previous.xml")
+	require.NoError(t, ioutil.WriteFile(previous, []byte(`<LocalPhonebook><Entry><LastName>Doe</LastName><Number type="office">20</Number></Entry><Entry><LastName>Old</LastName><Number type="office">25</Number></Entry></LocalPhonebook>`), os.ModePerm), "no error expected")
+	setup := func(rule string) func(flags *flag.FlagSet) {
+		return func(flags *flag.FlagSet) {
+			flags.String(centralFlagName, central, "")
+			flags.String(conflictFlagName, rule, "")
+			flags.String(previousFlagName, previous, "")
+		}
+	}
+	names := func(content string) []string {
+		book, err := phonebook.Parse([]byte(content))
+		require.NoError(t, err, "no error expected")
+		result := make([]string, 0)
+		for _, entry := range book.Entries {
+			result = append(result, entry.Name())
+		}
+		return result
+	}
+
+	got, err := runCommand(mergePhoneBook, setup("central"), server1.URL, server2.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, server1.URL+":\n\tLogin successful\n\tDownloading Phone Book successful\n\tFound 1 numbers both in the central directory and in entries of the phone (rule: central)\n\tMerging Phone Book successful\n", "output of first phone is wrong")
+	assert.Contains(t, got, server2.URL+":\n\tLogin successful\n\tDownloading Phone Book successful\n\tMerging Phone Book successful\n", "output of second phone is wrong")
+	assert.Equal(t, []string{"Doe", "Reception", "Mom"}, names(phone1.Phonebook), "entries of first phone are wrong")
+	assert.Equal(t, []string{"Doe", "Reception"}, names(phone2.Phonebook), "entries of second phone are wrong")
+
+	t.Run("unchanged", func(t *testing.T) {
+		got, err := runCommand(mergePhoneBook, setup("central"), server1.URL)
+		require.NoError(t, err, "no error expected")
+		assert.Contains(t, got, "\tDownloading Phone Book successful\n\tPhone book unchanged\n", "phone book should be unchanged")
+	})
+	t.Run("unknown rule", func(t *testing.T) {
+		_, err := runCommand(mergePhoneBook, setup("mine"), server1.URL)
+		assert.EqualError(t, err, "the conflict rule \"mine\" is unknown, expected central, local or both", "error is wrong")
+	})
+}
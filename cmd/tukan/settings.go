@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// splitAssignments separates the arguments of the form path=value from the address specifications.
+func splitAssignments(args []string) (map[string]string, []string) {
+	assignments := make(map[string]string)
+	specs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if separator := strings.Index(arg, "="); separator != -1 {
+			assignments[arg[:separator]] = arg[separator+1:]
+		} else {
+			specs = append(specs, arg)
+		}
+	}
+	return assignments, specs
+}
+
+func setParameters(context *cli.Context) error {
+	assignments, specs := splitAssignments(context.Args())
+	if len(assignments) == 0 {
+		return cli.NewExitError("at least one argument of the form path=value is required", exitCodeError)
+	}
+	for path, value := range assignments {
+		var err error
+		if isTemplate(value) {
+			_, err = (&params.Parameters{}).Get(path)
+			if err == nil {
+				_, err = parseTemplate(value)
+			}
+		} else {
+			_, err = buildTemplate(map[string]string{path: value}, nil)
+		}
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid assignment %s=%s: %v", path, value, err), exitCodeError)
+		}
+	}
+	variables, err := loadPhoneVariables(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, specs)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, "set", channel)
+	setOperation := patchOperation(ctx, context, channel, recorder, actionSet, nil, func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error) {
+		return buildTemplate(assignments, variables.lookup(p.Address, current))
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			setOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
+
+// buildTemplate returns parameters with the assigned values, in which templated values are expanded with the variables.
+func buildTemplate(assignments map[string]string, variables map[string]string) (params.Parameters, error) {
+	template := params.Parameters{}
+	for path, value := range assignments {
+		value, err := expandTemplate(value, variables)
+		if err != nil {
+			return template, err
+		}
+		// the phones only receive settings which are set, so empty values cannot be uploaded
+		single := params.Parameters{}
+		err = single.Set(path, value)
+		if err == nil && len(params.Diff(&params.Parameters{}, &single)) == 0 {
+			err = fmt.Errorf("\"%s\" cannot be set to an empty value", path)
+		}
+		if err == nil {
+			err = template.Set(path, value)
+		}
+		if err != nil {
+			return template, err
+		}
+	}
+	return template, nil
+}
+
+func getParameters(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the path of the parameter is required", exitCodeError)
+	}
+	paths := strings.Split(context.Args().First(), ",")
+	for _, path := range paths {
+		if _, err := (&params.Parameters{}).Get(path); err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid path: %v", err), exitCodeError)
+		}
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+
+	downloadHandler := actionDownloadParameters.handler(channel)
+	getOperation := func(p *tukan.Phone) {
+		parameters, err := p.DownloadParametersContext(ctx)
+		downloadHandler(p.Result(err))
+		if err != nil {
+			return
+		}
+		for _, path := range paths {
+			value, _ := parameters.Get(path)
+			value = params.Change{Path: path, New: value}.Redacted().New
+			reportPlan(channel, p.Address, actionGet, fmt.Sprintf("%s = %s", path, formatSetting(value)))
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	if format := context.GlobalString(outputFlagName); format != "" && format != outputText {
+		go handleResults(&wg, channel, context, summary)
+	} else {
+		go printSettingsTable(&wg, channel, context, summary, paths)
+	}
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			getOperation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	return summary.exitError()
+}
+
+func formatSetting(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if text := reflect.ValueOf(value); text.Kind() == reflect.String {
+		return text.String()
+	}
+	formatted, _ := json.Marshal(value)
+	return string(formatted)
+}
+
+// printSettingsTable prints the values reported by the get command as table with one row per phone.
+// If a phone failed, its row contains the first error instead of the values.
+func printSettingsTable(wg *sync.WaitGroup, channel chan commentedResult, context *cli.Context, summary *runSummary, paths []string) {
+	defer wg.Done()
+	values := make(map[string][]string)
+	failures := make(map[string]string)
+	for result := range channel {
+		summary.add(result)
+		if _, present := values[result.Address]; !present {
+			values[result.Address] = make([]string, 0, len(paths))
+		}
+		if result.Error != nil && failures[result.Address] == "" {
+			failures[result.Address] = result.comment
+		}
+		if result.action == actionGet {
+			parts := strings.SplitN(result.comment, " = ", 2)
+			values[result.Address] = append(values[result.Address], parts[len(parts)-1])
+		}
+	}
+	addresses := make([]string, 0, len(values))
+	for address := range values {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	writer := tabwriter.NewWriter(context.App.Writer, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "Address\t%s\n", strings.Join(paths, "\t"))
+	for _, address := range addresses {
+		if failure, failed := failures[address]; failed {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\n", address, failure)
+			continue
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\n", address, strings.Join(values[address], "\t"))
+	}
+	_ = writer.Flush()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetParameters(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Parameters = params.Parameters{TimeServer: "ntp.example.com", Sip: params.Sips{{DisplayName: "John Doe", Active: params.True, AuthenticationPassword: "secret"}}}
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	phone2.Parameters = params.Parameters{Backlight: 3}
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	_ = flags.Parse([]string{"Sip[0].DisplayName,TimeServer,Backlight,Sip[0].Active,Sip[0].AuthenticationPassword", server1.URL, server2.URL})
+	var buff bytes.Buffer
+	err := getParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.NoError(t, err, "no error expected")
+	rows := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(buff.String()), "\n") {
+		fields := strings.Fields(line)
+		rows[fields[0]] = fields[1:]
+	}
+	assert.Equal(t, []string{"Sip[0].DisplayName", "TimeServer", "Backlight", "Sip[0].Active", "Sip[0].AuthenticationPassword"}, rows["Address"], "header is wrong")
+	assert.Equal(t, []string{"John", "Doe", "ntp.example.com", "0", "1", "********"}, rows[server1.URL], "values of first phone are wrong")
+	assert.Equal(t, []string{"3"}, rows[server2.URL], "values of second phone are wrong")
+	assert.NotContains(t, buff.String(), "secret", "passwords must not be printed")
+}
+
+func TestSetParameters(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{TimeServer: "ntp.example.com", FunctionKeys: params.FunctionKeys{{DisplayName: "John"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	run := func(args ...string) (string, error) {
+		flags := flag.NewFlagSet("", flag.PanicOnError)
+		flags.String(loginFlagName, username, "")
+		flags.String(passwordFlagName, password, "")
+		_ = flags.Parse(args)
+		var buff bytes.Buffer
+		err := setParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+		return buff.String(), err
+	}
+
+	got, err := run("FunctionKeys[1].PhoneNumber=20", server.URL, "TimeServer=ntp.example.com")
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tChanged FunctionKeys[1]: (unset) -> {\"PhoneNumber\":\"20\"}\n", "change should be reported")
+	assert.Equal(t, params.Parameters{FunctionKeys: params.FunctionKeys{{}, {PhoneNumber: "20"}}}, phone.Parameters, "only the changed field should be uploaded")
+
+	got, err = run("Backlight=0", server.URL)
+	assert.EqualError(t, err, "invalid assignment Backlight=0: \"Backlight\" cannot be set to an empty value", "message is wrong")
+
+	got, err = run("Backlight=dark", server.URL)
+	assert.EqualError(t, err, "invalid assignment Backlight=dark: value \"dark\" of \"Backlight\" is not an integer", "message is wrong")
+}
+
+func TestSetParameters_Template(t *testing.T) {
+	handler1, phone1 := mock.CreatePhone(username, password)
+	phone1.Parameters = params.Parameters{MACAddress: "00:1A:2B:3C:4D:5E"}
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, phone2 := mock.CreatePhone(username, password)
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	fleet := fmt.Sprintf("phones:\n  - address: %s\n    name: Room 3.12\n    vars:\n      Extension: \"312\"\n  - address: %s\n    vars:\n      Extension: \"313\"\n", server1.URL, server2.URL)
+	inventoryFile := filepath.Join(dir, "inventory.yaml")
+	require.NoError(t, ioutil.WriteFile(inventoryFile, []byte(fleet), os.ModePerm), "no error expected")
+	varsFile := filepath.Join(dir, "vars.csv")
+	require.NoError(t, ioutil.WriteFile(varsFile, []byte("MAC,Owner\n001a2b3c4d5e,John Doe\n"), os.ModePerm), "no error expected")
+
+	flags := flag.NewFlagSet("", flag.PanicOnError)
+	flags.String(loginFlagName, username, "")
+	flags.String(passwordFlagName, password, "")
+	flags.String(inventoryFlagName, inventoryFile, "")
+	flags.String(varsFlagName, varsFile, "")
+	_ = flags.Parse([]string{"Sip[0].DisplayName={{.Extension}} {{.Owner}}", "PhoneName={{.Name}}"})
+	var buff bytes.Buffer
+	err = setParameters(cli.NewContext(&cli.App{Writer: &buff}, flags, nil))
+
+	require.Error(t, err, "error expected")
+	assert.Equal(t, params.Sips{{DisplayName: "312 John Doe"}}, phone1.Parameters.Sip, "display name of first phone is wrong")
+	assert.Equal(t, "Room 3.12", phone1.Parameters.PhoneName, "phone name of first phone is wrong")
+	assert.Contains(t, buff.String(), "map has no entry for key", "missing variables of second phone should be reported")
+	assert.Empty(t, phone2.Parameters.Sip, "second phone must not be changed")
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// passwordSource provides the new passwords of SIP accounts. Either all accounts get the same password
+// from an environment variable or a file, or every account gets a generated password. Generated passwords
+// are saved after the changes have been validated but before they are uploaded, so that none gets lost;
+// the outcome of the upload is recorded next to the password.
+type passwordSource struct {
+	password string
+	length   int
+	mutex    sync.Mutex
+	saved    *os.File
+	pending  map[string][]string
+	records  [][]string
+}
+
+const (
+	passwordPending  = "pending"
+	passwordUploaded = "uploaded"
+	passwordFailed   = "failed"
+)
+
+// newPasswordSource returns the source selected by the flags, or nil if no source is selected.
+func newPasswordSource(context *cli.Context) (*passwordSource, error) {
+	selected := make([]string, 0)
+	for _, name := range []string{passwordEnvFlagName, passwordFileFlagName, generatePasswordFlagName} {
+		if context.IsSet(name) {
+			selected = append(selected, "--"+name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+	if len(selected) > 1 {
+		return nil, fmt.Errorf("only one of %s can be used", strings.Join(selected, ", "))
+	}
+	source := &passwordSource{}
+	switch {
+	case context.IsSet(passwordEnvFlagName):
+		source.password = os.Getenv(context.String(passwordEnvFlagName))
+		if source.password == "" {
+			return nil, fmt.Errorf("the environment variable \"%s\" is empty", context.String(passwordEnvFlagName))
+		}
+	case context.IsSet(passwordFileFlagName):
+		data, err := ioutil.ReadFile(context.String(passwordFileFlagName))
+		if err != nil {
+			return nil, fmt.Errorf("could not read password: %v", err)
+		}
+		source.password = strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0])
+	default:
+		return newGeneratingSource(context)
+	}
+	if source.password == "" {
+		return nil, fmt.Errorf("the password file \"%s\" is empty", context.String(passwordFileFlagName))
+	}
+	return source, nil
+}
+
+func newGeneratingSource(context *cli.Context) (*passwordSource, error) {
+	source := &passwordSource{length: context.Int(passwordLengthFlagName), pending: make(map[string][]string)}
+	if source.length < 8 {
+		return nil, fmt.Errorf("generated passwords must have at least 8 characters")
+	}
+	path := context.String(savePasswordsFlagName)
+	if path == "" {
+		return nil, fmt.Errorf("--%s requires --%s", generatePasswordFlagName, savePasswordsFlagName)
+	}
+	if context.GlobalBool(dryRunFlagName) {
+		return source, nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not create password file: %v", err)
+	}
+	source.saved = file
+	source.records = [][]string{{"address", "slot", "username", "password", "outcome"}}
+	return source, source.write()
+}
+
+// next returns the password for the account in the slot of the phone. A generated password
+// is only saved by beforeUpload, once the changes of the phone are known to be valid.
+func (s *passwordSource) next(address string, slot int, username string) (string, error) {
+	if s.length == 0 {
+		return s.password, nil
+	}
+	password, err := generatePassword(s.length)
+	if err != nil {
+		return "", fmt.Errorf("could not generate password: %v", err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending[address] = []string{address, strconv.Itoa(slot), username, password, passwordPending}
+	return password, nil
+}
+
+// beforeUpload saves the password generated for the phone, if any, and returns
+// a function which records the outcome of the upload (see uploadHook).
+func (s *passwordSource) beforeUpload(p *tukan.Phone) (func(err error), error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, ok := s.pending[p.Address]
+	if s.saved == nil || !ok {
+		return func(error) {}, nil
+	}
+	delete(s.pending, p.Address)
+	s.records = append(s.records, record)
+	if err := s.write(); err != nil {
+		return nil, fmt.Errorf("could not save password: %v", err)
+	}
+	return func(err error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		record[len(record)-1] = passwordUploaded
+		if err != nil {
+			record[len(record)-1] = passwordFailed
+		}
+		_ = s.write()
+	}, nil
+}
+
+// write replaces the content of the password file with the records.
+func (s *passwordSource) write() error {
+	err := s.saved.Truncate(0)
+	if err == nil {
+		_, err = s.saved.Seek(0, 0)
+	}
+	if err == nil {
+		writer := csv.NewWriter(s.saved)
+		err = writer.WriteAll(s.records)
+	}
+	if err == nil {
+		err = s.saved.Sync()
+	}
+	return err
+}
+
+func (s *passwordSource) close() {
+	if s != nil && s.saved != nil {
+		_ = s.saved.Close()
+	}
+}
+
+func generatePassword(length int) (string, error) {
+	password := make([]byte, length)
+	for index := range password {
+		position, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		password[index] = passwordAlphabet[position.Int64()]
+	}
+	return string(password), nil
+}
+
+var transportProtocols = map[string]params.TransportProtocol{
+	"udp": params.TransportUDP,
+	"tcp": params.TransportTCP,
+	"tls": params.TransportTLS,
+}
+
+// sipAccountFromFlags returns the account with the settings given as flags, which may be templates.
+func sipAccountFromFlags(context *cli.Context) (params.Sip, params.TransportProtocol, error) {
+	account := params.Sip{
+		RegistrationServerAddress: context.String(registrarFlagName),
+		RegistrationServerPort:    context.Int(registrarPortFlagName),
+		ProxyServerAddress:        context.String(proxyFlagName),
+		ProxyServerPort:           context.Int(proxyPortFlagName),
+		OutboundProxyAddress:      context.String(outboundProxyFlagName),
+		OutboundProxyPort:         context.Int(outboundProxyPortFlagName),
+		Domain:                    context.String(domainFlagName),
+		Username:                  context.String(usernameFlagName),
+		AuthenaticationName:       context.String(authNameFlagName),
+		DisplayName:               context.String(displayNameFlagName),
+	}
+	if context.Bool(enableFlagName) {
+		account.Active = params.True
+	}
+	var transport params.TransportProtocol
+	if name := context.String(transportFlagName); name != "" {
+		protocol, ok := transportProtocols[strings.ToLower(name)]
+		if !ok {
+			return account, "", fmt.Errorf("unsupported transport \"%s\", want one of udp, tcp, tls", name)
+		}
+		transport = protocol
+	}
+	_, err := (&params.Parameters{Sip: params.Sips{account}}).MapStrings(func(value string) (string, error) {
+		if isTemplate(value) {
+			_, err := parseTemplate(value)
+			return value, err
+		}
+		return value, nil
+	})
+	return account, transport, err
+}
+
+func provisionSipAccount(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the slot of the SIP account is required", exitCodeError)
+	}
+	slot, err := parseIndex(context.Args().First(), "SIP account")
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	account, transport, err := sipAccountFromFlags(context)
+	if err == nil && reflect.DeepEqual(account, params.Sip{}) && transport == "" && !context.IsSet(passwordEnvFlagName) && !context.IsSet(passwordFileFlagName) && !context.IsSet(generatePasswordFlagName) {
+		err = fmt.Errorf("at least one setting of the SIP account is required")
+	}
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	variables, err := loadPhoneVariables(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	passwords, err := newPasswordSource(context)
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	defer passwords.close()
+	return runSipProvisioning(context, connector, "sip-account", passwords, func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error) {
+		values := variables.lookup(p.Address, current)
+		expanded, err := (&params.Parameters{Sip: params.Sips{account}}).MapStrings(func(value string) (string, error) {
+			return expandTemplate(value, values)
+		})
+		if err != nil {
+			return params.Parameters{}, err
+		}
+		update := expanded.Sip[0]
+		sips, _ := current.Sip.WithSlots(slot + 1).Transform(params.SipUpdateAccount(slot, update))
+		if passwords != nil {
+			password, err := passwords.next(p.Address, slot, sips[slot].Username)
+			if err != nil {
+				return params.Parameters{}, err
+			}
+			sips, _ = sips.Transform(params.SipRotatePassword(slot, password))
+		}
+		return params.Parameters{Sip: sips, SIPTransportProtocol: transport}, nil
+	})
+}
+
+func enableSipAccounts(context *cli.Context) error {
+	return switchSipAccounts(context, true)
+}
+
+func disableSipAccounts(context *cli.Context) error {
+	return switchSipAccounts(context, false)
+}
+
+func switchSipAccounts(context *cli.Context, active bool) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the slots of the SIP accounts are required", exitCodeError)
+	}
+	slots, err := parseIndices(context.Args().First(), "SIP account")
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	command := "sip-disable"
+	if active {
+		command = "sip-enable"
+	}
+	return runSipProvisioning(context, connector, command, nil, func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error) {
+		sips, _ := current.Sip.Transform(params.SipSetActive(active, slots...))
+		for _, slot := range slots {
+			if slot >= len(sips) {
+				return params.Parameters{}, fmt.Errorf("the phone has no SIP account %d", slot)
+			}
+		}
+		return params.Parameters{Sip: sips}, nil
+	})
+}
+
+func rotateSipPassword(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return cli.NewExitError("the slot of the SIP account is required", exitCodeError)
+	}
+	slot, err := parseIndex(context.Args().First(), "SIP account")
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	connector, err := createConnectorFor(context, context.Args().Tail())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("could not create connector: %v", err), exitCodeError)
+	}
+	passwords, err := newPasswordSource(context)
+	if err == nil && passwords == nil {
+		err = fmt.Errorf("one of --%s, --%s or --%s is required", passwordEnvFlagName, passwordFileFlagName, generatePasswordFlagName)
+	}
+	if err != nil {
+		return cli.NewExitError(err, exitCodeError)
+	}
+	defer passwords.close()
+	return runSipProvisioning(context, connector, "sip-password", passwords, func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error) {
+		if slot >= len(current.Sip) {
+			return params.Parameters{}, fmt.Errorf("the phone has no SIP account %d", slot)
+		}
+		password, err := passwords.next(p.Address, slot, current.Sip[slot].Username)
+		if err != nil {
+			return params.Parameters{}, err
+		}
+		sips, _ := current.Sip.Transform(params.SipRotatePassword(slot, password))
+		return params.Parameters{Sip: sips}, nil
+	})
+}
+
+// runSipProvisioning uploads the changed fields of the template to all phones of the connector (see patchOperation)
+// and records the run in the journal under the command's name. Generated passwords of the source are saved
+// right before they are uploaded.
+func runSipProvisioning(context *cli.Context, connector *tukan.Connector, command string, passwords *passwordSource, template func(p *tukan.Phone, current *params.Parameters) (params.Parameters, error)) error {
+	ctx, stop := interruptContext()
+	defer stop()
+	channel := make(chan commentedResult)
+	recorder := newJournalRecorder(context, command, channel)
+	var hook uploadHook
+	if passwords != nil {
+		hook = passwords.beforeUpload
+	}
+	operation := patchOperation(ctx, context, channel, recorder, actionProvisionSip, hook, template)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	summary := newRunSummary(context, connector)
+	go handleResults(&wg, channel, context, summary)
+	connector.
+		RunContext(ctx, actionLogin.handler(channel),
+			operation,
+			actionLogout.handler(channel))
+	close(channel)
+	wg.Wait()
+	recorder.finish(context)
+	return summary.exitError()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func passwordFlags(flags *flag.FlagSet) {
+	flags.String(passwordEnvFlagName, "", "")
+	flags.String(passwordFileFlagName, "", "")
+	flags.Bool(generatePasswordFlagName, false, "")
+	flags.Int(passwordLengthFlagName, 20, "")
+	flags.String(savePasswordsFlagName, "", "")
+}
+
+func TestProvisionSipAccount(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{Sip: params.Sips{{DisplayName: "John", Domain: "old.example.com"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	got, err := runCommand(provisionSipAccount, func(flags *flag.FlagSet) {
+		for _, name := range []string{registrarFlagName, proxyFlagName, outboundProxyFlagName, domainFlagName, usernameFlagName, authNameFlagName, displayNameFlagName, transportFlagName} {
+			flags.String(name, "", "")
+		}
+		for _, name := range []string{registrarPortFlagName, proxyPortFlagName, outboundProxyPortFlagName} {
+			flags.Int(name, 0, "")
+		}
+		flags.Bool(enableFlagName, false, "")
+		passwordFlags(flags)
+	}, "-registrar", "pbx.example.com", "-registrar-port", "5061", "-username", "{{.Address}}", "-transport", "TLS", "-enable", "1", server.URL)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tChanged SIP[1]: (unset) -> ", "new account should be reported")
+	assert.Equal(t, params.Parameters{SIPTransportProtocol: params.TransportTLS, Sip: params.Sips{{}, {
+		RegistrationServerAddress: "pbx.example.com",
+		RegistrationServerPort:    5061,
+		Username:                  server.URL,
+		Active:                    params.True,
+	}}}, phone.Parameters, "only the new account and the transport should be uploaded")
+}
+
+func TestSwitchSipAccounts(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	accounts := params.Sips{{Active: params.True, Domain: "example.com"}, {Active: params.False, Domain: "example.com"}, {}}
+	phone.Parameters = params.Parameters{Sip: accounts}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	got, err := runCommand(enableSipAccounts, nil, "0-1", server.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tChanged SIP[1].Active: \"0\" -> \"1\"\n", "change should be reported")
+	assert.Equal(t, params.Sips{{}, {Active: params.True}}, phone.Parameters.Sip, "only the enabled account should be uploaded")
+
+	phone.Parameters = params.Parameters{Sip: accounts}
+	_, err = runCommand(enableSipAccounts, nil, "2", server.URL)
+	assert.Error(t, err, "an account without server should not be enabled")
+	assert.Equal(t, accounts, phone.Parameters.Sip, "nothing should be uploaded")
+
+	_, err = runCommand(disableSipAccounts, nil, "0", server.URL)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, params.Sips{{Active: params.False}}, phone.Parameters.Sip, "only the disabled account should be uploaded")
+
+	got, err = runCommand(disableSipAccounts, nil, "3", server.URL)
+	assert.Error(t, err, "error expected")
+	assert.Contains(t, got, "the phone has no SIP account 3", "missing account should be reported")
+}
+
+func TestRotateSipPassword(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{Sip: params.Sips{{Username: "20", AuthenticationPassword: "old secret"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	require.NoError(t, os.Setenv("TUKAN_TEST_SIP_PASSWORD", "new secret"), "no error expected")
+	defer func() { _ = os.Unsetenv("TUKAN_TEST_SIP_PASSWORD") }()
+
+	got, err := runCommand(rotateSipPassword, passwordFlags, "-password-env", "TUKAN_TEST_SIP_PASSWORD", "0", server.URL)
+
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, got, "\tChanged SIP[0].AuthenticationPassword: \"********\" -> \"********\"\n", "change should be reported without password")
+	assert.NotContains(t, got, "secret", "passwords must not be printed")
+	assert.Equal(t, params.Sips{{AuthenticationPassword: "new secret"}}, phone.Parameters.Sip, "password should be uploaded")
+
+	got, err = runCommand(rotateSipPassword, passwordFlags, "0", server.URL)
+	assert.EqualError(t, err, "one of --password-env, --password-file or --generate-password is required", "message is wrong")
+}
+
+func TestRotateSipPassword_Generate(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{Sip: params.Sips{{Username: "20", AuthenticationPassword: "old secret"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	file := filepath.Join(dir, "passwords.csv")
+
+	_, err = runCommand(rotateSipPassword, passwordFlags, "-generate-password", "-save-passwords", file, "0", server.URL)
+
+	require.NoError(t, err, "no error expected")
+	reader, err := os.Open(file)
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = reader.Close() }()
+	records, err := csv.NewReader(reader).ReadAll()
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 2, len(records), "one password should be saved")
+	assert.Equal(t, []string{"address", "slot", "username", "password", "outcome"}, records[0], "header is wrong")
+	assert.Equal(t, []string{server.URL, "0", "20"}, records[1][:3], "account is wrong")
+	assert.Equal(t, 20, len(records[1][3]), "password has wrong length")
+	assert.Equal(t, "uploaded", records[1][4], "outcome is wrong")
+	assert.Equal(t, records[1][3], phone.Parameters.Sip[0].AuthenticationPassword, "saved password should be uploaded")
+
+	_, err = runCommand(rotateSipPassword, passwordFlags, "-generate-password", "-save-passwords", file, "0", server.URL)
+	require.Error(t, err, "existing password files must not be overwritten")
+	assert.Contains(t, err.Error(), "could not create password file", "message is wrong")
+}
+
+func TestProvisionSipAccount_InvalidGenerated(t *testing.T) {
+	handler, phone := mock.CreatePhone(username, password)
+	phone.Parameters = params.Parameters{Sip: params.Sips{{Username: "20"}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	file := filepath.Join(dir, "passwords.csv")
+
+	_, err = runCommand(provisionSipAccount, func(flags *flag.FlagSet) {
+		flags.Bool(enableFlagName, false, "")
+		passwordFlags(flags)
+	}, "-enable", "-generate-password", "-save-passwords", file, "0", server.URL)
+
+	assert.Error(t, err, "an account without server should not be enabled")
+	assert.Equal(t, params.Sips{{Username: "20"}}, phone.Parameters.Sip, "nothing should be uploaded")
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, "address,slot,username,password,outcome\n", string(data), "passwords of invalid changes must not be saved")
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/urfave/cli"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 const loginFlagName = "login"
@@ -15,6 +17,65 @@ const targetDirFlagName = "targetDir"
 const sourceDirFlagName = "sourceDir"
 const originalFlagName = "original"
 const replaceFlagName = "replace"
+const schemeFlagName = "scheme"
+const caCertFlagName = "ca-cert"
+const pinFlagName = "pin"
+const allowSelfSignedFlagName = "allow-self-signed"
+const parallelFlagName = "parallel"
+const subnetParallelFlagName = "subnet-parallel"
+const subnetPrefixFlagName = "subnet-prefix"
+const maxAttemptsFlagName = "max-attempts"
+const retryBackoffFlagName = "retry-backoff"
+const retryMaxBackoffFlagName = "retry-max-backoff"
+const retryJitterFlagName = "retry-jitter"
+const hostsFileFlagName = "hosts-file"
+const excludeFlagName = "exclude"
+const inventoryFlagName = "inventory"
+const tagFlagName = "tag"
+const outputFlagName = "output"
+const failFastFlagName = "fail-fast"
+const againstFlagName = "against"
+const templateFlagName = "template"
+const patchDirFlagName = "patchDir"
+const stateFileFlagName = "stateFile"
+const dryRunFlagName = "dry-run"
+const journalFlagName = "journal"
+const journalBackupFlagName = "journal-backup"
+const fromBackupFlagName = "from-backup"
+const varsFlagName = "vars"
+const byFlagName = "by"
+const indexFlagName = "index"
+const pickupCodeFlagName = "pickup-code"
+const urlFlagName = "url"
+const fromFlagName = "from"
+const registrarFlagName = "registrar"
+const registrarPortFlagName = "registrar-port"
+const proxyFlagName = "proxy"
+const proxyPortFlagName = "proxy-port"
+const outboundProxyFlagName = "outbound-proxy"
+const outboundProxyPortFlagName = "outbound-proxy-port"
+const domainFlagName = "domain"
+const usernameFlagName = "username"
+const authNameFlagName = "auth-name"
+const displayNameFlagName = "display-name"
+const transportFlagName = "transport"
+const enableFlagName = "enable"
+const passwordEnvFlagName = "password-env"
+const passwordFileFlagName = "password-file"
+const generatePasswordFlagName = "generate-password"
+const passwordLengthFlagName = "password-length"
+const savePasswordsFlagName = "save-passwords"
+const checkFlagName = "check"
+const fileFlagName = "file"
+const mapFlagName = "map"
+const countryCodeFlagName = "country-code"
+const areaCodeFlagName = "area-code"
+const extensionLengthFlagName = "extension-length"
+const sharedFlagName = "shared"
+const overlayDirFlagName = "overlay-dir"
+const centralFlagName = "central"
+const conflictFlagName = "conflict"
+const previousFlagName = "previous"
 
 func main() {
 	app := cli.NewApp()
@@ -29,10 +90,31 @@ func main() {
 
 	loginFlag := cli.StringFlag{Name: loginFlagName, Value: "Admin", Usage: "The actionLogin to be used", Destination: &login}
 	passwordFlag := cli.StringFlag{Name: passwordFlagName, Value: "admin", Usage: "The password to be used", Destination: &password}
-	portFlag := cli.IntFlag{Name: portFlagName, Value: 80, Usage: "The port to be used to connect to the telephones", Destination: &port}
+	portFlag := cli.IntFlag{Name: portFlagName, Value: 0, Usage: "The port of addresses without port, 0 means 443 for https and 80 otherwise", Destination: &port}
 	verboseFlag := cli.BoolFlag{Name: verboseFlagName, Usage: "Disables the logging and only prints the final results", Destination: &noLogging}
 	timeoutFlag := cli.IntFlag{Name: timeoutFlagName, Value: 20, Usage: "Number of seconds to wait for remote connection", Destination: &timeout}
 	originalFlag := cli.StringFlag{Name: originalFlagName, Value: "", Usage: "The display name to be replaced", Destination: &original, Required: true}
+	schemeFlag := cli.StringFlag{Name: schemeFlagName, Value: "http", Usage: "The scheme used to connect to the telephones, either \"http\" or \"https\""}
+	caCertFlag := cli.StringFlag{Name: caCertFlagName, Usage: "A PEM file with CA certificates which are trusted in addition to the system's CAs", TakesFile: true}
+	pinFlag := cli.StringSliceFlag{Name: pinFlagName, Usage: "Pins the SHA-256 certificate fingerprint of a telephone, e.g. 10.20.30.40=AB:CD:… for all ports or 10.20.30.40:8443=AB:CD:…; can be repeated"}
+	allowSelfSignedFlag := cli.BoolFlag{Name: allowSelfSignedFlagName, Usage: "Accepts self-signed and otherwise invalid certificates of telephones without pinned fingerprint"}
+	parallelFlag := cli.IntFlag{Name: parallelFlagName, Value: 0, Usage: "Maximum number of telephones processed at the same time, 0 means no limit"}
+	subnetParallelFlag := cli.IntFlag{Name: subnetParallelFlagName, Value: 0, Usage: "Maximum number of telephones of the same subnet processed at the same time, 0 means no limit"}
+	subnetPrefixFlag := cli.IntFlag{Name: subnetPrefixFlagName, Value: 24, Usage: "Prefix length which determines the subnet of a telephone for --" + subnetParallelFlagName}
+	maxAttemptsFlag := cli.IntFlag{Name: maxAttemptsFlagName, Value: 1, Usage: "Maximum number of attempts for a request that fails transiently (timeouts, refused connections, 502/503/504); requests changing the phone are only repeated if the connection could not be established"}
+	retryBackoffFlag := cli.DurationFlag{Name: retryBackoffFlagName, Value: time.Second, Usage: "Time to wait before the first retry; doubles with every further retry"}
+	retryMaxBackoffFlag := cli.DurationFlag{Name: retryMaxBackoffFlagName, Value: 30 * time.Second, Usage: "Maximum time to wait between two retries"}
+	retryJitterFlag := cli.Float64Flag{Name: retryJitterFlagName, Value: 0.2, Usage: "Fraction (0 to 1) by which the wait time between retries is randomly shortened"}
+	hostsFileFlag := cli.StringFlag{Name: hostsFileFlagName, Usage: "A file with one address specification per line, in addition to the arguments; \"#\" starts a comment", TakesFile: true}
+	excludeFlag := cli.StringSliceFlag{Name: excludeFlagName, Usage: "An address specification whose addresses are skipped; can be repeated"}
+	inventoryFlag := cli.StringFlag{Name: inventoryFlagName, Usage: "A YAML or JSON file listing the telephones with their credentials, names and tags", TakesFile: true}
+	tagFlag := cli.StringSliceFlag{Name: tagFlagName, Usage: "Only uses the telephones of the inventory carrying this tag; can be repeated"}
+	outputFlag := cli.StringFlag{Name: outputFlagName, Value: outputText, Usage: "The format of the results, one of " + strings.Join(outputFormats, ", ")}
+	failFastFlag := cli.BoolFlag{Name: failFastFlagName, Usage: "Stops processing further telephones after the first error"}
+	dryRunFlag := cli.BoolFlag{Name: dryRunFlagName, Usage: "Only downloads from the phones and reports what would change, without changing anything"}
+	journalFlag := cli.StringFlag{Name: journalFlagName, Usage: "The directory where the state of phones is saved before they are changed, defaults to ~/.tukan/journal", TakesFile: true}
+	journalBackupFlag := cli.BoolFlag{Name: journalBackupFlagName, Usage: "Additionally saves a binary backup of every phone into the journal before it is changed"}
+	varsFlag := cli.StringFlag{Name: varsFlagName, Usage: "A CSV file with variables for templated values like \"{{.Owner}}\", keyed by the column \"address\" or \"mac\"", TakesFile: true}
 	replaceFlag := cli.StringFlag{Name: replaceFlagName, Value: "", Usage: "The new display name", Destination: &replace, Required: true}
 
 	scanCommand := cli.Command{
@@ -45,7 +127,10 @@ func main() {
 		Name:  "pb-up",
 		Usage: "Uploads a phone book to a set of VoIP phones.",
 		Flags: []cli.Flag{
-			cli.StringFlag{Name: sourceDirFlagName, Required: true, Usage: "The directory where the phone books to upload can be found.", TakesFile: true},
+			cli.StringFlag{Name: sourceDirFlagName, Usage: "The directory where the phone books to upload can be found.", TakesFile: true},
+			cli.BoolFlag{Name: checkFlagName, Usage: "Parses and validates the phone books and refuses to upload invalid ones"},
+			cli.StringFlag{Name: sharedFlagName, Usage: "A phone book which is uploaded to all phones instead of the phone books of --sourceDir.", TakesFile: true},
+			cli.StringFlag{Name: overlayDirFlagName, Usage: "A directory with entries which are put on top of the --shared phone book: tag_<tag>.xml for the phones with the tag, and the phone books named like in --sourceDir for single phones.", TakesFile: true},
 		},
 		Action: uploadPhoneBook,
 	}
@@ -59,6 +144,33 @@ func main() {
 		Action: downloadPhoneBook,
 	}
 
+	phoneBookImportCommand := cli.Command{
+		Name:      "pb-import",
+		Usage:     "Converts a directory export (CSV, vCard or LDIF) into a phone book and uploads it to a set of VoIP phones.",
+		ArgsUsage: "[address specifications]",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: fileFlagName, Required: true, Usage: "The file with the contacts.", TakesFile: true},
+			cli.StringFlag{Name: fromFlagName, Usage: "The format of the file: csv, vcf or ldif. By default, it is taken from the file extension."},
+			cli.StringFlag{Name: mapFlagName, Usage: "Assigns CSV columns to the fields FirstName, LastName, Company, Group, office, mobile, home and other, e.g. \"LastName=Surname,mobile=Cell\". Unmapped fields are read from columns named like them."},
+			cli.StringFlag{Name: countryCodeFlagName, Usage: "The country calling code for the numbers, e.g. 49. By default, it is taken from the area code settings of each phone."},
+			cli.StringFlag{Name: areaCodeFlagName, Usage: "The area code for local numbers, e.g. 30. By default, it is taken from the area code settings of each phone."},
+			cli.IntFlag{Name: extensionLengthFlagName, Value: 4, Usage: "Numbers with at most this many digits are internal extensions and are not normalized."},
+		},
+		Action: importPhoneBook,
+	}
+
+	phoneBookMergeCommand := cli.Command{
+		Name:      "pb-merge",
+		Usage:     "Merges the phone books of a set of VoIP phones with a central directory, keeping the contacts added on the phones.",
+		ArgsUsage: "[address specifications]",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: centralFlagName, Required: true, Usage: "The central directory as phone book.", TakesFile: true},
+			cli.StringFlag{Name: conflictFlagName, Value: "central", Usage: "Decides which entry keeps a number which is both in the central directory and in an entry of the phone: central, local or both."},
+			cli.StringFlag{Name: previousFlagName, Usage: "The central directory which was uploaded before. Its entries are removed from the phones, so that entries deleted from the central directory disappear.", TakesFile: true},
+		},
+		Action: mergePhoneBook,
+	}
+
 	downloadCommand := cli.Command{
 		Name:  "downloadConfig",
 		Usage: "Downloads all parameters from the phone and stores them in a json file. Though possible, the downloaded params are only meant for analyzing the settings, not for a complete restore on the phone.",
@@ -96,6 +208,118 @@ func main() {
 		Action: replaceFunctionKeys,
 	}
 
+	functionKeysCommand := cli.Command{
+		Name:  "fnkeys",
+		Usage: "Lists and edits the function keys of phones; keys are counted from 0.",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "Prints a table with the function keys of all phones.",
+				Action: listFunctionKeys,
+			},
+			{
+				Name:      "set",
+				Usage:     "Changes fields of the function key at the index, e.g. \"DisplayName=Linda PhoneNumber=20\".",
+				ArgsUsage: "<index> <field=value>... [address specifications]",
+				Action:    setFunctionKey,
+			},
+			{
+				Name:      "clear",
+				Usage:     "Clears the function keys at the indices, e.g. \"0,3-5\".",
+				ArgsUsage: "<indices> [address specifications]",
+				Action:    clearFunctionKeys,
+			},
+			{
+				Name:      "insert",
+				Usage:     "Inserts a function key with the fields at the index and moves the following keys back by one.",
+				ArgsUsage: "<index> <field=value>... [address specifications]",
+				Action:    insertFunctionKey,
+			},
+			{
+				Name:      "shift",
+				Usage:     "Moves the function keys from the index on; the keys in front are overwritten when moving forward.",
+				ArgsUsage: "<index> [address specifications]",
+				Flags: []cli.Flag{
+					cli.IntFlag{Name: byFlagName, Value: 1, Usage: "The number of keys to move by, negative numbers move forward."},
+				},
+				Action: shiftFunctionKeys,
+			},
+			{
+				Name:      "blf",
+				Usage:     "Creates busy lamp field keys for extensions with optional names, e.g. \"20=Linda,21\".",
+				ArgsUsage: "<extensions> [address specifications]",
+				Flags: []cli.Flag{
+					cli.IntFlag{Name: indexFlagName, Usage: "The index of the first key."},
+					cli.StringFlag{Name: pickupCodeFlagName, Usage: "The call pickup code of the keys, defaults to the phone's BLFCallPickupCode."},
+					cli.StringFlag{Name: urlFlagName, Usage: "The URL of the keys, defaults to the phone's BLFURL."},
+				},
+				Action: createBLFKeys,
+			},
+			{
+				Name:  "copy",
+				Usage: "Copies the function keys of a phone or a parameters file to the phones; their other keys are cleared.",
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: fromFlagName, Required: true, Usage: "The source, either a parameters file or the address of a single phone."},
+				},
+				Action: copyFunctionKeys,
+			},
+		},
+	}
+
+	passwordFlags := []cli.Flag{
+		cli.StringFlag{Name: passwordEnvFlagName, Usage: "The name of an environment variable containing the new password of the account."},
+		cli.StringFlag{Name: passwordFileFlagName, Usage: "A file whose first line is the new password of the account.", TakesFile: true},
+		cli.BoolFlag{Name: generatePasswordFlagName, Usage: "Generates a random password for every account; requires --" + savePasswordsFlagName + "."},
+		cli.IntFlag{Name: passwordLengthFlagName, Value: 20, Usage: "The length of generated passwords."},
+		cli.StringFlag{Name: savePasswordsFlagName, Usage: "A new CSV file where generated passwords are saved before they are uploaded.", TakesFile: true},
+	}
+
+	sipCommand := cli.Command{
+		Name:  "sip",
+		Usage: "Provisions the SIP accounts of phones; the accounts are counted from 0.",
+		Subcommands: []cli.Command{
+			{
+				Name:      "account",
+				Usage:     "Adds or updates the SIP account in the slot; settings which are not given are kept, values can be templates.",
+				ArgsUsage: "<slot> [address specifications]",
+				Flags: append([]cli.Flag{
+					cli.StringFlag{Name: registrarFlagName, Usage: "The address of the registration server."},
+					cli.IntFlag{Name: registrarPortFlagName, Usage: "The port of the registration server."},
+					cli.StringFlag{Name: proxyFlagName, Usage: "The address of the proxy server."},
+					cli.IntFlag{Name: proxyPortFlagName, Usage: "The port of the proxy server."},
+					cli.StringFlag{Name: outboundProxyFlagName, Usage: "The address of the outbound proxy."},
+					cli.IntFlag{Name: outboundProxyPortFlagName, Usage: "The port of the outbound proxy."},
+					cli.StringFlag{Name: domainFlagName, Usage: "The SIP domain."},
+					cli.StringFlag{Name: usernameFlagName, Usage: "The user name of the account."},
+					cli.StringFlag{Name: authNameFlagName, Usage: "The authentication name of the account."},
+					cli.StringFlag{Name: displayNameFlagName, Usage: "The display name of the account."},
+					cli.StringFlag{Name: transportFlagName, Usage: "The transport protocol (udp, tcp or tls), which applies to all accounts of a phone."},
+					cli.BoolFlag{Name: enableFlagName, Usage: "Enables the account."},
+				}, passwordFlags...),
+				Action: provisionSipAccount,
+			},
+			{
+				Name:      "enable",
+				Usage:     "Enables the SIP accounts in the slots, e.g. \"0,2-3\".",
+				ArgsUsage: "<slots> [address specifications]",
+				Action:    enableSipAccounts,
+			},
+			{
+				Name:      "disable",
+				Usage:     "Disables the SIP accounts in the slots, e.g. \"0,2-3\".",
+				ArgsUsage: "<slots> [address specifications]",
+				Action:    disableSipAccounts,
+			},
+			{
+				Name:      "password",
+				Usage:     "Rotates the authentication password of the SIP account in the slot.",
+				ArgsUsage: "<slot> [address specifications]",
+				Flags:     passwordFlags,
+				Action:    rotateSipPassword,
+			},
+		},
+	}
+
 	sipOverrideDisplayNamesCommand := cli.Command{
 		Name:  "sip-override",
 		Usage: "Overrides SIP display names if they are not empty",
@@ -105,15 +329,62 @@ func main() {
 		Action: SipOverrideDisplayNames,
 	}
 
+	diffCommand := cli.Command{
+		Name:      "diff",
+		Usage:     "Compares the parameters of phones or parameter files with reference parameters.",
+		ArgsUsage: "[parameter files or address specifications]",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: againstFlagName, Required: true, Usage: "The reference, either a parameters file or the address of a single phone."},
+			cli.BoolFlag{Name: templateFlagName, Usage: "Treats the reference as template, i.e. only compares the parameters set in the reference."},
+			cli.StringFlag{Name: patchDirFlagName, Usage: "A directory where a JSON patch is saved for every phone or file, which turns its parameters into the reference."},
+		},
+		Action: diffParameters,
+	}
+
+	applyCommand := cli.Command{
+		Name:  "apply",
+		Usage: "Changes the parameters of phones so that they match a desired state, uploading only the differing fields.",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: stateFileFlagName, Required: true, Usage: "A JSON file with the desired parameters and per-tag overrides.", TakesFile: true},
+			cli.BoolFlag{Name: dryRunFlagName, Usage: "Same as the global --" + dryRunFlagName + "."},
+		},
+		Action: applyParameters,
+	}
+
+	rollbackCommand := cli.Command{
+		Name:      "rollback",
+		Usage:     "Restores the state of the phones saved in the journal by a run of a changing command; lists the runs if no run ID is given.",
+		ArgsUsage: "[run ID]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: fromBackupFlagName, Usage: "Restores the binary backups saved with --" + journalBackupFlagName + " instead of the parameters."},
+		},
+		Action: rollback,
+	}
+
+	getCommand := cli.Command{
+		Name:      "get",
+		Usage:     "Prints a table with the values of parameters of all phones, e.g. \"Sip[0].DisplayName,TimeServer\".",
+		ArgsUsage: "<comma separated paths> [address specifications]",
+		Action:    getParameters,
+	}
+
+	setCommand := cli.Command{
+		Name:      "set",
+		Usage:     "Sets parameters of phones, e.g. \"FunctionKeys[3].PhoneNumber=20\", uploading only the changed fields.",
+		ArgsUsage: "<path=value>... [address specifications]",
+		Action:    setParameters,
+	}
+
 	resetCommand := cli.Command{
 		Name:   "reset",
 		Usage:  "Resets the whole telephone.",
 		Action: reset,
 	}
 
-	app.Commands = []cli.Command{scanCommand, phoneBookUploadCommand, phonebookDownloadCommand, downloadCommand, restoreCommand, functionKeysReplaceCommand, resetCommand, backup, sipOverrideDisplayNamesCommand}
+	app.Commands = []cli.Command{scanCommand, phoneBookUploadCommand, phonebookDownloadCommand, phoneBookImportCommand, phoneBookMergeCommand, downloadCommand, restoreCommand, functionKeysReplaceCommand, functionKeysCommand, resetCommand, backup, sipOverrideDisplayNamesCommand, sipCommand, diffCommand, applyCommand, rollbackCommand, getCommand, setCommand}
 
-	app.Flags = []cli.Flag{loginFlag, passwordFlag, portFlag, timeoutFlag, verboseFlag}
+	app.Flags = []cli.Flag{loginFlag, passwordFlag, portFlag, timeoutFlag, verboseFlag, schemeFlag, caCertFlag, pinFlag, allowSelfSignedFlag, parallelFlag, subnetParallelFlag, subnetPrefixFlag,
+		maxAttemptsFlag, retryBackoffFlag, retryMaxBackoffFlag, retryJitterFlag, hostsFileFlag, excludeFlag, inventoryFlag, tagFlag, outputFlag, failFastFlag, dryRunFlag, journalFlag, journalBackupFlag, varsFlag}
 
 	err := app.Run(os.Args)
 	if err != nil {
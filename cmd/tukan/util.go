@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/fafeitsch/Tukan/tukan"
+	"github.com/fafeitsch/Tukan/tukan/params"
 	"github.com/urfave/cli"
+	"os"
+	"os/signal"
 	"sort"
 	"strings"
 	"sync"
@@ -11,6 +15,7 @@ import (
 
 type commentedResult struct {
 	*tukan.PhoneResult
+	action  action
 	comment string
 }
 
@@ -27,13 +32,30 @@ const (
 	actionReset
 	actionBackup
 	actionSipOverrideDisplayName
+	actionDiff
+	actionApply
+	actionSaveSnapshot
+	actionRollback
+	actionGet
+	actionSet
+	actionListFunctionKeys
+	actionEditFunctionKeys
+	actionProvisionSip
+	actionImportPhoneBook
+	actionMergePhoneBook
 )
 
 func (a action) String() string {
-	names := []string{"Login", "Logout", "Uploading Phone Book", "Downloading Phone Book", "Replacing Function Keys", "Downloading Parameters", "Uploading Parameters", "Resetting", "Backing up", "Overriding Sip Display Names"}
+	names := []string{"Login", "Logout", "Uploading Phone Book", "Downloading Phone Book", "Replacing Function Keys", "Downloading Parameters", "Uploading Parameters", "Resetting", "Backing up", "Overriding Sip Display Names", "Comparing Parameters", "Applying Parameters", "Saving Snapshot", "Rolling back", "Reading Parameters", "Setting Parameters", "Listing Function Keys", "Editing Function Keys", "Provisioning SIP Accounts", "Importing Phone Book", "Merging Phone Book"}
 	return names[a]
 }
 
+// id returns the name of the action used in the machine-readable output formats.
+func (a action) id() string {
+	ids := []string{"login", "logout", "upload-phonebook", "download-phonebook", "replace-function-keys", "download-parameters", "upload-parameters", "reset", "backup", "override-sip-display-name", "diff", "apply", "save-snapshot", "rollback", "get", "set", "list-function-keys", "edit-function-keys", "provision-sip", "import-phonebook", "merge-phonebook"}
+	return ids[a]
+}
+
 func (a action) handler(consumer chan<- commentedResult) func(*tukan.PhoneResult) {
 	return func(result *tukan.PhoneResult) {
 		var comment string
@@ -42,19 +64,113 @@ func (a action) handler(consumer chan<- commentedResult) func(*tukan.PhoneResult
 		} else {
 			comment = fmt.Sprintf("%s returned error: %v", a.String(), result.Error)
 		}
-		commentedResult := commentedResult{PhoneResult: result, comment: comment}
+		if result.Attempts > 1 {
+			comment = fmt.Sprintf("%s (%d attempts)", comment, result.Attempts)
+		}
+		commentedResult := commentedResult{PhoneResult: result, action: a, comment: comment}
 		consumer <- commentedResult
 	}
 }
 
-func handleResults(wg *sync.WaitGroup, channel chan commentedResult, context *cli.Context) {
+const exitCodeError = 1
+const exitCodeAllFailed = 2
+const exitCodePartialFailure = 3
+const exitCodeAuthFailure = 4
+
+// runSummary aggregates the results of a command to determine its exit code. With --fail-fast,
+// it also stops the connector as soon as the first error occurs.
+type runSummary struct {
+	failed     map[string]bool
+	authFailed map[string]bool
+	stop       chan struct{}
+	once       sync.Once
+}
+
+func newRunSummary(context *cli.Context, connector *tukan.Connector) *runSummary {
+	summary := &runSummary{failed: make(map[string]bool), authFailed: make(map[string]bool)}
+	if context.GlobalBool(failFastFlagName) {
+		summary.stop = make(chan struct{})
+		connector.Stop = summary.stop
+	}
+	return summary
+}
+
+func (r *runSummary) add(result commentedResult) {
+	r.failed[result.Address] = r.failed[result.Address] || result.Error != nil
+	if result.Error == nil {
+		return
+	}
+	if tukan.Classify(result.Error) == tukan.ErrorClassAuth {
+		r.authFailed[result.Address] = true
+	}
+	if r.stop != nil {
+		r.once.Do(func() { close(r.stop) })
+	}
+}
+
+// exitError returns nil if all telephones were successful, otherwise an error whose exit code
+// tells whether authentication failed for some telephones, all telephones failed, or only some of them.
+// Authentication failures take precedence over the other codes.
+func (r *runSummary) exitError() error {
+	failed := 0
+	for _, f := range r.failed {
+		if f {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return nil
+	case len(r.authFailed) != 0:
+		return cli.NewExitError(fmt.Sprintf("authentication failed for %d of %d telephones", len(r.authFailed), len(r.failed)), exitCodeAuthFailure)
+	case failed == len(r.failed):
+		return cli.NewExitError(fmt.Sprintf("all %d telephones failed", failed), exitCodeAllFailed)
+	default:
+		return cli.NewExitError(fmt.Sprintf("%d of %d telephones failed", failed, len(r.failed)), exitCodePartialFailure)
+	}
+}
+
+// reportPlan sends a successful result with the comment, which describes what an action would do or has done.
+func reportPlan(channel chan<- commentedResult, address string, a action, comment string) {
+	channel <- commentedResult{PhoneResult: &tukan.PhoneResult{Address: address}, action: a, comment: comment}
+}
+
+// reportChanges sends one result per change, describing whether the change would happen in a dry run or has happened.
+// Passwords are not reported (see Change.Redacted).
+func reportChanges(channel chan<- commentedResult, address string, a action, changes []params.Change, dryRun bool) {
+	prefix := "Changed"
+	if dryRun {
+		prefix = "Would change"
+	}
+	for _, change := range changes {
+		reportPlan(channel, address, a, fmt.Sprintf("%s %s", prefix, change.Redacted().String()))
+	}
+}
+
+func handleResults(wg *sync.WaitGroup, channel chan commentedResult, context *cli.Context, summary *runSummary) {
 	defer wg.Done()
+	// the inventory was already loaded when the connector was created
+	fleet, err := loadInventory(context)
+	if err != nil {
+		fleet = &phoneInventory{}
+	}
+	if format := context.GlobalString(outputFlagName); format != "" && format != outputText {
+		writeRecords(context.App.Writer, format, channel, fleet, summary)
+		return
+	}
+	label := func(address string) string {
+		if name := fleet.name(address); name != "" {
+			return fmt.Sprintf("%s (%s)", address, name)
+		}
+		return address
+	}
 	verbose := context.GlobalBool(verboseFlagName)
 	results := make(map[string][]string)
 	keys := make([]string, 0, 0)
 	for result := range channel {
+		summary.add(result)
 		if verbose {
-			_, _ = fmt.Fprintf(context.App.Writer, "%s: %s\n", result.Address, result.comment)
+			_, _ = fmt.Fprintf(context.App.Writer, "%s: %s\n", label(result.Address), result.comment)
 		}
 		if _, present := results[result.Address]; !present {
 			results[result.Address] = make([]string, 0, 0)
@@ -69,6 +185,29 @@ func handleResults(wg *sync.WaitGroup, channel chan commentedResult, context *cl
 		_, _ = fmt.Fprint(context.App.Writer, "\n")
 	}
 	for _, key := range keys {
-		_, _ = fmt.Fprintf(context.App.Writer, "%s:\n\t%s\n", key, strings.Join(results[key], "\n\t"))
+		_, _ = fmt.Fprintf(context.App.Writer, "%s:\n\t%s\n", label(key), strings.Join(results[key], "\n\t"))
+	}
+}
+
+// interruptContext returns a context which is canceled as soon as the process receives an
+// interrupt signal (e.g. Ctrl-C), so that pending requests are aborted and the telephones
+// are logged out. The signal handler is released after the first signal, so that a second
+// Ctrl-C terminates the process at once. The returned function must be called to release the
+// signal handler if no signal was received.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
 	}
 }
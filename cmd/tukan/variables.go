@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/inventory"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/urfave/cli"
+	"strings"
+	"text/template"
+)
+
+// phoneVariables provides the variables of templated values like "{{.Owner}}" for every phone.
+type phoneVariables struct {
+	byAddress map[string]map[string]string
+	macs      map[string]string
+	file      *inventory.Variables
+}
+
+// loadPhoneVariables collects the names, MAC addresses and vars of the inventory's devices as well as
+// the variables of the --vars file.
+func loadPhoneVariables(context *cli.Context) (*phoneVariables, error) {
+	result := &phoneVariables{byAddress: make(map[string]map[string]string), macs: make(map[string]string)}
+	fleet, err := loadInventory(context)
+	if err != nil {
+		return nil, err
+	}
+	for address, device := range fleet.byAddress {
+		result.add(address, device.Vars)
+		if device.Name != "" {
+			result.add(address, map[string]string{"Name": device.Name})
+		}
+		if device.MAC != "" {
+			result.macs[address] = device.MAC
+		}
+	}
+	if path := context.GlobalString(varsFlagName); path != "" {
+		file, err := inventory.LoadVariables(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load variables: %v", err)
+		}
+		for _, spec := range file.Addresses() {
+			addresses, err := parseAddresses(context, spec)
+			if err != nil {
+				return nil, fmt.Errorf("could not load variables: %v", err)
+			}
+			for _, address := range addresses {
+				result.add(address, file.ForAddress(spec))
+			}
+		}
+		result.file = file
+	}
+	return result, nil
+}
+
+func (v *phoneVariables) add(address string, values map[string]string) {
+	if v.byAddress[address] == nil {
+		v.byAddress[address] = make(map[string]string)
+	}
+	for name, value := range values {
+		v.byAddress[address][name] = value
+	}
+}
+
+// lookup returns the variables of the phone. Besides the variables of the inventory and of the --vars file,
+// "Address" and "MAC" are always defined. Rows of the --vars file matching the MAC address take
+// precedence over all other variables.
+func (v *phoneVariables) lookup(address string, current *params.Parameters) map[string]string {
+	result := map[string]string{"Address": address, "MAC": v.macs[address]}
+	if current != nil && current.MACAddress != "" {
+		result["MAC"] = current.MACAddress
+	}
+	for name, value := range v.byAddress[address] {
+		result[name] = value
+	}
+	if v.file != nil && result["MAC"] != "" {
+		for name, value := range v.file.ForMAC(result["MAC"]) {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// expandTemplate renders the value as Go template with the variables. Values without "{{" are
+// returned unchanged. Undefined variables are an error.
+func expandTemplate(value string, variables map[string]string) (string, error) {
+	if !isTemplate(value) {
+		return value, nil
+	}
+	parsed, err := parseTemplate(value)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = parsed.Execute(&buffer, variables)
+	if err != nil {
+		return "", fmt.Errorf("could not expand template \"%s\": %v", value, err)
+	}
+	return buffer.String(), nil
+}
+
+func parseTemplate(value string) (*template.Template, error) {
+	parsed, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template \"%s\": %v", value, err)
+	}
+	return parsed, nil
+}
+
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
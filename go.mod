@@ -1,9 +1,10 @@
 module github.com/fafeitsch/Tukan
 
-go 1.14
+go 1.15
 
 require (
 	github.com/gorilla/mux v1.7.4
 	github.com/stretchr/testify v1.4.0
 	github.com/urfave/cli v1.22.2
+	gopkg.in/yaml.v2 v2.2.2
 )
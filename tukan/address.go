@@ -0,0 +1,325 @@
+package tukan
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxAddressesPerSpec limits the number of addresses a single address specification may expand to.
+const MaxAddressesPerSpec = 65536
+
+var hostNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// AddressSpecError describes an address specification that could not be parsed.
+type AddressSpecError struct {
+	Spec   string
+	Reason string
+}
+
+func (a *AddressSpecError) Error() string {
+	return fmt.Sprintf("invalid address \"%s\": %s", a.Spec, a.Reason)
+}
+
+// AddressErrors contains all invalid address specifications found by ParseAddresses.
+type AddressErrors []*AddressSpecError
+
+func (a AddressErrors) Error() string {
+	messages := make([]string, 0, len(a))
+	for _, err := range a {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ParseAddresses expands address specifications into the addresses of telephones, which can be
+// used in a Connector. The following specifications are understood (the port is always optional and
+// defaults to 443 for the scheme https and to 80 otherwise):
+//
+//	10.20.30.40:8080          a single IPv4 address
+//	10.20.30.40:8080+3        an IPv4 address and the next three addresses
+//	10.20.30.0/24:8080        all host addresses of a subnet
+//	10.20.30.10-10.20.30.90   all addresses of a range, both ends inclusive
+//	[fd00::1]:8080, fd00::1   an IPv6 address, the port requires brackets
+//	phone.example.com:8080    a host name
+//	https://10.20.30.40:8443  a complete URL, which is used as is
+//
+// A specification prefixed with "!" excludes the addresses it expands to from the result. If it has no port,
+// the hosts are excluded with all ports and schemes, e.g. "!10.20.30.40" also excludes "https://10.20.30.40:8443".
+// Duplicate addresses are removed; otherwise, the order of the specifications is kept.
+// Invalid specifications are skipped and reported with an error of type AddressErrors.
+//
+// Attention: IP addresses given with "+n" are incremented "stupidly", regardless of any subnet,
+// so addresses like 10.20.30.255 or 10.20.255.0 may occur.
+func ParseAddresses(scheme string, specs ...string) ([]string, error) {
+	return ParseAddressesWithPort(scheme, 0, specs...)
+}
+
+// ParseAddressesWithPort is like ParseAddresses, but addresses without port get the passed port.
+// A port of 0 stands for the default port of the scheme.
+func ParseAddressesWithPort(scheme string, port int, specs ...string) ([]string, error) {
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("default port %d is not between 1 and 65535", port)
+	}
+	defaultPort := port
+	switch {
+	case defaultPort != 0:
+	case scheme == "https":
+		defaultPort = 443
+	default:
+		defaultPort = 80
+	}
+	included := make([]string, 0, len(specs))
+	excluded := make(map[string]bool)
+	excludedHosts := make(map[string]bool)
+	var errs AddressErrors
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		exclude := strings.HasPrefix(spec, "!")
+		addresses, err := parseAddressSpec(scheme, defaultPort, strings.TrimPrefix(spec, "!"))
+		if err != nil {
+			errs = append(errs, &AddressSpecError{Spec: spec, Reason: err.Error()})
+			continue
+		}
+		byHost := exclude && !hasPort(strings.TrimPrefix(spec, "!"))
+		for _, address := range addresses {
+			if byHost {
+				excludedHosts[addressHost(address)] = true
+			} else if exclude {
+				excluded[address] = true
+			} else {
+				included = append(included, address)
+			}
+		}
+	}
+	result := make([]string, 0, len(included))
+	seen := make(map[string]bool)
+	for _, address := range included {
+		if !excluded[address] && !excludedHosts[addressHost(address)] && !seen[address] {
+			result = append(result, address)
+			seen[address] = true
+		}
+	}
+	if len(errs) != 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+// ReadAddressSpecs reads address specifications, one per line, as they can be passed to
+// ParseAddresses. Empty lines and everything after a "#" are ignored.
+func ReadAddressSpecs(reader io.Reader) ([]string, error) {
+	result := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment != -1 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result, scanner.Err()
+}
+
+func parseAddressSpec(scheme string, defaultPort int, spec string) ([]string, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("empty specification")
+	}
+	if strings.Contains(spec, "://") {
+		parsed, err := url.Parse(spec)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return nil, fmt.Errorf("not a valid http or https URL")
+		}
+		return []string{strings.TrimSuffix(spec, "/")}, nil
+	}
+	host, count, err := splitCount(spec)
+	if err != nil {
+		return nil, err
+	}
+	host, port, err := splitPort(host, defaultPort)
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	switch {
+	case strings.Contains(host, "/"):
+		ips, err = cidrAddresses(host)
+	case strings.Contains(host, "-") && net.ParseIP(host[:strings.Index(host, "-")]) != nil:
+		ips, err = rangeAddresses(host)
+	case net.ParseIP(host) != nil:
+		ips, err = incrementedAddresses(net.ParseIP(host), count)
+		count = 0
+	case hostNamePattern.MatchString(host):
+		if count != 0 {
+			return nil, fmt.Errorf("host names cannot be incremented")
+		}
+		return []string{fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))}, nil
+	default:
+		return nil, fmt.Errorf("neither an IP address, a range, a subnet, nor a host name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if count != 0 {
+		return nil, fmt.Errorf("only single IP addresses can be incremented")
+	}
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(ip.String(), strconv.Itoa(port))))
+	}
+	return result, nil
+}
+
+// hasPort returns true if the specification has a port. URLs are treated as if they had one,
+// because they are used as they are.
+func hasPort(spec string) bool {
+	if strings.Contains(spec, "://") {
+		return true
+	}
+	host, _, err := splitCount(spec)
+	if err != nil {
+		return false
+	}
+	_, port, err := splitPort(host, 0)
+	return err == nil && port != 0
+}
+
+// addressHost returns the host of the address, with IP addresses in their canonical form.
+func addressHost(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func splitCount(spec string) (string, int, error) {
+	modifier := strings.LastIndex(spec, "+")
+	if modifier == -1 {
+		return spec, 0, nil
+	}
+	count, err := strconv.Atoi(spec[modifier+1:])
+	if err != nil || count < 0 {
+		return "", 0, fmt.Errorf("the number after \"+\" must be a non-negative integer")
+	}
+	return spec[:modifier], count, nil
+}
+
+func splitPort(spec string, defaultPort int) (string, int, error) {
+	host, portString := spec, ""
+	if strings.HasPrefix(spec, "[") {
+		end := strings.Index(spec, "]")
+		if end == -1 {
+			return "", 0, fmt.Errorf("missing \"]\"")
+		}
+		host = spec[1:end]
+		rest := spec[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, ":") {
+			return "", 0, fmt.Errorf("unexpected characters after \"]\"")
+		}
+		portString = strings.TrimPrefix(rest, ":")
+		fields := strings.FieldsFunc(host, isRangeOrSubnetSeparator)
+		if len(fields) == 0 || net.ParseIP(fields[0]) == nil || net.ParseIP(fields[0]).To4() != nil {
+			return "", 0, fmt.Errorf("brackets are only allowed around IPv6 addresses")
+		}
+	} else if strings.Count(spec, ":") == 1 {
+		colon := strings.Index(spec, ":")
+		host, portString = spec[:colon], spec[colon+1:]
+	}
+	if portString == "" {
+		return host, defaultPort, nil
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("port must be a number between 1 and 65535")
+	}
+	return host, port, nil
+}
+
+func isRangeOrSubnetSeparator(r rune) bool {
+	return r == '-' || r == '/'
+}
+
+func incrementedAddresses(start net.IP, count int) ([]net.IP, error) {
+	if count >= MaxAddressesPerSpec {
+		return nil, fmt.Errorf("expands to more than %d addresses", MaxAddressesPerSpec)
+	}
+	if ipv4 := start.To4(); ipv4 != nil {
+		start = ipv4
+	}
+	result := make([]net.IP, 0, count+1)
+	current := start
+	for i := 0; i <= count; i++ {
+		result = append(result, current)
+		current = nextIP(current)
+	}
+	return result, nil
+}
+
+func rangeAddresses(spec string) ([]net.IP, error) {
+	separator := strings.Index(spec, "-")
+	start, end := net.ParseIP(spec[:separator]), net.ParseIP(spec[separator+1:])
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("both ends of a range must be IP addresses")
+	}
+	if (start.To4() == nil) != (end.To4() == nil) {
+		return nil, fmt.Errorf("both ends of a range must be of the same IP version")
+	}
+	if start.To4() != nil {
+		start, end = start.To4(), end.To4()
+	}
+	distance := new(big.Int).Sub(new(big.Int).SetBytes(end), new(big.Int).SetBytes(start))
+	if distance.Sign() < 0 {
+		return nil, fmt.Errorf("the start of a range must not be greater than its end")
+	}
+	if !distance.IsInt64() || distance.Int64() >= MaxAddressesPerSpec {
+		return nil, fmt.Errorf("expands to more than %d addresses", MaxAddressesPerSpec)
+	}
+	return incrementedAddresses(start, int(distance.Int64()))
+}
+
+func cidrAddresses(spec string) ([]net.IP, error) {
+	ip, network, err := net.ParseCIDR(spec)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid subnet")
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones >= 17 {
+		return nil, fmt.Errorf("expands to more than %d addresses", MaxAddressesPerSpec)
+	}
+	if ip.To4() != nil {
+		ip = ip.To4()
+	}
+	size := 1 << uint(bits-ones)
+	start := network.IP
+	if ip.To4() != nil && size > 2 {
+		// skip the network and the broadcast address
+		start = nextIP(start)
+		size = size - 2
+	}
+	return incrementedAddresses(start, size-1)
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for j := len(next) - 1; j >= 0; j-- {
+		next[j]++
+		if next[j] > 0 {
+			break
+		}
+	}
+	return next
+}
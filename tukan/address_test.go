@@ -0,0 +1,110 @@
+package tukan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func ExampleParseAddresses() {
+	addresses, err := ParseAddresses("http", "127.0.0.1", "not an ip", "10.20.30.40+2", "20.20.20.20:8080+1", "30.30.30.30:1234", "!10.20.30.41")
+	for _, address := range addresses {
+		fmt.Printf("%s\n", address)
+	}
+	fmt.Printf("%v\n", err)
+	// Output: http://127.0.0.1:80
+	// http://10.20.30.40:80
+	// http://10.20.30.42:80
+	// http://20.20.20.20:8080
+	// http://20.20.20.21:8080
+	// http://30.30.30.30:1234
+	// invalid address "not an ip": neither an IP address, a range, a subnet, nor a host name
+}
+
+func TestParseAddresses(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		specs  []string
+		want   []string
+	}{
+		{name: "increment", scheme: "http", specs: []string{"10.1.254.254:8081+2"}, want: []string{"http://10.1.254.254:8081", "http://10.1.254.255:8081", "http://10.1.255.0:8081"}},
+		{name: "https default port", scheme: "https", specs: []string{"10.20.30.40+1", "20.20.20.20:8443"}, want: []string{"https://10.20.30.40:443", "https://10.20.30.41:443", "https://20.20.20.20:8443"}},
+		{name: "cidr", scheme: "http", specs: []string{"10.20.30.0/30:8080"}, want: []string{"http://10.20.30.1:8080", "http://10.20.30.2:8080"}},
+		{name: "cidr /32", scheme: "http", specs: []string{"10.20.30.7/32"}, want: []string{"http://10.20.30.7:80"}},
+		{name: "range", scheme: "http", specs: []string{"10.20.30.254-10.20.31.1"}, want: []string{"http://10.20.30.254:80", "http://10.20.30.255:80", "http://10.20.31.0:80", "http://10.20.31.1:80"}},
+		{name: "host name", scheme: "https", specs: []string{"phone-1.example.com", "phone2:8443"}, want: []string{"https://phone-1.example.com:443", "https://phone2:8443"}},
+		{name: "ipv6", scheme: "http", specs: []string{"fd00::1", "[fd00::2]:8080+1"}, want: []string{"http://[fd00::1]:80", "http://[fd00::2]:8080", "http://[fd00::3]:8080"}},
+		{name: "ipv6 range", scheme: "http", specs: []string{"[fd00::1-fd00::2]:8080"}, want: []string{"http://[fd00::1]:8080", "http://[fd00::2]:8080"}},
+		{name: "url", scheme: "https", specs: []string{"http://127.0.0.1:4321/"}, want: []string{"http://127.0.0.1:4321"}},
+		{name: "exclusion and duplicates", scheme: "http", specs: []string{"10.0.0.1+3", "!10.0.0.2-10.0.0.3", "10.0.0.1"}, want: []string{"http://10.0.0.1:80", "http://10.0.0.4:80"}},
+		{name: "exclusion by host", scheme: "http", specs: []string{"10.0.0.1:8080+1", "https://10.0.0.1:8443", "[fd00::1]:8080", "Phone:8080", "!10.0.0.1", "!fd00:0::1", "!phone"}, want: []string{"http://10.0.0.2:8080"}},
+		{name: "exclusion with port", scheme: "http", specs: []string{"10.0.0.1", "10.0.0.1:8080", "!10.0.0.1:8080"}, want: []string{"http://10.0.0.1:80"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAddresses(tt.scheme, tt.specs...)
+			require.NoError(t, err, "no error expected")
+			assert.Equal(t, tt.want, got, "addresses are wrong")
+		})
+	}
+}
+
+func TestParseAddressesWithPort(t *testing.T) {
+	got, err := ParseAddressesWithPort("https", 8443, "10.20.30.40", "10.20.30.41:443", "!10.20.30.42", "http://10.20.30.43")
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []string{"https://10.20.30.40:8443", "https://10.20.30.41:443", "http://10.20.30.43"}, got, "addresses without port should get the passed port")
+
+	got, err = ParseAddressesWithPort("https", 0, "10.20.30.40")
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []string{"https://10.20.30.40:443"}, got, "port of the scheme expected")
+
+	_, err = ParseAddressesWithPort("http", 70000, "10.20.30.40")
+	assert.EqualError(t, err, "default port 70000 is not between 1 and 65535", "error is wrong")
+}
+
+func TestParseAddresses_Invalid(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "10.0.0.1:http", want: "port must be a number between 1 and 65535"},
+		{spec: "10.0.0.1:70000", want: "port must be a number between 1 and 65535"},
+		{spec: "10.0.0.1+x", want: "the number after \"+\" must be a non-negative integer"},
+		{spec: "10.0.0.5-10.0.0.1", want: "the start of a range must not be greater than its end"},
+		{spec: "10.0.0.1-fd00::1", want: "both ends of a range must be of the same IP version"},
+		{spec: "10.0.0.0/8", want: "expands to more than 65536 addresses"},
+		{spec: "10.0.0.0/24+2", want: "only single IP addresses can be incremented"},
+		{spec: "phone+2", want: "host names cannot be incremented"},
+		{spec: "[10.0.0.1]:80", want: "brackets are only allowed around IPv6 addresses"},
+		{spec: "[]", want: "brackets are only allowed around IPv6 addresses"},
+		{spec: "ftp://10.0.0.1", want: "not a valid http or https URL"},
+		{spec: "  ", want: "empty specification"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseAddresses("http", tt.spec, "10.0.0.1")
+			assert.Equal(t, []string{"http://10.0.0.1:80"}, got, "valid addresses should still be returned")
+			var errs AddressErrors
+			require.True(t, errors.As(err, &errs), "error should be of type AddressErrors")
+			require.Equal(t, 1, len(errs), "exactly one invalid address expected")
+			assert.Equal(t, strings.TrimSpace(tt.spec), errs[0].Spec, "invalid spec is wrong")
+			assert.Equal(t, tt.want, errs[0].Reason, "reason is wrong")
+		})
+	}
+}
+
+func TestReadAddressSpecs(t *testing.T) {
+	file := `# phones of the first floor
+10.20.30.0/28
+  10.20.31.5:8080 # reception
+
+!10.20.30.1
+`
+	got, err := ReadAddressSpecs(strings.NewReader(file))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []string{"10.20.30.0/28", "10.20.31.5:8080", "!10.20.30.1"}, got, "specs are wrong")
+}
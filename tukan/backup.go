@@ -2,31 +2,37 @@ package tukan
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
-	"net/http"
 	"net/textproto"
 )
 
+// Downloads a binary backup of all settings from the telephone. The backup
+// can be restored with Phone#Restore.
 func (p *Phone) Backup() ([]byte, error) {
-	url := fmt.Sprintf("%s/SaveAllSettings", p.Address)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(req)
-	if err == nil {
-		defer resp.Body.Close()
-	}
-	err = checkResponse(resp, err)
+	return p.BackupContext(context.Background())
+}
+
+// BackupContext is like Backup, but the request is bound to the context.
+func (p *Phone) BackupContext(ctx context.Context) ([]byte, error) {
+	resp, err := p.do(ctx, "GET", "SaveAllSettings", nil, "")
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	return ioutil.ReadAll(resp.Body)
 }
 
+// Uploads a binary backup, as created by Phone#Backup, to the telephone.
 func (p *Phone) Restore(data []byte) error {
-	url := fmt.Sprintf("%s/RestoreSettings", p.Address)
+	return p.RestoreContext(context.Background(), data)
+}
+
+// RestoreContext is like Restore, but the request is bound to the context.
+func (p *Phone) RestoreContext(ctx context.Context, data []byte) error {
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
 	part, err := injectableCreateFormFile(writer)
@@ -38,11 +44,11 @@ func (p *Phone) Restore(data []byte) error {
 	if err != nil {
 		return err
 	}
-	request, _ := http.NewRequest("POST", url, body)
-	request.Header.Add("Authorization", "Bearer "+p.token)
-	request.Header.Add("Content-Type", writer.FormDataContentType())
-	resp, err := p.client.Do(request)
-	return checkResponse(resp, err)
+	resp, err := p.do(ctx, "POST", "RestoreSettings", body.Bytes(), writer.FormDataContentType())
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	return err
 }
 
 func injectableCreateFormFile(w *multipart.Writer) (io.Writer, error) {
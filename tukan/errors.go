@@ -0,0 +1,139 @@
+package tukan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// HTTPStatusError is returned if a telephone answers a request with an unsuccessful status code.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d with message \"%s\"", e.StatusCode, e.Status)
+}
+
+// AuthError is returned if a telephone rejects the credentials or the token, that is if it
+// answers with 401 or 403. An AuthError wraps the corresponding HTTPStatusError.
+type AuthError struct {
+	HTTPStatusError
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication error, status code: %d with message \"%s\" and content \"%s\"", e.StatusCode, e.Status, e.Body)
+}
+
+func (e *AuthError) Unwrap() error {
+	return &e.HTTPStatusError
+}
+
+// TransportError is returned if a request could not be sent to a telephone or
+// no response was received, for example because of a timeout or a refused connection.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned if the response of a telephone could not be decoded,
+// for example because the firmware uses an unexpected format.
+type DecodeError struct {
+	Address string
+	Content string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("could not decode %s from %s: %v", e.Content, e.Address, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass is a short and stable name for the kind of an error, see Classify.
+type ErrorClass string
+
+const (
+	ErrorClassNone      ErrorClass = ""
+	ErrorClassAuth      ErrorClass = "auth"
+	ErrorClassHTTP      ErrorClass = "http"
+	ErrorClassTransport ErrorClass = "transport"
+	ErrorClassDecode    ErrorClass = "decode"
+	ErrorClassCanceled  ErrorClass = "canceled"
+	ErrorClassOther     ErrorClass = "other"
+)
+
+// Classify returns the class of the error, which is ErrorClassNone if the error is nil.
+// Errors caused by a canceled context or a stopped Connector are classified as ErrorClassCanceled,
+// regardless of their type.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrStopped) {
+		return ErrorClassCanceled
+	}
+	switch {
+	case errors.As(err, new(*AuthError)):
+		return ErrorClassAuth
+	case errors.As(err, new(*HTTPStatusError)):
+		return ErrorClassHTTP
+	case errors.As(err, new(*TransportError)):
+		return ErrorClassTransport
+	case errors.As(err, new(*DecodeError)):
+		return ErrorClassDecode
+	}
+	return ErrorClassOther
+}
+
+// IsTransient returns true if the error is likely to disappear when the request is repeated.
+// This is the case for timeouts, refused or reset connections, as well as for the status codes 502, 503, and 504.
+// Canceled requests and all other status codes are not considered transient.
+func IsTransient(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isUnsent returns true if the error occurred before the request was sent to the telephone, that is
+// while the connection was established. Such requests can be repeated even if they change the telephone.
+func isUnsent(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,103 @@
+package tukan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	handler, _ := mock.CreatePhone(username, password)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	t.Run("authentication error", func(t *testing.T) {
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: "wrong"}
+		_, err := connector.SingleConnect(server.URL)
+		var authErr *AuthError
+		require.True(t, errors.As(err, &authErr), "error should be an AuthError")
+		assert.Equal(t, http.StatusForbidden, authErr.StatusCode, "status code is wrong")
+		assert.Equal(t, "provided credentials not valid", authErr.Body, "body is wrong")
+		var statusErr *HTTPStatusError
+		require.True(t, errors.As(err, &statusErr), "an AuthError should also be an HTTPStatusError")
+		assert.Equal(t, http.StatusForbidden, statusErr.StatusCode, "status code is wrong")
+	})
+	t.Run("status error", func(t *testing.T) {
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password}
+		_, err := connector.SingleConnect(server.URL + "/unknown")
+		var statusErr *HTTPStatusError
+		require.True(t, errors.As(err, &statusErr), "error should be an HTTPStatusError")
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode, "status code is wrong")
+		assert.False(t, errors.As(err, new(*AuthError)), "error should not be an AuthError")
+	})
+	t.Run("transport error", func(t *testing.T) {
+		closed := httptest.NewServer(handler)
+		closed.Close()
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password}
+		_, err := connector.SingleConnect(closed.URL)
+		assert.True(t, errors.As(err, new(*TransportError)), "error should be a TransportError")
+		assert.True(t, errors.Is(err, syscall.ECONNREFUSED), "error should wrap the original error")
+	})
+	t.Run("decode error", func(t *testing.T) {
+		garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, "<html>")
+		}))
+		defer garbage.Close()
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password}
+		_, err := connector.SingleConnect(garbage.URL)
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr), "error should be a DecodeError")
+		assert.Equal(t, garbage.URL, decodeErr.Address, "address is wrong")
+		assert.EqualError(t, err, "could not decode token from "+garbage.URL+": invalid character '<' looking for beginning of value", "error message is wrong")
+	})
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "service unavailable", err: &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "bad gateway", err: &HTTPStatusError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "not found", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "unauthorized", err: &AuthError{HTTPStatusError{StatusCode: http.StatusUnauthorized}}, want: false},
+		{name: "connection refused", err: &TransportError{Err: syscall.ECONNREFUSED}, want: true},
+		{name: "canceled", err: &TransportError{Err: context.Canceled}, want: false},
+		{name: "decode error", err: &DecodeError{Err: errors.New("invalid")}, want: false},
+		{name: "nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err), "classification is wrong")
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{name: "nil", err: nil, want: ErrorClassNone},
+		{name: "auth", err: &AuthError{HTTPStatusError{StatusCode: http.StatusForbidden}}, want: ErrorClassAuth},
+		{name: "status", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: ErrorClassHTTP},
+		{name: "transport", err: &TransportError{Err: syscall.ECONNREFUSED}, want: ErrorClassTransport},
+		{name: "canceled", err: &TransportError{Err: context.Canceled}, want: ErrorClassCanceled},
+		{name: "stopped", err: ErrStopped, want: ErrorClassCanceled},
+		{name: "decode", err: fmt.Errorf("wrapped: %w", &DecodeError{Err: errors.New("invalid")}), want: ErrorClassDecode},
+		{name: "other", err: errors.New("file not found"), want: ErrorClassOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err), "classification is wrong")
+		})
+	}
+}
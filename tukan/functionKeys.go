@@ -1,34 +1,32 @@
 package tukan
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/fafeitsch/Tukan/tukan/params"
-	"net/http"
 )
 
 // Downloads the phone's parameters, for example the function key definitions from the
 // telephone or returns an error if the download is not successful.
 func (p *Phone) DownloadParameters() (*params.Parameters, error) {
-	url := fmt.Sprintf("%s/Parameters", p.Address)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(req)
-	if err == nil {
-		defer resp.Body.Close()
-	}
-	err = checkResponse(resp, err)
+	return p.DownloadParametersContext(context.Background())
+}
+
+// DownloadParametersContext is like DownloadParameters, but the request is bound to the context.
+func (p *Phone) DownloadParametersContext(ctx context.Context) (*params.Parameters, error) {
+	resp, err := p.do(ctx, "GET", "Parameters", nil, "")
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	params := params.Parameters{}
 	err = json.NewDecoder(resp.Body).Decode(&params)
 	if err == nil {
+		p.functionKeys = len(params.FunctionKeys)
 		params.FunctionKeys = purgeTrailingFunctionKeys(params.FunctionKeys)
 		return &params, nil
 	}
-	return nil, err
+	return nil, &DecodeError{Address: p.Address, Content: "parameters", Err: err}
 }
 
 func purgeTrailingFunctionKeys(keys params.FunctionKeys) params.FunctionKeys {
@@ -45,18 +43,19 @@ func purgeTrailingFunctionKeys(keys params.FunctionKeys) params.FunctionKeys {
 	return result
 }
 
-// Uploads the parameters to the telephone. Returns an error if
+// Uploads the parameters to the telephone. Unknown settings are uploaded without their metadata
+// (see Parameters.WithoutMetadata). Returns an error if
 // an error occurred during the request or if the response code was not successful.
 func (p *Phone) UploadParameters(params params.Parameters) error {
-	url := fmt.Sprintf("%s/Parameters", p.Address)
-	payload, _ := json.Marshal(params)
-	reader := bytes.NewBuffer(payload)
-	req, _ := http.NewRequest("POST", url, reader)
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(req)
+	return p.UploadParametersContext(context.Background(), params)
+}
+
+// UploadParametersContext is like UploadParameters, but the request is bound to the context.
+func (p *Phone) UploadParametersContext(ctx context.Context, params params.Parameters) error {
+	payload, _ := json.Marshal(params.WithoutMetadata())
+	resp, err := p.do(ctx, "POST", "Parameters", payload, "application/json")
 	if err == nil {
-		defer resp.Body.Close()
+		_ = resp.Body.Close()
 	}
-	return checkResponse(resp, err)
+	return err
 }
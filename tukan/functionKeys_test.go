@@ -1,6 +1,7 @@
 package tukan
 
 import (
+	"encoding/json"
 	"github.com/fafeitsch/Tukan/tukan/mock"
 	"github.com/fafeitsch/Tukan/tukan/params"
 	"github.com/stretchr/testify/assert"
@@ -31,6 +32,7 @@ func TestPhone_DownloadParameters(t *testing.T) {
 		require.NoError(t, err, "error should be nil")
 		require.NotNil(t, got, "response object should not be nil")
 		assert.Equal(t, 3, len(got.FunctionKeys), "there should be three function keys after pruning")
+		assert.Equal(t, 6, phone.FunctionKeys(), "the pruned keys should be counted")
 		assert.Equal(t, "", got.FunctionKeys[0].DisplayName, "display name of first entry should be empty")
 		assert.Equal(t, "Ellen", got.FunctionKeys[1].DisplayName, "display name of second function key is wrong")
 		assert.Equal(t, "Alan", got.FunctionKeys[2].DisplayName, "display name of third function key is wrong")
@@ -68,3 +70,22 @@ func TestPhone_UploadParameters(t *testing.T) {
 		assert.Equal(t, "", telephone.Parameters.FunctionKeys[1].CallPickupCode, "CallPickupCode should not have been changed")
 	})
 }
+
+func TestPhone_UnknownEntryFields(t *testing.T) {
+	handler, telephone := mock.CreatePhone(username, password)
+	telephone.Parameters.Sip = params.Sips{{DisplayName: "Office", Unknown: map[string]json.RawMessage{"SRTPMode": json.RawMessage(`"2"`)}}}
+	telephone.Parameters.FunctionKeys = params.FunctionKeys{{DisplayName: "John", Unknown: map[string]json.RawMessage{"LEDMode": json.RawMessage(`"blink"`)}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	connector := Connector{Client: http.DefaultClient, UserName: username, Password: password}
+	phone, err := connector.SingleConnect(server.URL)
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = phone.Logout() }()
+
+	downloaded, err := phone.DownloadParameters()
+	require.NoError(t, err, "no error expected")
+	require.NoError(t, phone.UploadParameters(*downloaded), "no error expected")
+
+	assert.JSONEq(t, `"2"`, string(telephone.Parameters.Sip[0].Unknown["SRTPMode"]), "unknown field of SIP account should survive the round trip")
+	assert.JSONEq(t, `"blink"`, string(telephone.Parameters.FunctionKeys[0].Unknown["LEDMode"]), "unknown field of function key should survive the round trip")
+}
@@ -0,0 +1,131 @@
+// Package inventory describes a fleet of telephones: their addresses, their credentials,
+// friendly names and arbitrary tags. An inventory is read from a YAML or JSON file, for example:
+//
+//	login: Admin
+//	password: admin
+//	phones:
+//	  - address: 10.20.30.40
+//	    name: Reception
+//	    tags: [floor3, sales]
+//	  - address: 10.20.30.41:8080
+//	    name: Room 3.12
+//	    mac: 00:1a:2b:3c:4d:5e
+//	    password: secret
+//	    tags: [floor3]
+//	    vars:
+//	      Extension: "312"
+//	      Owner: John Doe
+//
+// The login and password on the top level are used for all phones that do not define their own.
+// The vars of a phone are available in templated parameter values, see Variables.
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Device is a single telephone of the inventory. The address is an address
+// specification as understood by tukan.ParseAddresses.
+type Device struct {
+	Address  string            `json:"address" yaml:"address"`
+	Name     string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Login    string            `json:"login,omitempty" yaml:"login,omitempty"`
+	Password string            `json:"password,omitempty" yaml:"password,omitempty"`
+	Tags     []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	MAC      string            `json:"mac,omitempty" yaml:"mac,omitempty"`
+	Vars     map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+}
+
+// HasTags returns true if the device carries all of the passed tags.
+func (d *Device) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range d.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Inventory is a list of devices with default credentials.
+type Inventory struct {
+	Login    string   `json:"login,omitempty" yaml:"login,omitempty"`
+	Password string   `json:"password,omitempty" yaml:"password,omitempty"`
+	Devices  []Device `json:"phones" yaml:"phones"`
+}
+
+// Load reads an inventory from a file. Files ending with ".json" are parsed as JSON,
+// all other files as YAML.
+func Load(path string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return ParseJSON(data)
+	}
+	return ParseYAML(data)
+}
+
+// ParseJSON parses an inventory in JSON format. Like with ParseYAML, unknown fields are an error.
+func ParseJSON(data []byte) (*Inventory, error) {
+	inventory := Inventory{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&inventory)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse inventory: %v", err)
+	}
+	return inventory.complete()
+}
+
+// ParseYAML parses an inventory in YAML format.
+func ParseYAML(data []byte) (*Inventory, error) {
+	inventory := Inventory{}
+	err := yaml.UnmarshalStrict(data, &inventory)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse inventory: %v", err)
+	}
+	return inventory.complete()
+}
+
+// complete checks that every device has an address and fills in the default credentials.
+func (i *Inventory) complete() (*Inventory, error) {
+	for index := range i.Devices {
+		device := &i.Devices[index]
+		if strings.TrimSpace(device.Address) == "" {
+			return nil, fmt.Errorf("phone %d of the inventory has no address", index+1)
+		}
+		if device.Login == "" {
+			device.Login = i.Login
+		}
+		if device.Password == "" {
+			device.Password = i.Password
+		}
+	}
+	return i, nil
+}
+
+// Select returns all devices carrying all of the passed tags. If no tags
+// are passed, all devices are returned.
+func (i *Inventory) Select(tags ...string) []Device {
+	result := make([]Device, 0, len(i.Devices))
+	for _, device := range i.Devices {
+		if device.HasTags(tags...) {
+			result = append(result, device)
+		}
+	}
+	return result
+}
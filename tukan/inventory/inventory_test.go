@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fleetYAML = `login: Admin
+password: admin
+phones:
+  - address: 10.20.30.40
+    name: Reception
+    tags: [floor3, sales]
+  - address: 10.20.30.41:8080
+    name: Room 3.12
+    password: secret
+    tags: [floor3]
+  - address: 10.20.40.1
+    login: root
+    tags: [floor4]
+`
+
+func TestParseYAML(t *testing.T) {
+	got, err := ParseYAML([]byte(fleetYAML))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 3, len(got.Devices), "number of devices is wrong")
+	assert.Equal(t, Device{Address: "10.20.30.40", Name: "Reception", Login: "Admin", Password: "admin", Tags: []string{"floor3", "sales"}}, got.Devices[0], "first device is wrong")
+	assert.Equal(t, "Admin", got.Devices[1].Login, "default login should be used")
+	assert.Equal(t, "secret", got.Devices[1].Password, "own password should be used")
+	assert.Equal(t, "root", got.Devices[2].Login, "own login should be used")
+	assert.Equal(t, "admin", got.Devices[2].Password, "default password should be used")
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := ParseYAML([]byte("phones:\n  - adress: 10.20.30.40\n"))
+		assert.Error(t, err, "typos should be reported")
+	})
+	t.Run("missing address", func(t *testing.T) {
+		_, err := ParseYAML([]byte("phones:\n  - name: Reception\n"))
+		assert.EqualError(t, err, "phone 1 of the inventory has no address", "error message is wrong")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tukan-inventory")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "fleet.json")
+	err = ioutil.WriteFile(path, []byte(`{"password": "admin", "phones": [{"address": "10.20.30.40", "tags": ["floor3"]}]}`), os.ModePerm)
+	require.NoError(t, err, "no error expected")
+	got, err := Load(path)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []Device{{Address: "10.20.30.40", Password: "admin", Tags: []string{"floor3"}}}, got.Devices, "devices are wrong")
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err, "missing files should be reported")
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := ParseJSON([]byte(`{"login": "Admin", "phones": [{"address": "10.20.30.40", "name": "Reception"}]}`))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []Device{{Address: "10.20.30.40", Name: "Reception", Login: "Admin"}}, got.Devices, "devices are wrong")
+	_, err = ParseJSON([]byte(`{"phones": [{"address": "10.20.30.40", "pasword": "secret"}]}`))
+	assert.EqualError(t, err, "could not parse inventory: json: unknown field \"pasword\"", "typos should be reported")
+}
+
+func TestInventory_Select(t *testing.T) {
+	fleet, err := ParseYAML([]byte(fleetYAML))
+	require.NoError(t, err, "no error expected")
+	names := func(devices []Device) []string {
+		result := make([]string, 0, len(devices))
+		for _, device := range devices {
+			result = append(result, device.Address)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"10.20.30.40", "10.20.30.41:8080", "10.20.40.1"}, names(fleet.Select()), "without tags, all devices should be selected")
+	assert.Equal(t, []string{"10.20.30.40", "10.20.30.41:8080"}, names(fleet.Select("floor3")), "selection by one tag is wrong")
+	assert.Equal(t, []string{"10.20.30.40"}, names(fleet.Select("floor3", "sales")), "selection by two tags is wrong")
+	assert.Empty(t, fleet.Select("floor5"), "no device should be selected")
+}
+
+func TestParseVariables(t *testing.T) {
+	got, err := ParseVariables(strings.NewReader("Owner,MAC,Extension\nJohn Doe,00:1A:2B:3C:4D:5E,201\nMary Hope,001a2b3c4d5f,202\n"))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, map[string]string{"Owner": "John Doe", "Extension": "201"}, got.ForMAC("001a.2b3c.4d5e"), "variables of first phone are wrong")
+	assert.Equal(t, map[string]string{"Owner": "Mary Hope", "Extension": "202"}, got.ForMAC("00-1a-2b-3c-4d-5f"), "variables of second phone are wrong")
+	assert.Nil(t, got.ForAddress("10.20.30.40"), "no variables by address expected")
+
+	got, err = ParseVariables(strings.NewReader("address,Owner\n10.20.30.40,John Doe\n"))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, []string{"10.20.30.40"}, got.Addresses(), "addresses are wrong")
+	assert.Equal(t, map[string]string{"Owner": "John Doe"}, got.ForAddress("10.20.30.40"), "variables are wrong")
+
+	_, err = ParseVariables(strings.NewReader("Owner,Extension\nJohn Doe,201\n"))
+	assert.EqualError(t, err, "could not parse variables: column \"address\" or \"mac\" is missing", "error is wrong")
+	_, err = ParseVariables(strings.NewReader("address,Owner\n,John Doe\n"))
+	assert.EqualError(t, err, "could not parse variables: address of line 2 is empty", "error is wrong")
+}
+
+func TestParseYAML_Vars(t *testing.T) {
+	got, err := ParseYAML([]byte("phones:\n  - address: 10.20.30.40\n    mac: 00:1a:2b:3c:4d:5e\n    vars:\n      Extension: \"201\"\n"))
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, "00:1a:2b:3c:4d:5e", got.Devices[0].MAC, "MAC address is wrong")
+	assert.Equal(t, map[string]string{"Extension": "201"}, got.Devices[0].Vars, "vars are wrong")
+}
@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Variables are the values of templated parameters per phone, keyed by the address or the
+// MAC address of the phone. They are read from a CSV file whose header names the variables. The column
+// "address" or "mac" (in any case) identifies the phone of a row, e.g.
+//
+//	address,Extension,Owner
+//	10.20.30.40,201,John Doe
+//	10.20.30.41,202,Mary Hope
+type Variables struct {
+	byAddress map[string]map[string]string
+	byMAC     map[string]map[string]string
+}
+
+// LoadVariables reads variables from a CSV file.
+func LoadVariables(path string) (*Variables, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return ParseVariables(file)
+}
+
+// ParseVariables reads variables in CSV format.
+func ParseVariables(reader io.Reader) (*Variables, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse variables: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("could not parse variables: header is missing")
+	}
+	header := records[0]
+	key := -1
+	for index, name := range header {
+		if strings.EqualFold(name, "address") || strings.EqualFold(name, "mac") {
+			key = index
+			break
+		}
+	}
+	if key == -1 {
+		return nil, fmt.Errorf("could not parse variables: column \"address\" or \"mac\" is missing")
+	}
+	result := &Variables{byAddress: make(map[string]map[string]string), byMAC: make(map[string]map[string]string)}
+	byMAC := strings.EqualFold(header[key], "mac")
+	target := result.byAddress
+	if byMAC {
+		target = result.byMAC
+	}
+	for line, record := range records[1:] {
+		identifier := strings.TrimSpace(record[key])
+		if identifier == "" {
+			return nil, fmt.Errorf("could not parse variables: %s of line %d is empty", header[key], line+2)
+		}
+		values := make(map[string]string)
+		for index, name := range header {
+			if index != key {
+				values[name] = record[index]
+			}
+		}
+		if byMAC {
+			identifier = NormalizeMAC(identifier)
+		}
+		target[identifier] = values
+	}
+	return result, nil
+}
+
+// Addresses returns the addresses for which variables are defined.
+func (v *Variables) Addresses() []string {
+	result := make([]string, 0, len(v.byAddress))
+	for address := range v.byAddress {
+		result = append(result, address)
+	}
+	return result
+}
+
+// ForAddress returns the variables of the phone with the address exactly as written in the file, or nil.
+func (v *Variables) ForAddress(address string) map[string]string {
+	return v.byAddress[address]
+}
+
+// ForMAC returns the variables of the phone with the MAC address, or nil. The format of
+// the MAC address does not matter, e.g. "00:1A:2B:3C:4D:5E" and "001a.2b3c.4d5e" are the same.
+func (v *Variables) ForMAC(mac string) map[string]string {
+	return v.byMAC[NormalizeMAC(mac)]
+}
+
+// NormalizeMAC returns the MAC address in lower case without any separators.
+func NormalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "", " ", "").Replace(mac))
+}
@@ -0,0 +1,208 @@
+// Package journal records the state of telephones before they are changed, so that
+// the changes can be rolled back later. Every run of a changing command gets its own
+// ID; the snapshots of a run are stored as JSON files in a directory named after the ID:
+//
+//	<journal directory>/<run ID>/run.json          information about the run
+//	<journal directory>/<run ID>/http_10.20.30.40_80.json  snapshot of a single telephone
+package journal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const runFileName = "run.json"
+
+var idPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// Snapshot is the state of a single telephone before it was changed. Parameters,
+// Backup and PhoneBook are optional, depending on what the changing command touched.
+type Snapshot struct {
+	Address    string             `json:"address"`
+	Time       time.Time          `json:"time"`
+	Parameters *params.Parameters `json:"parameters,omitempty"`
+	Backup     []byte             `json:"backup,omitempty"`
+	PhoneBook  *string            `json:"phoneBook,omitempty"`
+}
+
+// RunInfo describes a run of the journal.
+type RunInfo struct {
+	ID      string    `json:"id"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+	Phones  int       `json:"-"`
+}
+
+// Journal is a directory containing the snapshots of all runs.
+type Journal struct {
+	dir string
+}
+
+// New creates a journal which stores its runs in the passed directory.
+// The directory is created as soon as the first snapshot is saved.
+func New(dir string) *Journal {
+	return &Journal{dir: dir}
+}
+
+// Run collects the snapshots of a single run of a command. Its methods may be called concurrently.
+type Run struct {
+	info    RunInfo
+	dir     string
+	mutex   sync.Mutex
+	created bool
+	saved   map[string]bool
+}
+
+// Start creates a new run for the command with a unique ID. Nothing is written
+// to the journal until the first snapshot is saved.
+func (j *Journal) Start(command string) *Run {
+	random := make([]byte, 3)
+	_, _ = rand.Read(random)
+	started := time.Now()
+	id := fmt.Sprintf("%s-%s", started.Format("20060102-150405"), hex.EncodeToString(random))
+	return &Run{
+		info:  RunInfo{ID: id, Command: command, Started: started},
+		dir:   filepath.Join(j.dir, id),
+		saved: make(map[string]bool),
+	}
+}
+
+// ID returns the ID of the run, which is needed to read its snapshots again.
+func (r *Run) ID() string {
+	return r.info.ID
+}
+
+// Len returns the number of telephones whose snapshots were saved.
+func (r *Run) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.saved)
+}
+
+// Save writes the snapshot into the journal. Only the first snapshot of a telephone is kept,
+// because it describes the state before the run changed anything.
+func (r *Run) Save(snapshot Snapshot) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.saved[snapshot.Address] {
+		return nil
+	}
+	if !r.created {
+		err := os.MkdirAll(r.dir, 0700)
+		if err != nil {
+			return fmt.Errorf("could not create journal directory: %v", err)
+		}
+		err = writeJSON(filepath.Join(r.dir, runFileName), r.info)
+		if err != nil {
+			return err
+		}
+		r.created = true
+	}
+	if snapshot.Time.IsZero() {
+		snapshot.Time = time.Now()
+	}
+	err := writeJSON(filepath.Join(r.dir, snapshotFileName(snapshot.Address)), snapshot)
+	if err != nil {
+		return err
+	}
+	r.saved[snapshot.Address] = true
+	return nil
+}
+
+func writeJSON(path string, value interface{}) error {
+	data, _ := json.MarshalIndent(value, "", "  ")
+	err := ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write journal: %v", err)
+	}
+	return nil
+}
+
+// isSnapshotFile returns true if the file of a run's directory contains the snapshot of a telephone.
+func isSnapshotFile(file os.FileInfo) bool {
+	return !file.IsDir() && file.Name() != runFileName && strings.HasSuffix(file.Name(), ".json")
+}
+
+func snapshotFileName(address string) string {
+	replacer := strings.NewReplacer("://", "_", ":", "_", "/", "_", "[", "", "]", "")
+	return replacer.Replace(address) + ".json"
+}
+
+// Snapshots reads all snapshots of the run with the passed ID, sorted by address.
+func (j *Journal) Snapshots(id string) ([]Snapshot, error) {
+	if !idPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid run ID \"%s\"", id)
+	}
+	dir := filepath.Join(j.dir, id)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("run \"%s\" not found in journal %s", id, j.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %v", err)
+	}
+	result := make([]Snapshot, 0, len(files))
+	for _, file := range files {
+		if !isSnapshotFile(file) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read journal: %v", err)
+		}
+		snapshot := Snapshot{}
+		err = json.Unmarshal(data, &snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("could not read snapshot %s: %v", file.Name(), err)
+		}
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Address < result[k].Address
+	})
+	return result, nil
+}
+
+// Runs lists all runs of the journal, the latest run first.
+func (j *Journal) Runs() ([]RunInfo, error) {
+	dirs, err := ioutil.ReadDir(j.dir)
+	if os.IsNotExist(err) {
+		return []RunInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %v", err)
+	}
+	result := make([]RunInfo, 0, len(dirs))
+	for _, dir := range dirs {
+		data, err := ioutil.ReadFile(filepath.Join(j.dir, dir.Name(), runFileName))
+		if err != nil {
+			continue
+		}
+		info := RunInfo{}
+		if json.Unmarshal(data, &info) != nil {
+			continue
+		}
+		files, _ := ioutil.ReadDir(filepath.Join(j.dir, dir.Name()))
+		for _, file := range files {
+			if isSnapshotFile(file) {
+				info.Phones++
+			}
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Started.After(result[k].Started)
+	})
+	return result, nil
+}
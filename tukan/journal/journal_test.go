@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("tukan-test%d", rand.Int()))
+	defer func() { _ = os.RemoveAll(dir) }()
+	journal := New(dir)
+
+	run := journal.Start("fnkeys-replace")
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "nothing should be written before the first snapshot")
+
+	book := "<phonebook/>"
+	require.NoError(t, run.Save(Snapshot{Address: "http://10.20.30.41:80", Parameters: &params.Parameters{TimeFormat: "24h"}}), "no error expected")
+	require.NoError(t, run.Save(Snapshot{Address: "https://[fd00::1]:443", Backup: []byte{1, 2, 3}, PhoneBook: &book}), "no error expected")
+	require.NoError(t, run.Save(Snapshot{Address: "http://10.20.30.41:80", Parameters: &params.Parameters{TimeFormat: "12h"}}), "no error expected")
+	assert.Equal(t, 2, run.Len(), "number of snapshots is wrong")
+
+	got, err := journal.Snapshots(run.ID())
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 2, len(got), "number of snapshots is wrong")
+	assert.Equal(t, "http://10.20.30.41:80", got[0].Address, "address is wrong")
+	assert.Equal(t, "24h", got[0].Parameters.TimeFormat, "only the first snapshot of a phone should be kept")
+	assert.False(t, got[0].Time.IsZero(), "time should be set")
+	assert.Equal(t, []byte{1, 2, 3}, got[1].Backup, "backup is wrong")
+	assert.Equal(t, &book, got[1].PhoneBook, "phone book is wrong")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, run.ID(), "http_10.20.30.41_80.bin"), []byte{1, 2, 3}, 0600), "no error expected")
+	runs, err := journal.Runs()
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 1, len(runs), "number of runs is wrong")
+	assert.Equal(t, run.ID(), runs[0].ID, "id is wrong")
+	assert.Equal(t, "fnkeys-replace", runs[0].Command, "command is wrong")
+	assert.Equal(t, 2, runs[0].Phones, "number of phones is wrong")
+}
+
+func TestJournal_Snapshots_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tukan-test")
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = os.RemoveAll(dir) }()
+	journal := New(dir)
+
+	_, err = journal.Snapshots("../etc")
+	assert.EqualError(t, err, "invalid run ID \"../etc\"", "error is wrong")
+	_, err = journal.Snapshots("20200101-000000-abcdef")
+	assert.EqualError(t, err, "run \"20200101-000000-abcdef\" not found in journal "+dir, "error is wrong")
+}
@@ -13,8 +13,8 @@ import (
 	"time"
 )
 
-const KeyTypeBLF = "4"
-const KeyTypeNone = "-1"
+const KeyTypeBLF = params.KeyTypeBLF
+const KeyTypeNone = params.KeyTypeNone
 
 // A mock telephone for using in test environments. It has similar properties
 // to those of the IP620/630 telephones and can be manipulated directly.
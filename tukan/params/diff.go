@@ -0,0 +1,192 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a single difference between two Parameters. The Path consists
+// of the JSON names of the fields and the indices of slice entries, e.g. "SIP[0].DisplayName".
+// Old and New are nil if the field is not set (i.e. has its zero value) in the respective Parameters.
+// If a slice entry exists only in one of the Parameters, the change contains the whole entry.
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, formatValue(c.Old), formatValue(c.New))
+}
+
+// Pointer returns the path of the change as JSON pointer (RFC 6901), e.g. "/SIP/0/DisplayName".
+func (c Change) Pointer() string {
+	replacer := strings.NewReplacer("[", "/", "]", "", ".", "/")
+	return "/" + replacer.Replace(c.Path)
+}
+
+// redactedValue replaces secrets in reported changes.
+const redactedValue = "********"
+
+// Redacted returns the change with the values of passwords replaced by "********", also within whole
+// slice entries, so that the change can be printed or logged.
+func (c Change) Redacted() Change {
+	segments := strings.Split(c.Path, ".")
+	if isSecret(strings.SplitN(segments[len(segments)-1], "[", 2)[0]) {
+		return Change{Path: c.Path, Old: redact(c.Old), New: redact(c.New)}
+	}
+	return Change{Path: c.Path, Old: redactEntry(c.Old), New: redactEntry(c.New)}
+}
+
+func isSecret(name string) bool {
+	return strings.HasSuffix(name, "Password")
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redactedValue
+}
+
+// redactEntry returns the value unchanged if it does not contain a secret, otherwise a generic
+// representation of it with the secrets replaced. Slices of entries are redacted entry by entry.
+func redactEntry(value interface{}) interface{} {
+	if value == nil {
+		return value
+	}
+	kind := reflect.ValueOf(value).Kind()
+	if kind != reflect.Struct && kind != reflect.Slice {
+		return value
+	}
+	data, _ := json.Marshal(value)
+	var generic interface{}
+	if json.Unmarshal(data, &generic) != nil {
+		return value
+	}
+	if !redactSecrets(generic) {
+		return value
+	}
+	return generic
+}
+
+// redactSecrets replaces the secrets in the decoded JSON value and returns true if there were any.
+func redactSecrets(value interface{}) bool {
+	found := false
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for name, field := range typed {
+			if isSecret(name) {
+				typed[name] = redact(field)
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, entry := range typed {
+			found = redactSecrets(entry) || found
+		}
+	}
+	return found
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "(unset)"
+	}
+	formatted, _ := json.Marshal(value)
+	return string(formatted)
+}
+
+// Diff returns the changes that are necessary to turn the current Parameters into the desired ones.
+// Slices like FunctionKeys or Sip are compared entry by entry.
+func Diff(current, desired *Parameters) []Change {
+	changes := make([]Change, 0)
+	diffStruct("", reflect.ValueOf(current).Elem(), reflect.ValueOf(desired).Elem(), &changes)
+	return changes
+}
+
+// DiffTemplate is like Diff, but the template is a partial Parameters document: Only
+// fields that are set in the template are compared, all other fields are ignored.
+func DiffTemplate(current, template *Parameters) []Change {
+	changes := make([]Change, 0)
+	for _, change := range Diff(current, template) {
+		if change.New != nil {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func diffStruct(prefix string, current, desired reflect.Value, changes *[]Change) {
+	for index := 0; index < current.NumField(); index++ {
+		field := current.Type().Field(index)
+		if field.PkgPath != "" || jsonFieldName(field) == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			diffStruct(prefix, current.Field(index), desired.Field(index), changes)
+			continue
+		}
+		path := jsonFieldName(field)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		diffValue(path, current.Field(index), desired.Field(index), changes)
+	}
+}
+
+func diffValue(path string, current, desired reflect.Value, changes *[]Change) {
+	isStructSlice := current.Kind() == reflect.Slice && current.Type().Elem().Kind() == reflect.Struct
+	if !isStructSlice || current.Len() == 0 || desired.Len() == 0 {
+		if !reflect.DeepEqual(valueOrNil(current), valueOrNil(desired)) {
+			*changes = append(*changes, Change{Path: path, Old: valueOrNil(current), New: valueOrNil(desired)})
+		}
+		return
+	}
+	common := current.Len()
+	if desired.Len() < common {
+		common = desired.Len()
+	}
+	for index := 0; index < common; index++ {
+		diffStruct(fmt.Sprintf("%s[%d]", path, index), current.Index(index), desired.Index(index), changes)
+	}
+	for index := common; index < desired.Len(); index++ {
+		*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%d]", path, index), New: desired.Index(index).Interface()})
+	}
+	// entries are removed from the end, so that the indices of a JSON patch stay valid
+	for index := current.Len() - 1; index >= common; index-- {
+		*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%d]", path, index), Old: current.Index(index).Interface()})
+	}
+}
+
+func valueOrNil(value reflect.Value) interface{} {
+	if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+		return nil
+	}
+	return value.Interface()
+}
+
+// PatchOperation is an operation of a JSON patch (RFC 6902).
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch converts the changes into a JSON patch, which turns the JSON document of the
+// current Parameters into the JSON document of the desired Parameters.
+func JSONPatch(changes []Change) []PatchOperation {
+	patch := make([]PatchOperation, 0, len(changes))
+	for _, change := range changes {
+		operation := PatchOperation{Op: "replace", Path: change.Pointer(), Value: change.New}
+		if change.Old == nil {
+			operation.Op = "add"
+		} else if change.New == nil {
+			operation.Op = "remove"
+		}
+		patch = append(patch, operation)
+	}
+	return patch
+}
@@ -0,0 +1,96 @@
+package params
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	current := &Parameters{
+		TimeFormat:     "24h",
+		Backlight:      3,
+		FunctionKeys:   FunctionKeys{{DisplayName: "John", PhoneNumber: "20"}, {DisplayName: "Mary"}},
+		Sip:            Sips{{DisplayName: "Office", Domain: "example.com"}},
+		SelectedCodecs: []int{1, 2},
+	}
+	desired := &Parameters{
+		TimeFormat:         "12h",
+		PhoneLanguage:      "de",
+		FunctionKeys:       FunctionKeys{{DisplayName: "John", PhoneNumber: "21"}},
+		Sip:                Sips{{DisplayName: "Office", Domain: "example.com"}, {DisplayName: "Home"}},
+		SelectedCodecs:     []int{1, 2},
+		CallDivertNoAnswer: []CallDivertNoAnswer{{CallDivertBusy: CallDivertBusy{Target: "30"}, Delay: "10"}},
+	}
+
+	got := Diff(current, desired)
+
+	want := []Change{
+		{Path: "Backlight", Old: 3},
+		{Path: "CallDivertNoAnswer", New: desired.CallDivertNoAnswer},
+		{Path: "PhoneLanguage", New: "de"},
+		{Path: "TimeFormat", Old: "24h", New: "12h"},
+		{Path: "FunctionKeys[0].PhoneNumber", Old: "20", New: "21"},
+		{Path: "FunctionKeys[1]", Old: FunctionKey{DisplayName: "Mary"}},
+		{Path: "SIP[1]", New: Sip{DisplayName: "Home"}},
+	}
+	assert.ElementsMatch(t, want, got, "changes are wrong")
+	assert.Empty(t, Diff(current, current), "identical parameters should not have changes")
+}
+
+func TestDiff_NestedSlices(t *testing.T) {
+	current := &Parameters{CallDivertNoAnswer: []CallDivertNoAnswer{{CallDivertBusy: CallDivertBusy{Target: "30"}, Delay: "10"}}}
+	desired := &Parameters{CallDivertNoAnswer: []CallDivertNoAnswer{{CallDivertBusy: CallDivertBusy{Target: "40"}, Delay: "10"}}}
+
+	got := Diff(current, desired)
+
+	assert.Equal(t, []Change{{Path: "CallDivertNoAnswer[0].Target", Old: "30", New: "40"}}, got, "embedded fields should be compared")
+}
+
+func TestDiffTemplate(t *testing.T) {
+	current := &Parameters{TimeFormat: "24h", PhoneLanguage: "en", Sip: Sips{{DisplayName: "Office", Domain: "example.com"}, {DisplayName: "Home"}}}
+	template := &Parameters{PhoneLanguage: "de", Sip: Sips{{Domain: "example.org"}}}
+
+	got := DiffTemplate(current, template)
+
+	want := []Change{
+		{Path: "PhoneLanguage", Old: "en", New: "de"},
+		{Path: "SIP[0].Domain", Old: "example.com", New: "example.org"},
+	}
+	assert.Equal(t, want, got, "only fields of the template should be compared")
+}
+
+func TestJSONPatch(t *testing.T) {
+	changes := []Change{
+		{Path: "TimeFormat", Old: "24h", New: "12h"},
+		{Path: "PhoneLanguage", New: "de"},
+		{Path: "SIP[2]", Old: Sip{DisplayName: "Home"}},
+		{Path: "FunctionKeys[0].DisplayName", Old: "John", New: ""},
+	}
+
+	got, err := json.Marshal(JSONPatch(changes))
+
+	require.NoError(t, err, "no error expected")
+	want := `[{"op":"replace","path":"/TimeFormat","value":"12h"},{"op":"add","path":"/PhoneLanguage","value":"de"},` +
+		`{"op":"remove","path":"/SIP/2"},{"op":"replace","path":"/FunctionKeys/0/DisplayName","value":""}]`
+	assert.JSONEq(t, want, string(got), "patch is wrong")
+}
+
+func TestChange_String(t *testing.T) {
+	assert.Equal(t, `SIP[0].DisplayName: "John" -> "Mary"`, Change{Path: "SIP[0].DisplayName", Old: "John", New: "Mary"}.String(), "string is wrong")
+	assert.Equal(t, `Backlight: (unset) -> 3`, Change{Path: "Backlight", New: 3}.String(), "string is wrong")
+}
+
+func TestChange_Redacted(t *testing.T) {
+	change := Change{Path: "SIP[0].AuthenticationPassword", Old: "secret", New: "new secret"}
+	assert.Equal(t, `SIP[0].AuthenticationPassword: "********" -> "********"`, change.Redacted().String(), "password should be redacted")
+	change = Change{Path: "SIP[1]", New: Sip{Username: "20", AuthenticationPassword: "secret"}}
+	assert.Equal(t, `SIP[1]: (unset) -> {"AuthenticationPassword":"********","Username":"20"}`, change.Redacted().String(), "password of entry should be redacted")
+	change = Change{Path: "SIP[1]", New: Sip{Username: "20"}}
+	assert.Equal(t, change, change.Redacted(), "entries without secrets should not be changed")
+	change = Change{Path: "SIP[0].DisplayName", Old: "John", New: "Mary"}
+	assert.Equal(t, change, change.Redacted(), "other settings should not be changed")
+	change = Change{Path: "SIP", New: Sips{{Username: "20", AuthenticationPassword: "secret"}}}
+	assert.Equal(t, `SIP: (unset) -> [{"AuthenticationPassword":"********","Username":"20"}]`, change.Redacted().String(), "passwords of slices should be redacted")
+}
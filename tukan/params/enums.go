@@ -0,0 +1,79 @@
+package params
+
+// Bool is a setting which is either switched on ("1") or off ("0").
+type Bool string
+
+const (
+	False Bool = "0"
+	True  Bool = "1"
+)
+
+// BoolOf returns True or False.
+func BoolOf(value bool) Bool {
+	if value {
+		return True
+	}
+	return False
+}
+
+// IsTrue returns true if the setting is switched on.
+func (b Bool) IsTrue() bool {
+	return b == True
+}
+
+// Valid returns true if the setting is either True, False or unset.
+func (b Bool) Valid() bool {
+	return b == "" || b == True || b == False
+}
+
+// TransportProtocol is the protocol used for SIP messages.
+type TransportProtocol string
+
+const (
+	TransportUDP TransportProtocol = "0"
+	TransportTCP TransportProtocol = "1"
+	TransportTLS TransportProtocol = "2"
+)
+
+// Valid returns true if the protocol is one of the constants or unset.
+func (t TransportProtocol) Valid() bool {
+	return t == "" || t == TransportUDP || t == TransportTCP || t == TransportTLS
+}
+
+// DTMFMode is the way in which a SIP account transmits DTMF tones.
+type DTMFMode string
+
+const (
+	DTMFInBand  DTMFMode = "0"
+	DTMFRFC2833 DTMFMode = "1"
+	DTMFSIPInfo DTMFMode = "2"
+)
+
+// Valid returns true if the mode is one of the constants or unset.
+func (d DTMFMode) Valid() bool {
+	return d == "" || d == DTMFInBand || d == DTMFRFC2833 || d == DTMFSIPInfo
+}
+
+// IPAddressType determines the IP versions the phone uses.
+type IPAddressType string
+
+const (
+	IPAddressTypeIPv4      IPAddressType = "0"
+	IPAddressTypeIPv6      IPAddressType = "1"
+	IPAddressTypeDualStack IPAddressType = "2"
+)
+
+// Valid returns true if the type is one of the constants or unset.
+func (i IPAddressType) Valid() bool {
+	return i == "" || i == IPAddressTypeIPv4 || i == IPAddressTypeIPv6 || i == IPAddressTypeDualStack
+}
+
+// KeyType is the function of a FunctionKey. The phones know more types than those listed here,
+// which is why KeyType has no validity check.
+type KeyType string
+
+const (
+	KeyTypeNone      KeyType = "-1"
+	KeyTypeSpeedDial KeyType = "3"
+	KeyTypeBLF       KeyType = "4"
+)
@@ -0,0 +1,45 @@
+package params
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBool(t *testing.T) {
+	assert.Equal(t, True, BoolOf(true), "true is converted wrongly")
+	assert.Equal(t, False, BoolOf(false), "false is converted wrongly")
+	assert.True(t, True.IsTrue(), "True should be true")
+	assert.False(t, False.IsTrue(), "False should not be true")
+	assert.False(t, Bool("").IsTrue(), "an unset flag should not be true")
+	assert.True(t, Bool("").Valid(), "an unset flag should be valid")
+	assert.False(t, Bool("true").Valid(), "\"true\" should not be valid")
+}
+
+func TestEnums_Valid(t *testing.T) {
+	assert.True(t, TransportTLS.Valid(), "TLS should be valid")
+	assert.False(t, TransportProtocol("3").Valid(), "unknown protocol should not be valid")
+	assert.True(t, DTMFSIPInfo.Valid(), "SIP INFO should be valid")
+	assert.False(t, DTMFMode("RFC2833").Valid(), "unknown mode should not be valid")
+	assert.True(t, IPAddressTypeDualStack.Valid(), "dual stack should be valid")
+	assert.False(t, IPAddressType("4").Valid(), "unknown address type should not be valid")
+}
+
+func TestEnums_WireFormat(t *testing.T) {
+	parameters := Parameters{
+		SIPTransportProtocol: TransportTCP,
+		IPAddressType:        IPAddressTypeIPv6,
+		LDAPEnable:           True,
+		Sip:                  Sips{{Active: True, DTMFTransmission: DTMFRFC2833}},
+		FunctionKeys:         FunctionKeys{{Type: KeyTypeBLF}},
+	}
+	data, err := json.Marshal(parameters)
+	require.NoError(t, err, "no error expected")
+	assert.JSONEq(t, `{"SIPTransportProtocol":"1","IPAddressType":"1","LDAPEnable":"1",`+
+		`"SIP":[{"Active":"1","DTMFTransmission":"1"}],"FunctionKeys":[{"Type":"4"}]}`, string(data), "wire format is wrong")
+
+	var parsed Parameters
+	require.NoError(t, json.Unmarshal(data, &parsed), "no error expected")
+	assert.Equal(t, parameters.WithoutMetadata(), parsed.WithoutMetadata(), "parsed parameters are wrong")
+}
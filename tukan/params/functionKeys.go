@@ -0,0 +1,138 @@
+package params
+
+import (
+	"reflect"
+)
+
+// ClearedKey is the function key which is uploaded to free a key.
+var ClearedKey = FunctionKey{Type: KeyTypeNone}
+
+// ChangedKeys returns the indices of the keys which differ between the current and the desired function keys.
+// Empty keys are equal to each other, no matter which fields they have.
+func ChangedKeys(current, desired FunctionKeys) []int {
+	length := len(current)
+	if len(desired) > length {
+		length = len(desired)
+	}
+	changed := make([]int, 0)
+	for index := 0; index < length; index++ {
+		old, new := keyAt(current, index), keyAt(desired, index)
+		if old.IsEmpty() && new.IsEmpty() {
+			continue
+		}
+		if !reflect.DeepEqual(old, new) {
+			changed = append(changed, index)
+		}
+	}
+	return changed
+}
+
+func keyAt(keys FunctionKeys, index int) FunctionKey {
+	if index < len(keys) {
+		return keys[index]
+	}
+	return FunctionKey{}
+}
+
+// copyKeys returns a copy of the function keys which has at least the given length.
+// Missing keys are filled with ClearedKey.
+func (f FunctionKeys) copyKeys(length int) FunctionKeys {
+	if len(f) > length {
+		length = len(f)
+	}
+	keys := make(FunctionKeys, length)
+	copy(keys, f)
+	for index := len(f); index < length; index++ {
+		keys[index] = ClearedKey
+	}
+	return keys
+}
+
+// Set returns a copy of the function keys in which the key at the index is replaced by the passed key.
+// If the index is beyond the end of the function keys, the keys in between are cleared. The second return value
+// contains the indices of the changed keys (see ChangedKeys). The index must not be negative.
+func (f FunctionKeys) Set(index int, key FunctionKey) (FunctionKeys, []int) {
+	keys := f.copyKeys(index + 1)
+	keys[index] = key
+	return keys, ChangedKeys(f, keys)
+}
+
+// Clear returns a copy of the function keys in which the keys at the indices are replaced by ClearedKey.
+// Indices beyond the end of the function keys are ignored.
+func (f FunctionKeys) Clear(indices ...int) (FunctionKeys, []int) {
+	keys := f.copyKeys(0)
+	for _, index := range indices {
+		if index >= 0 && index < len(keys) {
+			keys[index] = ClearedKey
+		}
+	}
+	return keys, ChangedKeys(f, keys)
+}
+
+// Replace returns a copy of the replacement, which is extended with cleared keys if the function keys are longer,
+// so that uploading it also frees the keys which the replacement does not have.
+func (f FunctionKeys) Replace(replacement FunctionKeys) (FunctionKeys, []int) {
+	keys := replacement.copyKeys(len(f))
+	return keys, ChangedKeys(f, keys)
+}
+
+// Insert returns a copy of the function keys in which the passed keys are inserted at the index. The keys
+// from the index on are moved back by the number of inserted keys. The index must not be negative.
+func (f FunctionKeys) Insert(index int, inserted ...FunctionKey) (FunctionKeys, []int) {
+	padded := f.copyKeys(index)
+	keys := make(FunctionKeys, 0, len(padded)+len(inserted))
+	keys = append(keys, padded[:index]...)
+	keys = append(keys, inserted...)
+	keys = append(keys, padded[index:]...)
+	return keys, ChangedKeys(f, keys)
+}
+
+// Shift returns a copy of the function keys in which all keys from the index on are moved by the offset.
+// With a positive offset, the keys are moved back and the gap is filled with cleared keys. With a negative
+// offset, the keys are moved forward and overwrite the keys in front of the index; the keys which
+// become free at the end are cleared. The index must not be negative, and index plus offset neither.
+func (f FunctionKeys) Shift(index int, offset int) (FunctionKeys, []int) {
+	if index >= len(f) {
+		keys := f.copyKeys(0)
+		return keys, ChangedKeys(f, keys)
+	}
+	start := index
+	if offset < 0 {
+		start = index + offset
+	}
+	keys := f.copyKeys(len(f) + offset)
+	for position := start; position < len(keys); position++ {
+		source := position - offset
+		if source >= index && source < len(f) {
+			keys[position] = f[source]
+		} else {
+			keys[position] = ClearedKey
+		}
+	}
+	return keys, ChangedKeys(f, keys)
+}
+
+// Limit returns a copy of the function keys which has at most count keys, which is usually the number of keys
+// of the phone. The second return value contains the keys which are cut off and are not empty, so that they are not
+// lost unnoticed. A count of zero or less does not limit the keys.
+func (f FunctionKeys) Limit(count int) (FunctionKeys, FunctionKeys) {
+	dropped := make(FunctionKeys, 0)
+	if count <= 0 || len(f) <= count {
+		return f.copyKeys(0), dropped
+	}
+	for _, key := range f[count:] {
+		if !key.IsEmpty() {
+			dropped = append(dropped, key)
+		}
+	}
+	return f[:count].copyKeys(0), dropped
+}
+
+// BLFKey returns a busy lamp field key which monitors the extension. If the name is empty,
+// the extension is displayed. The pickup code and the URL are usually the phone's BLFCallPickupCode and BLFURL.
+func BLFKey(extension, name, pickupCode, url string) FunctionKey {
+	if name == "" {
+		name = extension
+	}
+	return FunctionKey{Type: KeyTypeBLF, PhoneNumber: extension, DisplayName: name, CallPickupCode: pickupCode, Url: url}
+}
@@ -0,0 +1,97 @@
+package params
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testKeys() FunctionKeys {
+	return FunctionKeys{
+		{Type: KeyTypeSpeedDial, PhoneNumber: "20", DisplayName: "Ron"},
+		{Type: KeyTypeSpeedDial, PhoneNumber: "30", DisplayName: "Mary"},
+		{Type: KeyTypeSpeedDial, PhoneNumber: "40", DisplayName: "Linda"},
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	keys := testKeys()
+	assert.Equal(t, []int{}, ChangedKeys(keys, testKeys()), "equal keys should not be changed")
+	assert.Equal(t, []int{}, ChangedKeys(FunctionKeys{{}}, FunctionKeys{ClearedKey, ClearedKey}), "empty keys should be equal")
+	assert.Equal(t, []int{1, 3}, ChangedKeys(keys, FunctionKeys{keys[0], {}, keys[2], {PhoneNumber: "50"}}), "changed indices are wrong")
+}
+
+func TestFunctionKeys_Set(t *testing.T) {
+	keys := testKeys()
+	got, changed := keys.Set(1, FunctionKey{PhoneNumber: "31"})
+	assert.Equal(t, FunctionKeys{keys[0], {PhoneNumber: "31"}, keys[2]}, got, "keys are wrong")
+	assert.Equal(t, []int{1}, changed, "changed indices are wrong")
+	assert.Equal(t, "30", keys[1].PhoneNumber, "original keys must not be changed")
+
+	got, changed = keys.Set(4, FunctionKey{PhoneNumber: "50"})
+	assert.Equal(t, FunctionKeys{keys[0], keys[1], keys[2], ClearedKey, {PhoneNumber: "50"}}, got, "keys are wrong")
+	assert.Equal(t, []int{4}, changed, "changed indices are wrong")
+}
+
+func TestFunctionKeys_Clear(t *testing.T) {
+	keys := testKeys()
+	got, changed := keys.Clear(0, 2, 7)
+	assert.Equal(t, FunctionKeys{ClearedKey, keys[1], ClearedKey}, got, "keys are wrong")
+	assert.Equal(t, []int{0, 2}, changed, "changed indices are wrong")
+}
+
+func TestFunctionKeys_Replace(t *testing.T) {
+	keys := testKeys()
+	got, changed := keys.Replace(FunctionKeys{keys[0], {PhoneNumber: "31"}})
+	assert.Equal(t, FunctionKeys{keys[0], {PhoneNumber: "31"}, ClearedKey}, got, "keys are wrong")
+	assert.Equal(t, []int{1, 2}, changed, "changed indices are wrong")
+
+	got, changed = FunctionKeys{}.Replace(keys)
+	assert.Equal(t, keys, got, "keys are wrong")
+	assert.Equal(t, []int{0, 1, 2}, changed, "changed indices are wrong")
+}
+
+func TestFunctionKeys_Insert(t *testing.T) {
+	keys := testKeys()
+	got, changed := keys.Insert(1, FunctionKey{PhoneNumber: "25"})
+	assert.Equal(t, FunctionKeys{keys[0], {PhoneNumber: "25"}, keys[1], keys[2]}, got, "keys are wrong")
+	assert.Equal(t, []int{1, 2, 3}, changed, "changed indices are wrong")
+
+	got, changed = keys.Insert(4, FunctionKey{PhoneNumber: "50"})
+	assert.Equal(t, FunctionKeys{keys[0], keys[1], keys[2], ClearedKey, {PhoneNumber: "50"}}, got, "keys are wrong")
+	assert.Equal(t, []int{4}, changed, "changed indices are wrong")
+}
+
+func TestFunctionKeys_Shift(t *testing.T) {
+	keys := testKeys()
+	got, changed := keys.Shift(1, 2)
+	assert.Equal(t, FunctionKeys{keys[0], ClearedKey, ClearedKey, keys[1], keys[2]}, got, "keys are wrong")
+	assert.Equal(t, []int{1, 2, 3, 4}, changed, "changed indices are wrong")
+
+	got, changed = keys.Shift(2, -1)
+	assert.Equal(t, FunctionKeys{keys[0], keys[2], ClearedKey}, got, "keys are wrong")
+	assert.Equal(t, []int{1, 2}, changed, "changed indices are wrong")
+
+	got, changed = keys.Shift(3, 1)
+	assert.Equal(t, keys, got, "keys behind the end should not be shifted")
+	assert.Equal(t, []int{}, changed, "changed indices are wrong")
+}
+
+func TestFunctionKeys_Limit(t *testing.T) {
+	keys := append(testKeys(), ClearedKey, FunctionKey{})
+	got, dropped := keys.Limit(3)
+	assert.Equal(t, testKeys(), got, "keys are wrong")
+	assert.Empty(t, dropped, "empty keys should be cut off silently")
+
+	got, dropped = keys.Limit(2)
+	assert.Equal(t, testKeys()[:2], got, "keys are wrong")
+	assert.Equal(t, FunctionKeys{testKeys()[2]}, dropped, "dropped keys are wrong")
+
+	got, dropped = keys.Limit(0)
+	assert.Equal(t, keys, got, "keys should not be limited without count")
+	assert.Empty(t, dropped, "no key should be dropped")
+}
+
+func TestBLFKey(t *testing.T) {
+	assert.Equal(t, FunctionKey{Type: KeyTypeBLF, PhoneNumber: "20", DisplayName: "20", CallPickupCode: "*8", Url: "http://pbx"}, BLFKey("20", "", "*8", "http://pbx"), "key is wrong")
+	assert.Equal(t, "Linda", BLFKey("20", "Linda", "", "").DisplayName, "display name is wrong")
+}
@@ -0,0 +1,215 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlagReadOnly is the bit of Metadata.Flags which marks a setting the phone does not allow to change.
+const FlagReadOnly = 1
+
+// Metadata describes how a phone treats a single setting. The phones send it alongside the
+// value when the parameters are downloaded, e.g.
+//
+//	"Backlight": {"value": 3, "flags": 8, "validator": {"type": "range", "min": 0, "max": 5}}
+type Metadata struct {
+	Flags     int        `json:"flags,omitempty"`
+	Validator *Validator `json:"validator,omitempty"`
+}
+
+// ReadOnly returns true if the phone does not allow to change the setting.
+func (m Metadata) ReadOnly() bool {
+	return m.Flags&FlagReadOnly != 0
+}
+
+// Validator restricts the values of a setting. Every restriction is optional: Values lists
+// the allowed values, Min and Max are the inclusive bounds of numeric settings, and Regex
+// must match the whole value.
+type Validator struct {
+	Type   string
+	Values []string
+	Min    *float64
+	Max    *float64
+	Regex  string
+}
+
+// UnmarshalJSON reads a validator as sent by the phone. The phones are not consistent in
+// naming the properties, so "values", "allowed" and "options" as well as "regex" and "pattern" are accepted.
+func (v *Validator) UnmarshalJSON(data []byte) error {
+	raw := make(map[string]interface{})
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	v.Type, _ = raw["type"].(string)
+	for _, key := range []string{"values", "allowed", "options"} {
+		if values, ok := raw[key].([]interface{}); ok {
+			for _, value := range values {
+				if object, ok := value.(map[string]interface{}); ok {
+					value = object["value"]
+				}
+				v.Values = append(v.Values, scalarString(value))
+			}
+		}
+	}
+	v.Min = number(raw["min"])
+	v.Max = number(raw["max"])
+	for _, key := range []string{"regex", "pattern"} {
+		if pattern, ok := raw[key].(string); ok {
+			v.Regex = pattern
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the validator in the same format as it is read.
+func (v Validator) MarshalJSON() ([]byte, error) {
+	raw := make(map[string]interface{})
+	if v.Type != "" {
+		raw["type"] = v.Type
+	}
+	if len(v.Values) != 0 {
+		raw["values"] = v.Values
+	}
+	if v.Min != nil {
+		raw["min"] = *v.Min
+	}
+	if v.Max != nil {
+		raw["max"] = *v.Max
+	}
+	if v.Regex != "" {
+		raw["regex"] = v.Regex
+	}
+	return json.Marshal(raw)
+}
+
+func scalarString(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func number(value interface{}) *float64 {
+	switch converted := value.(type) {
+	case float64:
+		return &converted
+	case string:
+		parsed, err := strconv.ParseFloat(converted, 64)
+		if err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+// Check returns an error if the validator of the setting does not allow the value.
+// A nil value stands for an unset setting and is always allowed.
+func (m Metadata) Check(value interface{}) error {
+	if m.Validator == nil || value == nil {
+		return nil
+	}
+	formatted := scalarString(value)
+	validator := m.Validator
+	if len(validator.Values) != 0 && !contains(validator.Values, formatted) {
+		return fmt.Errorf("\"%s\" is not allowed, must be one of %s", formatted, strings.Join(validator.Values, ", "))
+	}
+	if validator.Min != nil || validator.Max != nil {
+		parsed, err := strconv.ParseFloat(formatted, 64)
+		if err != nil {
+			return fmt.Errorf("\"%s\" is not a number", formatted)
+		}
+		if validator.Min != nil && parsed < *validator.Min {
+			return fmt.Errorf("%s is less than %s", formatted, scalarString(*validator.Min))
+		}
+		if validator.Max != nil && parsed > *validator.Max {
+			return fmt.Errorf("%s is greater than %s", formatted, scalarString(*validator.Max))
+		}
+	}
+	if validator.Regex != "" {
+		pattern, err := regexp.Compile("^(?:" + validator.Regex + ")$")
+		if err == nil && !pattern.MatchString(formatted) {
+			return fmt.Errorf("\"%s\" does not match %s", formatted, validator.Regex)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckChanges returns an error listing all changes which the phone that sent the parameters
+// would reject according to the Metadata, i.e. changes of read-only settings or values not allowed
+// by their validators. Settings without metadata are not checked.
+func (p *Parameters) CheckChanges(changes []Change) error {
+	problems := make([]string, 0)
+	for _, change := range changes {
+		metadata, ok := p.Metadata[change.Path]
+		if !ok {
+			continue
+		}
+		if metadata.ReadOnly() {
+			problems = append(problems, fmt.Sprintf("%s is read-only", change.Path))
+		} else if err := metadata.Check(change.New); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", change.Path, err))
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("the phone would reject the changes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseMetadata collects the metadata of all settings sent in the object format. The keys of the
+// result are the paths of the settings, e.g. "SIP[0].DisplayName".
+func parseMetadata(data []byte) map[string]Metadata {
+	raw := make(map[string]json.RawMessage)
+	if json.Unmarshal(data, &raw) != nil {
+		return nil
+	}
+	result := make(map[string]Metadata)
+	collectMetadata(raw, "", result)
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func collectMetadata(raw map[string]json.RawMessage, prefix string, result map[string]Metadata) {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := prefix + name
+		value := raw[name]
+		object := make(map[string]json.RawMessage)
+		if json.Unmarshal(value, &object) == nil {
+			if _, ok := object["value"]; !ok {
+				continue
+			}
+			metadata := Metadata{}
+			if json.Unmarshal(value, &metadata) == nil && (metadata.Flags != 0 || metadata.Validator != nil) {
+				result[path] = metadata
+			}
+			value = object["value"]
+		}
+		entries := make([]map[string]json.RawMessage, 0)
+		if json.Unmarshal(value, &entries) == nil {
+			for index, entry := range entries {
+				collectMetadata(entry, fmt.Sprintf("%s[%d].", path, index), result)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package params
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParameters_Metadata(t *testing.T) {
+	data := `{
+  "TimeFormat": {"value": "24h", "flags": 8, "validator": {"type": "enum", "values": ["12h", "24h"]}},
+  "Backlight": {"value": 3, "flags": 8, "validator": {"type": "range", "min": "0", "max": 5}},
+  "PhoneModel": {"value": "Phone ABC", "flags": 9},
+  "PhoneName": "office",
+  "SIP": [{"DisplayName": {"value": "John", "flags": 8, "validator": {"pattern": "[A-Za-z ]+"}}}]
+}`
+	parameters := Parameters{}
+	require.NoError(t, json.Unmarshal([]byte(data), &parameters), "no error expected")
+
+	require.Equal(t, 4, len(parameters.Metadata), "number of metadata is wrong")
+	assert.Equal(t, []string{"12h", "24h"}, parameters.Metadata["TimeFormat"].Validator.Values, "allowed values are wrong")
+	assert.Equal(t, 0.0, *parameters.Metadata["Backlight"].Validator.Min, "minimum is wrong")
+	assert.Equal(t, 5.0, *parameters.Metadata["Backlight"].Validator.Max, "maximum is wrong")
+	assert.True(t, parameters.Metadata["PhoneModel"].ReadOnly(), "phone model should be read-only")
+	assert.False(t, parameters.Metadata["TimeFormat"].ReadOnly(), "time format should not be read-only")
+	assert.Equal(t, "[A-Za-z ]+", parameters.Metadata["SIP[0].DisplayName"].Validator.Regex, "regex is wrong")
+
+	assert.NoError(t, parameters.CheckChanges([]Change{
+		{Path: "TimeFormat", New: "12h"},
+		{Path: "Backlight", New: 5},
+		{Path: "SIP[0].DisplayName", New: "Mary Hope"},
+		{Path: "PhoneName", New: "reception"},
+	}), "valid changes should pass")
+	err := parameters.CheckChanges([]Change{
+		{Path: "TimeFormat", New: "am/pm"},
+		{Path: "Backlight", New: 6},
+		{Path: "PhoneModel", New: "Phone XYZ"},
+		{Path: "SIP[0].DisplayName", New: "Mary-Hope"},
+	})
+	assert.EqualError(t, err, "the phone would reject the changes: "+
+		"TimeFormat: \"am/pm\" is not allowed, must be one of 12h, 24h; "+
+		"Backlight: 6 is greater than 5; "+
+		"PhoneModel is read-only; "+
+		"SIP[0].DisplayName: \"Mary-Hope\" does not match [A-Za-z ]+", "error is wrong")
+}
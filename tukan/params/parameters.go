@@ -1,10 +1,12 @@
 package params
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -18,355 +20,483 @@ type Credentials struct {
 // slightly different format for the parameter upload/download. While unmarshalling
 // downloaded parameters, both formats can be unmarshalled.
 // For marshalling, the upload format is used.
+//
+// Settings which are not modelled by a field of Parameters are kept in Unknown exactly as they were
+// downloaded, i.e. including the metadata like flags and validators if the phone sent them. They are
+// marshalled unchanged, so that they survive a download and a later restore.
+// If the phone sent flags and validators, they are available in Metadata, keyed by the path of
+// the setting (see Change).
 type Parameters struct {
-	AcceptAllCertificates                    string `json:"AcceptAllCertificates,omitempty"`
-	AcceptInvalidCSeq                        string `json:"AcceptInvalidCSeq,omitempty"`
+	AcceptAllCertificates                    Bool   `json:"AcceptAllCertificates,omitempty"`
+	AcceptInvalidCSeq                        Bool   `json:"AcceptInvalidCSeq,omitempty"`
 	AccessCode                               string `json:"AccessCode,omitempty"`
-	AccessCodeEnabled                        string `json:"AccessCodeEnabled,omitempty"`
+	AccessCodeEnabled                        Bool   `json:"AccessCodeEnabled,omitempty"`
 	AccessCodeFor                            string `json:"AccessCodeFor,omitempty"`
 	AccessCodeInternalNumberLength           int    `json:"AccessCodeInternalNumberLength,omitempty"`
-	ActiveRingbackDisable                    string `json:"ActiveRingbackDisable,omitempty"`
+	ActiveRingbackDisable                    Bool   `json:"ActiveRingbackDisable,omitempty"`
 	AdaptiveJitterBufferInitialPrefetchValue int    `json:"AdaptiveJitterBufferInitialPrefetchValue,omitempty"`
 	AdaptiveJitterBufferMaximumDelay         int    `json:"AdaptiveJitterBufferMaximumDelay,omitempty"`
 	AdaptiveJitterBufferMinimumDelay         int    `json:"AdaptiveJitterBufferMinimumDelay,omitempty"`
 	// We don't include the admin password for security reasons and because we can't restore it properly
 	// AdminPassword                            string               `json:"AdminPassword,omitempty"`
-	AllowAccessWeb                         string               `json:"AllowAccessWeb,omitempty"`
-	AllowFragmentation                     string               `json:"AllowFragmentation,omitempty"`
-	AllowHttpOutgoing                      string               `json:"AllowHttpOutgoing,omitempty"`
-	AllowSpanning                          string               `json:"AllowSpanning,omitempty"`
-	AnonymousCallBlock                     string               `json:"AnonymousCallBlock,omitempty"`
-	AreaCodesCountry                       string               `json:"AreaCodesCountry,omitempty"`
-	AreaCodesIntCode                       string               `json:"AreaCodesIntCode,omitempty"`
-	AreaCodesIntPrefix                     string               `json:"AreaCodesIntPrefix,omitempty"`
-	AreaCodesLocalCode                     string               `json:"AreaCodesLocalCode,omitempty"`
-	AreaCodesLocalPrefix                   string               `json:"AreaCodesLocalPrefix,omitempty"`
-	AutoAdjustClockForDST                  string               `json:"AutoAdjustClockForDST,omitempty"`
-	AutoAdjustTime                         string               `json:"AutoAdjustTime,omitempty"`
-	AutoDetermineAddress                   string               `json:"AutoDetermineAddress,omitempty"`
-	AutomaticCheckForUpdates               string               `json:"AutomaticCheckForUpdates,omitempty"`
-	AutomaticFKFilling                     string               `json:"AutomaticFKFilling,omitempty"`
-	AutomaticRebootEnabled                 string               `json:"AutomaticRebootEnabled,omitempty"`
-	AutomaticRebootTime                    string               `json:"AutomaticRebootTime,omitempty"`
-	AutomaticRebootWeekdays                int                  `json:"AutomaticRebootWeekdays,omitempty"`
-	AvailableCodecs                        string               `json:"AvailableCodecs,omitempty"`
-	BLFCallPickupCode                      string               `json:"BLFCallPickupCode,omitempty"`
-	BLFURL                                 string               `json:"BLFURL,omitempty"`
-	Backlight                              int                  `json:"Backlight,omitempty"`
-	BroadsoftACDEnabled                    string               `json:"BroadSoftACDEnabled,omitempty"`
-	BroadsoftACDStatus                     string               `json:"BroadsoftACDStatus,omitempty"`
-	BroadsoftLookupIncomingEnabled         string               `json:"BroadsoftLookupIncomingEnabled,omitempty"`
-	BroadsoftLookupOutgoingEnabled         string               `json:"BroadsoftLookupOutgoingEnabled,omitempty"`
-	BroadsoftRemoteOfficeVisible           string               `json:"BroadsoftRemoteOfficeVisible,omitempty"`
-	CallDiverDisable                       string               `json:"CallDivertDisable,omitempty"`
-	CallDivertAll                          []CallDivertAll      `json:"CallDivertAll,omitempty"`
-	CallDivertBusy                         []CallDivertBusy     `json:"CallDivertBusy,omitempty"`
-	CallDivertNoAnswer                     []CallDivertNoAnswer `json:"CallDivertNoAnswer,omitempty"`
-	CallWaitingDisable                     string               `json:"CallWaitingDisable,omitempty"`
-	CallsViaCallManager                    string               `json:"CallsViaCallManager,omitempty"`
-	ClearSIPMessageWithBackKey             string               `json:"ClearSIPMessageWithBackKey,omitempty"`
-	ColourSchemeBasic                      string               `json:"ColourSchemeBasic,omitempty"`
-	ColourSchemeThress                     string               `json:"ColourSchemeThree,omitempty"`
-	ConfigurationCode                      string               `json:"ConfigurationCode,omitempty"`
-	ConfigurationWith                      string               `json:"ConfigurationWith,omitempty"`
-	ConnectionEstablished                  string               `json:"ConnectionEstablished,omitempty"`
-	ConnectionTerminated                   string               `json:"ConnectionTerminated,omitempty"`
-	ContactsDownloadPath                   string               `json:"ContactsDownloadPath,omitempty"`
-	Contrast                               string               `json:"Contrast,omitempty"`
-	CoreDumpsEnabled                       string               `json:"CoreDumpsEnabled,omitempty"`
-	DateOrder                              string               `json:"DateOrder,omitempty"`
-	DebugLEvelMaskAUTOREBOOT               string               `json:"DebugLevelMaskAutoREBOOT,omitempty"`
-	DebugLevelMaskAUDIO                    string               `json:"DebugLevelMaskAUDIO,omitempty"`
-	DebugLevelMaskDisplayGUI               string               `json:"DebugLevelMaskDisplayGUI,omitempty"`
-	DebugLevelMaskEXTENSIONBOARD           string               `json:"DebugLevelMaskEXTENSIONBOARD,omitempty"`
-	DebugLevelMaskMTIMERS                  string               `json:"DebugLevelMaskMTIMERS,omitempty"`
-	DebugLevelMaskNETWORK                  string               `json:"DebugLevelMaskNETWORK,omitempty"`
-	DebugLevelMaskPCM                      string               `json:"DebugLevelMaskPCM,omitempty"`
-	DebugLevelMaskSIP                      string               `json:"DebugLevelMaskSIP,omitempty"`
-	DebugLevelMaskSYSCONF                  string               `json:"DebugLevelMaskSYSCONF,omitempty"`
-	DebugLevelMaskWATCHDOG                 string               `json:"DebugLevelMaskWATCHDOG,omitempty"`
-	DefaultAccount                         string               `json:"DefaultAccount,omitempty"`
-	DefaultRingtone                        string               `json:"DefaultRingtone,omitempty"`
-	DefaultURLForAdmin                     string               `json:"DefaultURLForAdmin,omitempty"`
-	DefaultURLForUser                      string               `json:"DefaultURLForUser,omitempty"`
-	DeriveTargetAddress                    string               `json:"DeriveTargetAddress,omitempty"`
-	DeviceNameInNetwork                    string               `json:"DeviceNameInNetwork,omitempty"`
-	DialingPlans                           []DialingPlan        `json:"DialingPlans,omitempty"`
-	DisableWebUI                           string               `json:"DisableWebUI,omitempty"`
-	DisplayDiversionInfo                   string               `json:"DisplayDiversionInfo,omitempty"`
-	DistinctiveRingingEnabled              string               `json:"DistinctiveRingingEnabled,omitempty"`
-	DnDListActive                          string               `json:"DnDListActive,omitempty"`
-	Dnd                                    []Dnd                `json:"DnD,omitempty"`
-	DoorStations                           []DoorStation        `json:"DoorStations,omitempty"`
-	EnableAec                              string               `json:"EnableAec,omitempty"`
-	EnablePortMirroring                    string               `json:"EnablePortMirroring,omitempty"`
-	FirmwareDataServer                     string               `json:"FirmwareDataServer,omitempty"`
-	FirmwareDownloadPath                   string               `json:"FirmwareDownloadPath,omitempty"`
-	FlexibleSeatingEnabled                 string               `json:"FlexibleSeatingEnabled,omitempty"`
-	FunctionKeys                           FunctionKeys         `json:"FunctionKeys,omitempty"`
-	FunctionKeysIcons                      string               `json:"FunctionKeysIcons,omitempty"`
-	HTTPAuthPassword                       string               `json:"HTTPAuthPassword,omitempty"`
-	HTTPConnectionType                     string               `json:"HTTPConnectionType,omitempty"`
-	HTTPPort                               int                  `json:"HTTPPort,omitempty"`
-	HTTPSPort                              int                  `json:"HTTPSPort,omitempty"`
-	HandSetMode                            string               `json:"HandsetMode,omitempty"`
-	HandsFreeMode                          string               `json:"HandsFreeMode,omitempty"`
-	HeadsetMode                            string               `json:"HeadsetMode,omitempty"`
-	HoldOnTransferAttended                 string               `json:"HoldOnTransferAttended,omitempty"`
-	HoldOnTransferUnattended               string               `json:"HoldOnTransferUnattended,omitempty"`
-	HttpAuthUsername                       string               `json:"HTTPAuthUsername,omitempty"`
-	IPAddressType                          string               `json:"IPAddressType,omitempty"`
-	IPv4Address                            string               `json:"IPv4Address,omitempty"`
-	IPv4AlternateDNSServer                 string               `json:"IPv4AlternateDNSServer,omitempty"`
-	IPv4PreferredDNSServer                 string               `json:"IPv4PreferredDNSServer,omitempty"`
-	IPv4StandardGateway                    string               `json:"IPv4StandardGateway,omitempty"`
-	IPv4SubnetMask                         string               `json:"IPv4SubnetMask,omitempty"`
-	IncCallsWithoutCallManager             string               `json:"IncCallsWithoutCallManager,omitempty"`
-	IncomingCall                           string               `json:"IncomingCall,omitempty"`
-	LANPort                                string               `json:"LANPort,omitempty"`
-	LDAPAdditionalAttribute                string               `json:"LDAPAdditionalAttribute,omitempty"`
-	LDAPAdditionalAttributeDialable        string               `json:"LDAPAdditionalAttributeDialable,omitempty"`
-	LDAPBaseDN                             string               `json:"LDAPBaseDN,omitempty"`
-	LDAPCity                               string               `json:"LDAPCity,omitempty"`
-	LDAPCompany                            string               `json:"LDAPCompany,omitempty"`
-	LDAPCountry                            string               `json:"LDAPCountry,omitempty"`
-	LDAPDirectoryName                      string               `json:"LDAPDirectoryName,omitempty"`
-	LDAPDisplayFormat                      string               `json:"LDAPDisplayFormat,omitempty"`
-	LDAPEmail                              string               `json:"LDAPEmail,omitempty"`
-	LDAPEnable                             string               `json:"LDAPEnable,omitempty"`
-	LDAPFax                                string               `json:"LDAPFax,omitempty"`
-	LDAPFirstName                          string               `json:"LDAPFirstName,omitempty"`
-	LDAPLookup                             string               `json:"LDAPLookup,omitempty"`
-	LDAPMaxNumberOfSearchResults           int                  `json:"LDAPMaxNumberOfSearchResults,omitempty"`
-	LDAPNameFilter                         string               `json:"LDAPNameFilter,omitempty"`
-	LDAPNumberFilter                       string               `json:"LDAPNumberFilter,omitempty"`
-	LDAPPAssword                           string               `json:"LDAPPassword,omitempty"`
-	LDAPPhoneHome                          string               `json:"LDAPPhoneHome,omitempty"`
-	LDAPPhoneMobile                        string               `json:"LDAPPhoneMobile,omitempty"`
-	LDAPPhoneOffice                        string               `json:"LDAPPhoneOffice,omitempty"`
-	LDAPResponseTimeout                    int                  `json:"LDAPResponseTimeout,omitempty"`
-	LDAPSecurity                           string               `json:"LDAPSecurity,omitempty"`
-	LDAPServerAddress                      string               `json:"LDAPServerAddress,omitempty"`
-	LDAPServerPort                         int                  `json:"LDAPServerPort,omitempty"`
-	LDAPStreet                             string               `json:"LDAPStreet,omitempty"`
-	LDAPSurname                            string               `json:"LDAPSurname,omitempty"`
-	LDAPUsername                           string               `json:"LDAPUsername,omitempty"`
-	LDAPZIP                                string               `json:"LDAPZIP,omitempty"`
-	LLDAPActive                            string               `json:"LLDAPActive,omitempty"`
-	LLDAPPacketInterval                    string               `json:"LLDAPPacketInterval,omitempty"`
-	LinkSpeedDuplexLanPort                 string               `json:"LinkSpeedDuplexLanPort,omitempty"`
-	LinkedSpeedDuplexPcPort                string               `json:"LinkedSpeedDuplexPcPort,omitempty"`
-	LogoutTimer                            int                  `json:"LogoutTimer,omitempty"`
-	LookupIncoming                         string               `json:"LookupIncomming,omitempty"` // sic!
-	LookupOutgoing                         string               `json:"LookupOutgoing,omitempty"`
-	MACAddress                             string               `json:"MACAddress,omitempty"`
-	MainMenuContent                        string               `json:"MainMenuContent,omitempty"`
-	MenuAdaptiveJitterBuffer               string               `json:"MenuAdaptiveJitterBuffer,omitempty"`
-	MenuAdjustment                         string               `json:"MenuAdjustment,omitempty"`
-	MenuAudo                               string               `json:"MenuAudio,omitempty"`
-	MenuCLI                                string               `json:"MenuCLI,omitempty"`
-	MenuCallDivert                         string               `json:"MenuCallDivert,omitempty"`
-	MenuCallHistory                        string               `json:"MenuCallHistory,omitempty"`
-	MenuCallstrings                        string               `json:"MenuCallstrings,omitempty"`
-	MenuConnections                        string               `json:"MenuConnections,omitempty"`
-	MenuCoreDumps                          string               `json:"MenuCoreDumps,omitempty"`
-	MenuCorporate                          string               `json:"MenuCorporate,omitempty"`
-	MenuDateAndTime                        string               `json:"MenuDateAndTime,omitempty"`
-	MenuDebugging                          string               `json:"MenuDebugging,omitempty"`
-	MenuDeskPhone                          string               `json:"MenuDeskPhone,omitempty"`
-	MenuDevice                             string               `json:"MenuDevice,omitempty"`
-	MenuDiallingPlans                      string               `json:"MenuDiallingPlans,omitempty"`
-	MenuDisplay                            string               `json:"MenuDisplay,omitempty"`
-	MenuDoNotDisturb                       string               `json:"MenuDoNotDisturb,omitempty"`
-	MenuDoorStation                        string               `json:"MenuDoorStation,omitempty"`
-	MenuEvents                             string               `json:"MenuEvents,omitempty"`
-	MenuExpert                             string               `json:"menuExpert,omitempty"`
-	MenuExpertAudio                        string               `json:"MenuExpertAudio,omitempty"`
-	MenuExpertNetwork                      string               `json:"MenuExportNetwork,omitempty"`
-	MenuExtensionModule1                   string               `json:"MenuExtensionModule1,omitempty"`
-	MenuExtensionModule2                   string               `json:"MenuExtensionModule2,omitempty"`
-	MenuExtensionModule3                   string               `json:"MenuExtensionModule3,omitempty"`
-	MenuFirmwareUpdate                     string               `json:"MenuFirmwareUpdate,omitempty"`
-	MenuIP                                 string               `json:"MenuIP,omitempty"`
-	MenuKeysAndLEDs                        string               `json:"MenuKeysAndLEDs,omitempty"`
-	MenuLan                                string               `json:"MenuLAN`
-	MenuLocalPhonebook                     string               `json:"MenuLocalPhonebook,omitempty"`
-	MenuMainMenu                           string               `json:"MenuMainMenu,omitempty"`
-	MenuMessageNotification                string               `json:"MenuMessageNotification,omitempty"`
-	MenuNetwork                            string               `json:"MenuNetwork,omitempty"`
-	MenuOnlineDirectories                  string               `json:"MenuOnlineDirectories,omitempty"`
-	MenuOnlineServices                     string               `json:"MenuOnlineServices',omitempty"`
-	MenuPCAPLogging                        string               `json:"MenuPCAPLogging,omitempty"`
-	MenuPhoneSystem                        string               `json:"MenuPhoneSystem,omitempty"`
-	MenuPhoneWebServer                     string               `json:"MenuPhoneWebServer,omitempty"`
-	MenuPictures                           string               `json:"MenuPictures,omitempty"`
-	MenuProvisioningConfiguration          string               `json:"MenuProvisioningConfiguration,omitempty"`
-	MenuPublic                             string               `json:"MenuPublic,omitempty"`
-	MenuReboot                             string               `json:"MenuReboot,omitempty"`
-	MenuRebootAndReset                     string               `json:"MenuRebootAndReset,omitempty"`
-	MenuRingtones                          string               `json:"MenuRingtones,omitempty"`
-	MenuSIPProtocol                        string               `json:"MenuSIPPRotocol,omitempty"`
-	MenuSaveAndRestore                     string               `json:"MenuSaveAndRestore,omitempty"`
-	MenuSecurity                           string               `json:"MenuSecurity,omitempty"`
-	Menustrings                            string               `json:"Menustrings,omitempty"`
-	MenuStatus                             string               `json:"MenuStatus,omitempty"`
-	MenuStatusConnections                  string               `json:"MenuStatusConnections,omitempty"`
-	MenuStorageAllocation                  string               `json:"MenuStorageAllocation,omitempty"`
-	MenuSwitchstrings                      string               `json:"MenuSwitchstrings,omitempty"`
-	MenuSystem                             string               `json:"MenuSystem,omitempty"`
-	MenuSystemLogging                      string               `json:"MenuSystemLogging,omitempty"`
-	MenuTelephony                          string               `json:"MenuTelephony,omitempty"`
-	MenuVoIP                               string               `json:"MenuVoIP,omitempty"`
-	MenuVoiceMail                          string               `json:"MenuVoiceMail,omitempty"`
-	MenuWebConfigurator                    string               `json:"MenuWebConfigurator,omitempty"`
-	MenuWebcam                             string               `json:"MenuWebcam,omitempty"`
-	MenuXML                                string               `json:"MenuXML,omitempty"`
-	MissedCallsNotificationActive          string               `json:"MissedCallsNotificationActive,omitempty"`
-	NetworkType                            string               `json:"NetworkType,omitempty"`
-	OffHook                                string               `json:"OffHook,omitempty"`
-	OnHook                                 string               `json:"OnHook,omitempty"`
-	OneMelodyRingtoneDoorStation           string               `json:"OneMelodyRingtoneDoorStation,omitempty"`
-	OneMelodyRingtoneExternal              string               `json:"OneMelodyRingtoneExternal,omitempty"`
-	OneMelodyRingtoneGroup                 string               `json:"OneMelodyRingtoneGroup,omitempty"`
-	OneMelodyRingtoneInterval              string               `json:"OneMelodyRingtoneInterval,omitempty"`
-	OneMelodyRingtoneOptional              string               `json:"OneMelodyRingtoneOptional,omitempty"`
-	OutCallsViaFunctionKey                 string               `json:"OutCallsViaFunctionKey,omitempty"`
-	OutgoingCall                           string               `json:"OutgoingCall,omitempty"`
-	PCPort                                 int                  `json:"PCPort,omitempty"`
-	PIN                                    string               `json:"PIN,omitempty"`
-	PacketTimeForRtp                       string               `json:"PacketTimeForRTP,omitempty"`
-	PhoneLanguage                          string               `json:"PhoneLanguage,omitempty"`
-	PhoneModel                             string               `json:"PhoneModel,omitempty"`
-	PhoneName                              string               `json:"PhoneName,omitempty"`
-	PhoneSystem                            string               `json:"PhoneSystem,omitempty"`
-	ProgrammableKeys                       string               `json:"ProgrammableKeysDNDActionURLDisable,omitempty"`
-	ProgrammableKeysConferenceActionURL    string               `json:"ProgrammableKeysConferenceActionURL,omitempty"`
-	ProgrammableKeysConferenceDTMFCode     string               `json:"ProgrammableKeysDTMFCode,omitempty"`
-	ProgrammableKeysConferenceFAC          string               `json:"ProgrammableKeysConferenceFAC,omitempty"`
-	ProgrammableKeysConferenceType         string               `json:"ProgrammableKeysConferenceType,omitempty"`
-	ProgrammableKeysDNDActionURLEnable     string               `json:"ProgrammableKeysDNDActionURLEnable,omitempty"`
-	ProgrammableKeysDNDFACDisable          string               `json:"ProgrammableKeysDNDFACDisable,omitempty"`
-	ProgrammableKeysDNDType                string               `json:"ProgrammableKeysDNDType,omitempty"`
-	ProgrammableKeysDirectoryType          string               `json:"ProgrammableKeysDirectoryType,omitempty"`
-	ProgrammableKeysHoldActionURL          string               `json:"ProgrammableKeysHoldActionURL,omitempty"`
-	ProgrammableKeysHoldDTMFCode           string               `json:"ProgrammableKeysHoldDTMFCode,omitempty"`
-	ProgrammableKeysHoldType               string               `json:"ProgrammableKeysHoldType,omitempty"`
-	ProgrammableKeysMessagesActionURL      string               `json:"ProgrammableKeysMessagesActionURL,omitempty"`
-	ProgrammableKeysMessagesFAC            string               `json:"ProgrammableKeysMessagesFAC,omitempty"`
-	ProgrammableKeysMessagesType           string               `json:"ProgrammableKeysMessagesType,omitempty"`
-	ProvisioningDirectLink                 string               `json:"ProvisioningDirectLink,omitempty"`
-	ProvisioningServer                     string               `json:"ProvisioningServer,omitempty"`
-	ProxyServerActive                      string               `json:"ProxyServerActive,omitempty"`
-	ProxyServerAddress                     string               `json:"ProxyServerAddress,omitempty"`
-	ProxyServerPort                        int                  `json:"ProxyServerPort,omitempty"`
-	QuickDialKeys                          []QuickDialKey       `json:"QuickDialKeys,omitempty"`
-	RTPQoSDSCP                             int                  `json:"RTPoSDSCP,omitempty"`
-	RegistrationFailed                     string               `json:"RegistrationFailed,omitempty"`
-	RegistrationSucceeded                  string               `json:"RegistrationSucceeded,omitempty"`
-	RemoteControlAllow                     string               `json:"RemoteControlAllow,omitempty"`
-	RemoteControlSource                    string               `json:"RemoteControlSource,omitempty"`
-	SIPAccountFailover                     string               `json:"SIPAccountFailover,omitempty"`
-	SIPG729AnnexB                          string               `json:"SIPG729AnnexB,omitempty"`
-	SIPNoSrtpCalls                         string               `json:"SipNoSrtpCalls,omitempty"`
-	SIPPort                                int                  `json:"SIPPort,omitempty"`
-	SIPPrack                               string               `json:"SIPPRack,omitempty"`
-	SIPQoSDSCP                             int                  `json:"SIPQoSDSCP,omitempty"`
-	SIPRtpPort                             int                  `json:"SIPRtpPort,omitempty"`
-	SIPRtpRTCPXRServerAddress              string               `json:"SIPRtpRTCPXRServerAddress,omitempty"`
-	SIPRtpRTCPXRServerPort                 int                  `json:"SIPRtpRTCXRServerPort,omitempty"`
-	SIPRtpRandomPort                       string               `json:"SipRtpRandomPort,omitempty"`
-	SIPRtpSymmetricPort                    string               `json:"SIPRtpSymetricPort,omitempty"` // sic!
-	SIPRtpUseRTCPXR                        string               `json:"SIPRtpUseRTCPXR,omitempty"`
-	SIPRtprRtcp                            string               `json:"SIPRtprRtcp,omitempty"`
-	SIPSCertificate                        string               `json:"SIPSCertificate,omitempty"`
-	SIPSKeyPassword                        string               `json:"SIPSKeyPassword,omitempty"`
-	SIPSPrivateKey                         string               `json:"SIPSPrivateKey,omitempty"`
-	SIPSecurity                            string               `json:"SIPSecurity,omitempty"`
-	SIPSecurityEnabled                     string               `json:"SIPSecurityEnabled,omitempty"`
-	SIPSessionTimer                        int                  `json:"SIPSessionTimer,omitempty"`
-	SIPTimerT1                             int                  `json:"SIPTimerT1,omitempty"`
-	SIPTimersFailedRegistration            int                  `json:"SIPTimersFailedRegistration,omitempty"`
-	SIPTimersFailedSubscription            int                  `json:"SIPTimersFailSubscription,omitempty"`
-	SIPTimersSubscription                  int                  `json:"SIPTimersSubscription,omitempty"`
-	SIPTimersSubscriptionBLFFollowRegister string               `json:"SIPTimersSubscriptionBLFFollowRegister,omitempty"`
-	SIPTransportProtocol                   string               `json:"SIPTransportProtocol,omitempty"`
-	SIPSrtp                                string               `json:"SIPSrtp,omitempty"`
-	ScreenSaverTimeout                     string               `json:"ScreenSaverTimeout,omitempty"`
-	Screensaver                            string               `json:"Screensaver,omitempty"`
-	ScreensaverBacklight                   int                  `json:"ScreensaverBacklight,omitempty"`
-	ScreensaverHTTPSource                  string               `json:"ScreensaverHTTPSource,omitempty"`
-	ScreensaverPictures                    string               `json:"ScreensaverPictures,omitempty"`
-	SelectedCodecs                         []int                `json:"SelectedCodecs,omitempty"`
-	SelectedServesDisable                  string               `json:"SelectedServicesDisable,omitempty"`
-	SemiAttendedTransferType               string               `json:"SemiAttendedTransferType,omitempty"`
-	StringsVersion                         string               `json:"StringsVersion,omitempty"`
-	ShowPIN                                string               `json:"ShowPIN,omitempty"`
-	ShowPassword                           string               `json:"ShowPassword,omitempty"`
-	ShowSIPMessagesOnDisplay               string               `json:"ShowSIPMessagesOnDisplay,omitempty"`
-	Sip                                    Sips                 `json:"SIP,omitempty"`
-	SoftReboots                            int                  `json:"SoftReboots,omitempty"`
-	SoftwareVariant                        string               `json:"SoftwareVariant,omitempty"`
-	SoftwareVersion                        string               `json:"SoftwareVersion,omitempty"`
-	StandbyBacklight                       int                  `json:"StandbyBacklight,omitempty"`
-	Startups                               int                  `json:"Startups,omitempty"`
-	SyslogEnabled                          string               `json:"SyslogEnabled,omitempty"`
-	SyslogServer                           string               `json:"SyslogServer,omitempty"`
-	SystemLocalPhonebookUpdateTime         string               `json:"SystemLocalPhonebookUpdateTime,omitempty"`
-	SystemLocalPhonebookUrl                string               `json:"SystemLocalPhonebookUrl,omitempty"`
-	TimeFormat                             string               `json:"TimeFormat,omitempty"`
-	TimeServer                             string               `json:"TimeServer,omitempty"`
-	TimeServerDHCP                         string               `json:"TimeServerDHCP,omitempty"`
-	TimeServerProvisioning                 string               `json:"TimeServerProvisioning,omitempty"`
-	TimeZone                               string               `json:"TimeZone,omitempty"`
-	Timestamp                              int                  `json:"Timestamp,omitempty"`
-	ToneScheme                             string               `json:"TomeScheme,omitempty"`
-	UserPassword                           string               `json:"UserPassword,omitempty"`
-	VLANIdentifierLAN                      int                  `json:"VLANIdentifierLAN,omitempty"`
-	VLANIdentifierPC                       int                  `json:"VLANIdentifierPC,omitempty"`
-	VLANLocked                             string               `json:"VLANLocked,omitempty"`
-	VLANPriorityLAN                        string               `json:"VLANPriorityLAN,omitempty"`
-	VLANPriorityPC                         string               `json:"VLANPriorityDC,omitempty"`
-	VLANTagging                            string               `json:"VLANTagging,omitempty"`
-	Variant                                string               `json:"Variant,omitempty"`
-	VoiceQuality                           string               `json:"VoiceQuality,omitempty"`
-	VoicemailMessagesActive                string               `json:"VoicemailMessagesActive,omitempty"`
-	WebUICallDivertDisable                 string               `json:"WebUICallDivertDisable,omitempty"`
-	WebUICallWaitingDisable                string               `json:"WebUICallWaitingDisable,omitempty"`
-	WebUILanguage                          string               `json:"WebUILanguage,omitempty"`
-	WitholdNumberDisable                   string               `json:"WitholdNumberDisable,omitempty"`
-	WorkingCounter                         int                  `json:"WorkingCounter,omitempty"`
-	WorkingCounterSec                      int                  `json:"WorkingCounterSec,omitempty"`
-	XMLProviderName                        string               `json:"XMLProviderName,omitempty"`
-	XSIAuthName                            string               `json:"XSIAuthName,omitempty"`
-	XSIAuthPassword                        string               `json:"XSIAuthPassword,omitempty"`
-	XSICallLogType                         string               `json:"XSICallLogType,omitempty"`
-	XSIEnterpriseCommonDirectoryEnabled    string               `json:"XSIEnterpriseCommonDirectoryEnabled,omitempty"`
-	XSIEnterpriseCommonDirectoryName       string               `json:"XSIEnterpriseCommonDirectoryName,omitempty"`
-	XSIEnterpriseDirectoryEnabled          string               `json:"XSIEnterpriseDirectoryEnabled,omitempty"`
-	XSIEnterpriseDirectoryName             string               `json:"XSIEnterpriseDirectoryName,omitempty"`
-	XSIGroupCommonDirectoryEnabled         string               `json:"XSIGroupCommonDirectoryEnabled,omitempty"`
-	XSIGroupCommonDirectoryName            string               `json:"XSIGroupCommonDirectoryName,omitempty"`
-	XSIGroupDirectoryEnabled               string               `json:"XSIGroupDirectoryEnabled,omitempty"`
-	XSIGroupDirectoryName                  string               `json:"XSIGroupDirectoryName,omitempty"`
-	XSIPersonalDirectoryEnabled            string               `json:"XSIPersonalDirectoryEnabled,omitempty"`
-	XSIPersonalDirectoryName               string               `json:"XSIPersonalDirectoryName,omitempty"`
-	XSISIPAuthentication                   string               `json:"XSISIPAuthentication,omitempty"`
-	XSISearchAnywhereInNameEnabled         string               `json:"XSISearchAnywhereInNameEnabled,omitempty"`
-	XSIServer                              string               `json:"XSIServer,omitempty"`
-	XmlEnablePrivateDirectory              string               `json:"XmlEnablePrivateDirectory,omitempty"`
-	XmlEnableWhiteDirectory                string               `json:"XmlEnableWhiteDirectory,omitempty"`
-	XmlEnableYellowDirectory               string               `json:"XmlEnableYellowDirectory,omitempty"`
-	XmlNumberFilter                        string               `json:"XmlNumberFilter,omitempty"`
-	XmlPassword                            string               `json:"XMLPassword,omitempty"`
-	XmlPrivateDirectoryName                string               `json:"XMLPrivateDirectoryName,omitempty"`
-	XmlServerAddress                       string               `json:"XmlServerAddress,omitempty"`
-	XmlUsername                            string               `json:"XmlUsername,omitempty"`
-	XmlWhiteDirectoryName                  string               `json:"XmlWhiteDirectoryName,omitempty"`
-	XmlYellowDirectoryName                 string               `json:"XmlYellowDirectoryName,omitempty"`
+	AllowAccessWeb                         Bool                       `json:"AllowAccessWeb,omitempty"`
+	AllowFragmentation                     Bool                       `json:"AllowFragmentation,omitempty"`
+	AllowHttpOutgoing                      string                     `json:"AllowHttpOutgoing,omitempty"`
+	AllowSpanning                          Bool                       `json:"AllowSpanning,omitempty"`
+	AnonymousCallBlock                     Bool                       `json:"AnonymousCallBlock,omitempty"`
+	AreaCodesCountry                       string                     `json:"AreaCodesCountry,omitempty"`
+	AreaCodesIntCode                       string                     `json:"AreaCodesIntCode,omitempty"`
+	AreaCodesIntPrefix                     string                     `json:"AreaCodesIntPrefix,omitempty"`
+	AreaCodesLocalCode                     string                     `json:"AreaCodesLocalCode,omitempty"`
+	AreaCodesLocalPrefix                   string                     `json:"AreaCodesLocalPrefix,omitempty"`
+	AutoAdjustClockForDST                  string                     `json:"AutoAdjustClockForDST,omitempty"`
+	AutoAdjustTime                         string                     `json:"AutoAdjustTime,omitempty"`
+	AutoDetermineAddress                   string                     `json:"AutoDetermineAddress,omitempty"`
+	AutomaticCheckForUpdates               Bool                       `json:"AutomaticCheckForUpdates,omitempty"`
+	AutomaticFKFilling                     Bool                       `json:"AutomaticFKFilling,omitempty"`
+	AutomaticRebootEnabled                 Bool                       `json:"AutomaticRebootEnabled,omitempty"`
+	AutomaticRebootTime                    string                     `json:"AutomaticRebootTime,omitempty"`
+	AutomaticRebootWeekdays                int                        `json:"AutomaticRebootWeekdays,omitempty"`
+	AvailableCodecs                        string                     `json:"AvailableCodecs,omitempty"`
+	BLFCallPickupCode                      string                     `json:"BLFCallPickupCode,omitempty"`
+	BLFURL                                 string                     `json:"BLFURL,omitempty"`
+	Backlight                              int                        `json:"Backlight,omitempty"`
+	BroadsoftACDEnabled                    Bool                       `json:"BroadSoftACDEnabled,omitempty"`
+	BroadsoftACDStatus                     string                     `json:"BroadsoftACDStatus,omitempty"`
+	BroadsoftLookupIncomingEnabled         Bool                       `json:"BroadsoftLookupIncomingEnabled,omitempty"`
+	BroadsoftLookupOutgoingEnabled         Bool                       `json:"BroadsoftLookupOutgoingEnabled,omitempty"`
+	BroadsoftRemoteOfficeVisible           string                     `json:"BroadsoftRemoteOfficeVisible,omitempty"`
+	CallDiverDisable                       Bool                       `json:"CallDivertDisable,omitempty"`
+	CallDivertAll                          []CallDivertAll            `json:"CallDivertAll,omitempty"`
+	CallDivertBusy                         []CallDivertBusy           `json:"CallDivertBusy,omitempty"`
+	CallDivertNoAnswer                     []CallDivertNoAnswer       `json:"CallDivertNoAnswer,omitempty"`
+	CallWaitingDisable                     Bool                       `json:"CallWaitingDisable,omitempty"`
+	CallsViaCallManager                    string                     `json:"CallsViaCallManager,omitempty"`
+	ClearSIPMessageWithBackKey             Bool                       `json:"ClearSIPMessageWithBackKey,omitempty"`
+	ColourSchemeBasic                      string                     `json:"ColourSchemeBasic,omitempty"`
+	ColourSchemeThress                     string                     `json:"ColourSchemeThree,omitempty"`
+	ConfigurationCode                      string                     `json:"ConfigurationCode,omitempty"`
+	ConfigurationWith                      string                     `json:"ConfigurationWith,omitempty"`
+	ConnectionEstablished                  string                     `json:"ConnectionEstablished,omitempty"`
+	ConnectionTerminated                   string                     `json:"ConnectionTerminated,omitempty"`
+	ContactsDownloadPath                   string                     `json:"ContactsDownloadPath,omitempty"`
+	Contrast                               string                     `json:"Contrast,omitempty"`
+	CoreDumpsEnabled                       Bool                       `json:"CoreDumpsEnabled,omitempty"`
+	DateOrder                              string                     `json:"DateOrder,omitempty"`
+	DebugLEvelMaskAUTOREBOOT               string                     `json:"DebugLevelMaskAutoREBOOT,omitempty"`
+	DebugLevelMaskAUDIO                    string                     `json:"DebugLevelMaskAUDIO,omitempty"`
+	DebugLevelMaskDisplayGUI               string                     `json:"DebugLevelMaskDisplayGUI,omitempty"`
+	DebugLevelMaskEXTENSIONBOARD           string                     `json:"DebugLevelMaskEXTENSIONBOARD,omitempty"`
+	DebugLevelMaskMTIMERS                  string                     `json:"DebugLevelMaskMTIMERS,omitempty"`
+	DebugLevelMaskNETWORK                  string                     `json:"DebugLevelMaskNETWORK,omitempty"`
+	DebugLevelMaskPCM                      string                     `json:"DebugLevelMaskPCM,omitempty"`
+	DebugLevelMaskSIP                      string                     `json:"DebugLevelMaskSIP,omitempty"`
+	DebugLevelMaskSYSCONF                  string                     `json:"DebugLevelMaskSYSCONF,omitempty"`
+	DebugLevelMaskWATCHDOG                 string                     `json:"DebugLevelMaskWATCHDOG,omitempty"`
+	DefaultAccount                         string                     `json:"DefaultAccount,omitempty"`
+	DefaultRingtone                        string                     `json:"DefaultRingtone,omitempty"`
+	DefaultURLForAdmin                     string                     `json:"DefaultURLForAdmin,omitempty"`
+	DefaultURLForUser                      string                     `json:"DefaultURLForUser,omitempty"`
+	DeriveTargetAddress                    string                     `json:"DeriveTargetAddress,omitempty"`
+	DeviceNameInNetwork                    string                     `json:"DeviceNameInNetwork,omitempty"`
+	DialingPlans                           []DialingPlan              `json:"DialingPlans,omitempty"`
+	DisableWebUI                           Bool                       `json:"DisableWebUI,omitempty"`
+	DisplayDiversionInfo                   Bool                       `json:"DisplayDiversionInfo,omitempty"`
+	DistinctiveRingingEnabled              Bool                       `json:"DistinctiveRingingEnabled,omitempty"`
+	DnDListActive                          Bool                       `json:"DnDListActive,omitempty"`
+	Dnd                                    []Dnd                      `json:"DnD,omitempty"`
+	DoorStations                           []DoorStation              `json:"DoorStations,omitempty"`
+	EnableAec                              string                     `json:"EnableAec,omitempty"`
+	EnablePortMirroring                    Bool                       `json:"EnablePortMirroring,omitempty"`
+	FirmwareDataServer                     string                     `json:"FirmwareDataServer,omitempty"`
+	FirmwareDownloadPath                   string                     `json:"FirmwareDownloadPath,omitempty"`
+	FlexibleSeatingEnabled                 Bool                       `json:"FlexibleSeatingEnabled,omitempty"`
+	FunctionKeys                           FunctionKeys               `json:"FunctionKeys,omitempty"`
+	FunctionKeysIcons                      string                     `json:"FunctionKeysIcons,omitempty"`
+	HTTPAuthPassword                       string                     `json:"HTTPAuthPassword,omitempty"`
+	HTTPConnectionType                     string                     `json:"HTTPConnectionType,omitempty"`
+	HTTPPort                               int                        `json:"HTTPPort,omitempty"`
+	HTTPSPort                              int                        `json:"HTTPSPort,omitempty"`
+	HandSetMode                            string                     `json:"HandsetMode,omitempty"`
+	HandsFreeMode                          string                     `json:"HandsFreeMode,omitempty"`
+	HeadsetMode                            string                     `json:"HeadsetMode,omitempty"`
+	HoldOnTransferAttended                 string                     `json:"HoldOnTransferAttended,omitempty"`
+	HoldOnTransferUnattended               string                     `json:"HoldOnTransferUnattended,omitempty"`
+	HttpAuthUsername                       string                     `json:"HTTPAuthUsername,omitempty"`
+	IPAddressType                          IPAddressType              `json:"IPAddressType,omitempty"`
+	IPv4Address                            string                     `json:"IPv4Address,omitempty"`
+	IPv4AlternateDNSServer                 string                     `json:"IPv4AlternateDNSServer,omitempty"`
+	IPv4PreferredDNSServer                 string                     `json:"IPv4PreferredDNSServer,omitempty"`
+	IPv4StandardGateway                    string                     `json:"IPv4StandardGateway,omitempty"`
+	IPv4SubnetMask                         string                     `json:"IPv4SubnetMask,omitempty"`
+	IncCallsWithoutCallManager             Bool                       `json:"IncCallsWithoutCallManager,omitempty"`
+	IncomingCall                           string                     `json:"IncomingCall,omitempty"`
+	LANPort                                string                     `json:"LANPort,omitempty"`
+	LDAPAdditionalAttribute                string                     `json:"LDAPAdditionalAttribute,omitempty"`
+	LDAPAdditionalAttributeDialable        string                     `json:"LDAPAdditionalAttributeDialable,omitempty"`
+	LDAPBaseDN                             string                     `json:"LDAPBaseDN,omitempty"`
+	LDAPCity                               string                     `json:"LDAPCity,omitempty"`
+	LDAPCompany                            string                     `json:"LDAPCompany,omitempty"`
+	LDAPCountry                            string                     `json:"LDAPCountry,omitempty"`
+	LDAPDirectoryName                      string                     `json:"LDAPDirectoryName,omitempty"`
+	LDAPDisplayFormat                      string                     `json:"LDAPDisplayFormat,omitempty"`
+	LDAPEmail                              string                     `json:"LDAPEmail,omitempty"`
+	LDAPEnable                             Bool                       `json:"LDAPEnable,omitempty"`
+	LDAPFax                                string                     `json:"LDAPFax,omitempty"`
+	LDAPFirstName                          string                     `json:"LDAPFirstName,omitempty"`
+	LDAPLookup                             string                     `json:"LDAPLookup,omitempty"`
+	LDAPMaxNumberOfSearchResults           int                        `json:"LDAPMaxNumberOfSearchResults,omitempty"`
+	LDAPNameFilter                         string                     `json:"LDAPNameFilter,omitempty"`
+	LDAPNumberFilter                       string                     `json:"LDAPNumberFilter,omitempty"`
+	LDAPPAssword                           string                     `json:"LDAPPassword,omitempty"`
+	LDAPPhoneHome                          string                     `json:"LDAPPhoneHome,omitempty"`
+	LDAPPhoneMobile                        string                     `json:"LDAPPhoneMobile,omitempty"`
+	LDAPPhoneOffice                        string                     `json:"LDAPPhoneOffice,omitempty"`
+	LDAPResponseTimeout                    int                        `json:"LDAPResponseTimeout,omitempty"`
+	LDAPSecurity                           string                     `json:"LDAPSecurity,omitempty"`
+	LDAPServerAddress                      string                     `json:"LDAPServerAddress,omitempty"`
+	LDAPServerPort                         int                        `json:"LDAPServerPort,omitempty"`
+	LDAPStreet                             string                     `json:"LDAPStreet,omitempty"`
+	LDAPSurname                            string                     `json:"LDAPSurname,omitempty"`
+	LDAPUsername                           string                     `json:"LDAPUsername,omitempty"`
+	LDAPZIP                                string                     `json:"LDAPZIP,omitempty"`
+	LLDAPActive                            Bool                       `json:"LLDAPActive,omitempty"`
+	LLDAPPacketInterval                    string                     `json:"LLDAPPacketInterval,omitempty"`
+	LinkSpeedDuplexLanPort                 string                     `json:"LinkSpeedDuplexLanPort,omitempty"`
+	LinkedSpeedDuplexPcPort                string                     `json:"LinkedSpeedDuplexPcPort,omitempty"`
+	LogoutTimer                            int                        `json:"LogoutTimer,omitempty"`
+	LookupIncoming                         Bool                       `json:"LookupIncomming,omitempty"` // sic!
+	LookupOutgoing                         Bool                       `json:"LookupOutgoing,omitempty"`
+	MACAddress                             string                     `json:"MACAddress,omitempty"`
+	MainMenuContent                        string                     `json:"MainMenuContent,omitempty"`
+	MenuAdaptiveJitterBuffer               Bool                       `json:"MenuAdaptiveJitterBuffer,omitempty"`
+	MenuAdjustment                         Bool                       `json:"MenuAdjustment,omitempty"`
+	MenuAudo                               Bool                       `json:"MenuAudio,omitempty"`
+	MenuCLI                                Bool                       `json:"MenuCLI,omitempty"`
+	MenuCallDivert                         Bool                       `json:"MenuCallDivert,omitempty"`
+	MenuCallHistory                        Bool                       `json:"MenuCallHistory,omitempty"`
+	MenuCallstrings                        string                     `json:"MenuCallstrings,omitempty"`
+	MenuConnections                        Bool                       `json:"MenuConnections,omitempty"`
+	MenuCoreDumps                          Bool                       `json:"MenuCoreDumps,omitempty"`
+	MenuCorporate                          Bool                       `json:"MenuCorporate,omitempty"`
+	MenuDateAndTime                        Bool                       `json:"MenuDateAndTime,omitempty"`
+	MenuDebugging                          Bool                       `json:"MenuDebugging,omitempty"`
+	MenuDeskPhone                          Bool                       `json:"MenuDeskPhone,omitempty"`
+	MenuDevice                             Bool                       `json:"MenuDevice,omitempty"`
+	MenuDiallingPlans                      Bool                       `json:"MenuDiallingPlans,omitempty"`
+	MenuDisplay                            Bool                       `json:"MenuDisplay,omitempty"`
+	MenuDoNotDisturb                       Bool                       `json:"MenuDoNotDisturb,omitempty"`
+	MenuDoorStation                        Bool                       `json:"MenuDoorStation,omitempty"`
+	MenuEvents                             Bool                       `json:"MenuEvents,omitempty"`
+	MenuExpert                             string                     `json:"menuExpert,omitempty"`
+	MenuExpertAudio                        Bool                       `json:"MenuExpertAudio,omitempty"`
+	MenuExpertNetwork                      Bool                       `json:"MenuExportNetwork,omitempty"`
+	MenuExtensionModule1                   Bool                       `json:"MenuExtensionModule1,omitempty"`
+	MenuExtensionModule2                   Bool                       `json:"MenuExtensionModule2,omitempty"`
+	MenuExtensionModule3                   Bool                       `json:"MenuExtensionModule3,omitempty"`
+	MenuFirmwareUpdate                     Bool                       `json:"MenuFirmwareUpdate,omitempty"`
+	MenuIP                                 Bool                       `json:"MenuIP,omitempty"`
+	MenuKeysAndLEDs                        Bool                       `json:"MenuKeysAndLEDs,omitempty"`
+	MenuLan                                Bool                       `json:"MenuLAN,omitempty"`
+	MenuLocalPhonebook                     Bool                       `json:"MenuLocalPhonebook,omitempty"`
+	MenuMainMenu                           Bool                       `json:"MenuMainMenu,omitempty"`
+	MenuMessageNotification                Bool                       `json:"MenuMessageNotification,omitempty"`
+	MenuNetwork                            Bool                       `json:"MenuNetwork,omitempty"`
+	MenuOnlineDirectories                  Bool                       `json:"MenuOnlineDirectories,omitempty"`
+	MenuOnlineServices                     Bool                       `json:"MenuOnlineServices',omitempty"`
+	MenuPCAPLogging                        Bool                       `json:"MenuPCAPLogging,omitempty"`
+	MenuPhoneSystem                        Bool                       `json:"MenuPhoneSystem,omitempty"`
+	MenuPhoneWebServer                     Bool                       `json:"MenuPhoneWebServer,omitempty"`
+	MenuPictures                           Bool                       `json:"MenuPictures,omitempty"`
+	MenuProvisioningConfiguration          Bool                       `json:"MenuProvisioningConfiguration,omitempty"`
+	MenuPublic                             Bool                       `json:"MenuPublic,omitempty"`
+	MenuReboot                             Bool                       `json:"MenuReboot,omitempty"`
+	MenuRebootAndReset                     Bool                       `json:"MenuRebootAndReset,omitempty"`
+	MenuRingtones                          Bool                       `json:"MenuRingtones,omitempty"`
+	MenuSIPProtocol                        Bool                       `json:"MenuSIPPRotocol,omitempty"`
+	MenuSaveAndRestore                     Bool                       `json:"MenuSaveAndRestore,omitempty"`
+	MenuSecurity                           Bool                       `json:"MenuSecurity,omitempty"`
+	Menustrings                            string                     `json:"Menustrings,omitempty"`
+	MenuStatus                             Bool                       `json:"MenuStatus,omitempty"`
+	MenuStatusConnections                  Bool                       `json:"MenuStatusConnections,omitempty"`
+	MenuStorageAllocation                  Bool                       `json:"MenuStorageAllocation,omitempty"`
+	MenuSwitchstrings                      string                     `json:"MenuSwitchstrings,omitempty"`
+	MenuSystem                             Bool                       `json:"MenuSystem,omitempty"`
+	MenuSystemLogging                      Bool                       `json:"MenuSystemLogging,omitempty"`
+	MenuTelephony                          Bool                       `json:"MenuTelephony,omitempty"`
+	MenuVoIP                               Bool                       `json:"MenuVoIP,omitempty"`
+	MenuVoiceMail                          Bool                       `json:"MenuVoiceMail,omitempty"`
+	MenuWebConfigurator                    Bool                       `json:"MenuWebConfigurator,omitempty"`
+	MenuWebcam                             Bool                       `json:"MenuWebcam,omitempty"`
+	MenuXML                                Bool                       `json:"MenuXML,omitempty"`
+	MissedCallsNotificationActive          Bool                       `json:"MissedCallsNotificationActive,omitempty"`
+	NetworkType                            string                     `json:"NetworkType,omitempty"`
+	OffHook                                string                     `json:"OffHook,omitempty"`
+	OnHook                                 string                     `json:"OnHook,omitempty"`
+	OneMelodyRingtoneDoorStation           string                     `json:"OneMelodyRingtoneDoorStation,omitempty"`
+	OneMelodyRingtoneExternal              string                     `json:"OneMelodyRingtoneExternal,omitempty"`
+	OneMelodyRingtoneGroup                 string                     `json:"OneMelodyRingtoneGroup,omitempty"`
+	OneMelodyRingtoneInterval              string                     `json:"OneMelodyRingtoneInterval,omitempty"`
+	OneMelodyRingtoneOptional              string                     `json:"OneMelodyRingtoneOptional,omitempty"`
+	OutCallsViaFunctionKey                 string                     `json:"OutCallsViaFunctionKey,omitempty"`
+	OutgoingCall                           string                     `json:"OutgoingCall,omitempty"`
+	PCPort                                 int                        `json:"PCPort,omitempty"`
+	PIN                                    string                     `json:"PIN,omitempty"`
+	PacketTimeForRtp                       string                     `json:"PacketTimeForRTP,omitempty"`
+	PhoneLanguage                          string                     `json:"PhoneLanguage,omitempty"`
+	PhoneModel                             string                     `json:"PhoneModel,omitempty"`
+	PhoneName                              string                     `json:"PhoneName,omitempty"`
+	PhoneSystem                            string                     `json:"PhoneSystem,omitempty"`
+	ProgrammableKeys                       string                     `json:"ProgrammableKeysDNDActionURLDisable,omitempty"`
+	ProgrammableKeysConferenceActionURL    string                     `json:"ProgrammableKeysConferenceActionURL,omitempty"`
+	ProgrammableKeysConferenceDTMFCode     string                     `json:"ProgrammableKeysDTMFCode,omitempty"`
+	ProgrammableKeysConferenceFAC          string                     `json:"ProgrammableKeysConferenceFAC,omitempty"`
+	ProgrammableKeysConferenceType         string                     `json:"ProgrammableKeysConferenceType,omitempty"`
+	ProgrammableKeysDNDActionURLEnable     string                     `json:"ProgrammableKeysDNDActionURLEnable,omitempty"`
+	ProgrammableKeysDNDFACDisable          string                     `json:"ProgrammableKeysDNDFACDisable,omitempty"`
+	ProgrammableKeysDNDType                string                     `json:"ProgrammableKeysDNDType,omitempty"`
+	ProgrammableKeysDirectoryType          string                     `json:"ProgrammableKeysDirectoryType,omitempty"`
+	ProgrammableKeysHoldActionURL          string                     `json:"ProgrammableKeysHoldActionURL,omitempty"`
+	ProgrammableKeysHoldDTMFCode           string                     `json:"ProgrammableKeysHoldDTMFCode,omitempty"`
+	ProgrammableKeysHoldType               string                     `json:"ProgrammableKeysHoldType,omitempty"`
+	ProgrammableKeysMessagesActionURL      string                     `json:"ProgrammableKeysMessagesActionURL,omitempty"`
+	ProgrammableKeysMessagesFAC            string                     `json:"ProgrammableKeysMessagesFAC,omitempty"`
+	ProgrammableKeysMessagesType           string                     `json:"ProgrammableKeysMessagesType,omitempty"`
+	ProvisioningDirectLink                 string                     `json:"ProvisioningDirectLink,omitempty"`
+	ProvisioningServer                     string                     `json:"ProvisioningServer,omitempty"`
+	ProxyServerActive                      Bool                       `json:"ProxyServerActive,omitempty"`
+	ProxyServerAddress                     string                     `json:"ProxyServerAddress,omitempty"`
+	ProxyServerPort                        int                        `json:"ProxyServerPort,omitempty"`
+	QuickDialKeys                          []QuickDialKey             `json:"QuickDialKeys,omitempty"`
+	RTPQoSDSCP                             int                        `json:"RTPoSDSCP,omitempty"`
+	RegistrationFailed                     string                     `json:"RegistrationFailed,omitempty"`
+	RegistrationSucceeded                  string                     `json:"RegistrationSucceeded,omitempty"`
+	RemoteControlAllow                     Bool                       `json:"RemoteControlAllow,omitempty"`
+	RemoteControlSource                    string                     `json:"RemoteControlSource,omitempty"`
+	SIPAccountFailover                     string                     `json:"SIPAccountFailover,omitempty"`
+	SIPG729AnnexB                          Bool                       `json:"SIPG729AnnexB,omitempty"`
+	SIPNoSrtpCalls                         string                     `json:"SipNoSrtpCalls,omitempty"`
+	SIPPort                                int                        `json:"SIPPort,omitempty"`
+	SIPPrack                               string                     `json:"SIPPRack,omitempty"`
+	SIPQoSDSCP                             int                        `json:"SIPQoSDSCP,omitempty"`
+	SIPRtpPort                             int                        `json:"SIPRtpPort,omitempty"`
+	SIPRtpRTCPXRServerAddress              string                     `json:"SIPRtpRTCPXRServerAddress,omitempty"`
+	SIPRtpRTCPXRServerPort                 int                        `json:"SIPRtpRTCXRServerPort,omitempty"`
+	SIPRtpRandomPort                       string                     `json:"SipRtpRandomPort,omitempty"`
+	SIPRtpSymmetricPort                    Bool                       `json:"SIPRtpSymetricPort,omitempty"` // sic!
+	SIPRtpUseRTCPXR                        Bool                       `json:"SIPRtpUseRTCPXR,omitempty"`
+	SIPRtprRtcp                            Bool                       `json:"SIPRtprRtcp,omitempty"`
+	SIPSCertificate                        string                     `json:"SIPSCertificate,omitempty"`
+	SIPSKeyPassword                        string                     `json:"SIPSKeyPassword,omitempty"`
+	SIPSPrivateKey                         string                     `json:"SIPSPrivateKey,omitempty"`
+	SIPSecurity                            string                     `json:"SIPSecurity,omitempty"`
+	SIPSecurityEnabled                     string                     `json:"SIPSecurityEnabled,omitempty"`
+	SIPSessionTimer                        int                        `json:"SIPSessionTimer,omitempty"`
+	SIPTimerT1                             int                        `json:"SIPTimerT1,omitempty"`
+	SIPTimersFailedRegistration            int                        `json:"SIPTimersFailedRegistration,omitempty"`
+	SIPTimersFailedSubscription            int                        `json:"SIPTimersFailSubscription,omitempty"`
+	SIPTimersSubscription                  int                        `json:"SIPTimersSubscription,omitempty"`
+	SIPTimersSubscriptionBLFFollowRegister Bool                       `json:"SIPTimersSubscriptionBLFFollowRegister,omitempty"`
+	SIPTransportProtocol                   TransportProtocol          `json:"SIPTransportProtocol,omitempty"`
+	SIPSrtp                                string                     `json:"SIPSrtp,omitempty"`
+	ScreenSaverTimeout                     string                     `json:"ScreenSaverTimeout,omitempty"`
+	Screensaver                            string                     `json:"Screensaver,omitempty"`
+	ScreensaverBacklight                   int                        `json:"ScreensaverBacklight,omitempty"`
+	ScreensaverHTTPSource                  string                     `json:"ScreensaverHTTPSource,omitempty"`
+	ScreensaverPictures                    string                     `json:"ScreensaverPictures,omitempty"`
+	SelectedCodecs                         []int                      `json:"SelectedCodecs,omitempty"`
+	SelectedServesDisable                  Bool                       `json:"SelectedServicesDisable,omitempty"`
+	SemiAttendedTransferType               string                     `json:"SemiAttendedTransferType,omitempty"`
+	StringsVersion                         string                     `json:"StringsVersion,omitempty"`
+	ShowPIN                                Bool                       `json:"ShowPIN,omitempty"`
+	ShowPassword                           Bool                       `json:"ShowPassword,omitempty"`
+	ShowSIPMessagesOnDisplay               Bool                       `json:"ShowSIPMessagesOnDisplay,omitempty"`
+	Sip                                    Sips                       `json:"SIP,omitempty"`
+	SoftReboots                            int                        `json:"SoftReboots,omitempty"`
+	SoftwareVariant                        string                     `json:"SoftwareVariant,omitempty"`
+	SoftwareVersion                        string                     `json:"SoftwareVersion,omitempty"`
+	StandbyBacklight                       int                        `json:"StandbyBacklight,omitempty"`
+	Startups                               int                        `json:"Startups,omitempty"`
+	SyslogEnabled                          Bool                       `json:"SyslogEnabled,omitempty"`
+	SyslogServer                           string                     `json:"SyslogServer,omitempty"`
+	SystemLocalPhonebookUpdateTime         string                     `json:"SystemLocalPhonebookUpdateTime,omitempty"`
+	SystemLocalPhonebookUrl                string                     `json:"SystemLocalPhonebookUrl,omitempty"`
+	TimeFormat                             string                     `json:"TimeFormat,omitempty"`
+	TimeServer                             string                     `json:"TimeServer,omitempty"`
+	TimeServerDHCP                         string                     `json:"TimeServerDHCP,omitempty"`
+	TimeServerProvisioning                 string                     `json:"TimeServerProvisioning,omitempty"`
+	TimeZone                               string                     `json:"TimeZone,omitempty"`
+	Timestamp                              int                        `json:"Timestamp,omitempty"`
+	ToneScheme                             string                     `json:"TomeScheme,omitempty"`
+	UserPassword                           string                     `json:"UserPassword,omitempty"`
+	VLANIdentifierLAN                      int                        `json:"VLANIdentifierLAN,omitempty"`
+	VLANIdentifierPC                       int                        `json:"VLANIdentifierPC,omitempty"`
+	VLANLocked                             Bool                       `json:"VLANLocked,omitempty"`
+	VLANPriorityLAN                        string                     `json:"VLANPriorityLAN,omitempty"`
+	VLANPriorityPC                         string                     `json:"VLANPriorityDC,omitempty"`
+	VLANTagging                            Bool                       `json:"VLANTagging,omitempty"`
+	Variant                                string                     `json:"Variant,omitempty"`
+	VoiceQuality                           string                     `json:"VoiceQuality,omitempty"`
+	VoicemailMessagesActive                Bool                       `json:"VoicemailMessagesActive,omitempty"`
+	WebUICallDivertDisable                 Bool                       `json:"WebUICallDivertDisable,omitempty"`
+	WebUICallWaitingDisable                Bool                       `json:"WebUICallWaitingDisable,omitempty"`
+	WebUILanguage                          string                     `json:"WebUILanguage,omitempty"`
+	WitholdNumberDisable                   Bool                       `json:"WitholdNumberDisable,omitempty"`
+	WorkingCounter                         int                        `json:"WorkingCounter,omitempty"`
+	WorkingCounterSec                      int                        `json:"WorkingCounterSec,omitempty"`
+	XMLProviderName                        string                     `json:"XMLProviderName,omitempty"`
+	XSIAuthName                            string                     `json:"XSIAuthName,omitempty"`
+	XSIAuthPassword                        string                     `json:"XSIAuthPassword,omitempty"`
+	XSICallLogType                         string                     `json:"XSICallLogType,omitempty"`
+	XSIEnterpriseCommonDirectoryEnabled    Bool                       `json:"XSIEnterpriseCommonDirectoryEnabled,omitempty"`
+	XSIEnterpriseCommonDirectoryName       string                     `json:"XSIEnterpriseCommonDirectoryName,omitempty"`
+	XSIEnterpriseDirectoryEnabled          Bool                       `json:"XSIEnterpriseDirectoryEnabled,omitempty"`
+	XSIEnterpriseDirectoryName             string                     `json:"XSIEnterpriseDirectoryName,omitempty"`
+	XSIGroupCommonDirectoryEnabled         Bool                       `json:"XSIGroupCommonDirectoryEnabled,omitempty"`
+	XSIGroupCommonDirectoryName            string                     `json:"XSIGroupCommonDirectoryName,omitempty"`
+	XSIGroupDirectoryEnabled               Bool                       `json:"XSIGroupDirectoryEnabled,omitempty"`
+	XSIGroupDirectoryName                  string                     `json:"XSIGroupDirectoryName,omitempty"`
+	XSIPersonalDirectoryEnabled            Bool                       `json:"XSIPersonalDirectoryEnabled,omitempty"`
+	XSIPersonalDirectoryName               string                     `json:"XSIPersonalDirectoryName,omitempty"`
+	XSISIPAuthentication                   Bool                       `json:"XSISIPAuthentication,omitempty"`
+	XSISearchAnywhereInNameEnabled         Bool                       `json:"XSISearchAnywhereInNameEnabled,omitempty"`
+	XSIServer                              string                     `json:"XSIServer,omitempty"`
+	XmlEnablePrivateDirectory              Bool                       `json:"XmlEnablePrivateDirectory,omitempty"`
+	XmlEnableWhiteDirectory                Bool                       `json:"XmlEnableWhiteDirectory,omitempty"`
+	XmlEnableYellowDirectory               Bool                       `json:"XmlEnableYellowDirectory,omitempty"`
+	XmlNumberFilter                        string                     `json:"XmlNumberFilter,omitempty"`
+	XmlPassword                            string                     `json:"XMLPassword,omitempty"`
+	XmlPrivateDirectoryName                string                     `json:"XMLPrivateDirectoryName,omitempty"`
+	XmlServerAddress                       string                     `json:"XmlServerAddress,omitempty"`
+	XmlUsername                            string                     `json:"XmlUsername,omitempty"`
+	XmlWhiteDirectoryName                  string                     `json:"XmlWhiteDirectoryName,omitempty"`
+	XmlYellowDirectoryName                 string                     `json:"XmlYellowDirectoryName,omitempty"`
+	Unknown                                map[string]json.RawMessage `json:"-"`
+	Metadata                               map[string]Metadata        `json:"-"`
+}
+
+// ignoredFields are never kept in Unknown.
+var ignoredFields = map[string]bool{
+	// see the comment of AdminPassword above
+	"AdminPassword": true,
 }
 
 func (p *Parameters) UnmarshalJSON(data []byte) error {
-	return unmarshalInternal(data, p)
+	err := unmarshalInternal(data, p)
+	if err != nil {
+		return err
+	}
+	p.Unknown = unknownFields(data, reflect.TypeOf(p).Elem())
+	for name := range ignoredFields {
+		delete(p.Unknown, name)
+	}
+	if len(p.Unknown) == 0 {
+		p.Unknown = nil
+	}
+	p.Metadata = parseMetadata(data)
+	return nil
+}
+
+// MarshalJSON marshals the parameters in the upload format and appends the Unknown settings
+// in alphabetical order.
+func (p Parameters) MarshalJSON() ([]byte, error) {
+	type plain Parameters
+	data, err := json.Marshal(plain(p))
+	if err != nil {
+		return nil, err
+	}
+	return appendUnknown(data, p.Unknown), nil
+}
+
+// WithoutMetadata returns a copy of the parameters in which the Unknown settings, including those
+// of the SIP accounts and function keys, only contain their values, as expected by the phones when uploading.
+func (p Parameters) WithoutMetadata() Parameters {
+	p.Unknown = unknownValues(p.Unknown)
+	if p.Sip != nil {
+		sips := make(Sips, len(p.Sip))
+		for index, sip := range p.Sip {
+			sip.Unknown = unknownValues(sip.Unknown)
+			sips[index] = sip
+		}
+		p.Sip = sips
+	}
+	if p.FunctionKeys != nil {
+		keys := make(FunctionKeys, len(p.FunctionKeys))
+		for index, key := range p.FunctionKeys {
+			key.Unknown = unknownValues(key.Unknown)
+			keys[index] = key
+		}
+		p.FunctionKeys = keys
+	}
+	return p
+}
+
+// unknownFields returns the settings of the JSON object which are not modelled by a field of the type.
+func unknownFields(data []byte, known reflect.Type) map[string]json.RawMessage {
+	raw := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &raw)
+	var removeKnown func(known reflect.Type)
+	removeKnown = func(known reflect.Type) {
+		for index := 0; index < known.NumField(); index++ {
+			field := known.Field(index)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				removeKnown(field.Type)
+				continue
+			}
+			delete(raw, jsonFieldName(field))
+		}
+	}
+	removeKnown(known)
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
+
+// appendUnknown appends the unknown settings in alphabetical order to the marshalled JSON object.
+func appendUnknown(data []byte, unknown map[string]json.RawMessage) []byte {
+	if len(unknown) == 0 {
+		return data
+	}
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	buffer.Write(data[:len(data)-1])
+	for _, name := range names {
+		if len(unknown[name]) == 0 {
+			continue
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(unknown[name])
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes()
+}
+
+// unknownValues returns a copy of the unknown settings which only contains their values without metadata.
+func unknownValues(unknown map[string]json.RawMessage) map[string]json.RawMessage {
+	if len(unknown) == 0 {
+		return unknown
+	}
+	result := make(map[string]json.RawMessage)
+	for name, value := range unknown {
+		object := make(map[string]json.RawMessage)
+		if json.Unmarshal(value, &object) == nil {
+			if plain, ok := object["value"]; ok {
+				value = plain
+			}
+		}
+		result[name] = value
+	}
+	return result
 }
 
 type FunctionKeys []FunctionKey
@@ -406,79 +536,98 @@ func ReplaceDisplayName(original, replace string) func(index int, key *FunctionK
 }
 
 type FunctionKey struct {
-	AutomaticallyFilled string `json:"AutomaticallyFilled,omitempty"`
-	CallDivertType      string `json:"CallDivertType,omitempty"`
-	CallPickupCode      string `json:"CallPickupCode,omitempty"`
-	Color               string `json:"Color,omitempty"`
-	Connection          string `json:"Connection,omitempty"`
-	DTMFCode            string `json:"DTMFCode,omitempty"`
-	DisableCode         string `json:"DisableCode,omitempty"`
-	DisplayName         string `json:"DisplayName,omitempty"`
-	EnableCode          string `json:"EnableCode,omitempty"`
-	LockProvisioning    string `json:"LockProvisioning,omitempty"`
-	PhoneNumber         string `json:"PhoneNumber,omitempty"`
-	Silent              string `json:"Silent,omitempty"`
-	Type                string `json:"Type,omitempty"`
-	Url                 string `json:"URL,omitempty"`
+	AutomaticallyFilled Bool    `json:"AutomaticallyFilled,omitempty"`
+	CallDivertType      string  `json:"CallDivertType,omitempty"`
+	CallPickupCode      string  `json:"CallPickupCode,omitempty"`
+	Color               string  `json:"Color,omitempty"`
+	Connection          string  `json:"Connection,omitempty"`
+	DTMFCode            string  `json:"DTMFCode,omitempty"`
+	DisableCode         string  `json:"DisableCode,omitempty"`
+	DisplayName         string  `json:"DisplayName,omitempty"`
+	EnableCode          string  `json:"EnableCode,omitempty"`
+	LockProvisioning    Bool    `json:"LockProvisioning,omitempty"`
+	PhoneNumber         string  `json:"PhoneNumber,omitempty"`
+	Silent              Bool    `json:"Silent,omitempty"`
+	Type                KeyType `json:"Type,omitempty"`
+	Url                 string  `json:"URL,omitempty"`
+	// Unknown keeps the settings of the key which are not modelled by a field, see Parameters.
+	Unknown map[string]json.RawMessage `json:"-"`
 }
 
 func (f *FunctionKey) IsEmpty() bool {
-	return (f.Type == "" && f.PhoneNumber == "" && f.DisplayName == "" && f.CallPickupCode == "") || f.Type == "-1"
+	return (f.Type == "" && f.PhoneNumber == "" && f.DisplayName == "" && f.CallPickupCode == "") || f.Type == KeyTypeNone
 }
 
 func (f *FunctionKey) UnmarshalJSON(data []byte) error {
-	return unmarshalInternal(data, f)
+	err := unmarshalInternal(data, f)
+	if err != nil {
+		return err
+	}
+	f.Unknown = unknownFields(data, reflect.TypeOf(f).Elem())
+	return nil
+}
+
+// MarshalJSON marshals the function key and appends the Unknown settings in alphabetical order.
+func (f FunctionKey) MarshalJSON() ([]byte, error) {
+	type plain FunctionKey
+	data, err := json.Marshal(plain(f))
+	if err != nil {
+		return nil, err
+	}
+	return appendUnknown(data, f.Unknown), nil
 }
 
 type Sip struct {
-	AccountName                   string `json:"AccountName,omitempty"`
-	Active                        string `json:"Active,omitempty"`
-	AllowRouteHeaders             string `json:"AllowRouteHeaders,omitempty"`
-	AuthenaticationName           string `json:"AuthenticationName,omitempty"`
-	AuthenticationPassword        string `json:"AuthenticationPassword,omitempty"`
-	AutoNegOfDTMFTransmission     string `json:"AutoNetOfDTMSTransmission,omitempty"`
-	CLIPSource                    string `json:"CLIPSource,omitempty"`
-	CLIR                          string `json:"CLIR,omitempty"`
-	CallWaiting                   string `json:"CallWaiting,omitempty"`
-	CallWaitingSignal             string `json:"CallWaitingSignal,omitempty"`
-	CountMissedAcceptedCalls      string `json:"CountMissedAcceptedCalls,omitempty"`
-	DNSQuery                      string `json:"DNSQuery,omitempty"`
-	DTMFTransmission              string `json:"DTMFTransmission,omitempty"`
-	DisplayName                   string `json:"DisplayName,omitempty"`
-	Domain                        string `json:"Domain,omitempty"`
-	FailoverServerAddress         string `json:"FailoverServerAddress,omitempty"`
-	FailoverServerEnabled         string `json:"FailoverServerEnabled,omitempty"`
-	FailoverServerPort            int    `json:"FailoverServerPort,omitempty"`
-	HeaderDoorstation             string `json:"HeaderDoorstation,omitempty"`
-	HeaderExternal                string `json:"HeaderExternal,omitempty"`
-	HeaderGroup                   string `json:"HeaderGroup,omitempty"`
-	HeaderInternal                string `json:"HeaderInternal,omitempty"`
-	HeaderOptional                string `json:"HeaderOptional,omitempty"`
-	ICE                           string `json:"ICE,omitempty"`
-	NATRefreshTime                int    `json:"NATRefreshTime,omitempty"`
-	OutboundProxyAddress          string `json:"OutboundProxyAddress,omitempty"`
-	OutboundProxyMode             string `json:"OutboundProxyMode,omitempty"`
-	OutboundProxyPort             int    `json:"OutboundProxyPort,omitempty"`
-	Provider                      string `json:"Provider,omitempty"`
-	ProxyServerAddress            string `json:"ProxyServerAddress,omitempty"`
-	ProxyServerPort               int    `json:"ProxyServerPort,omitempty"`
-	RegistrationServerAddress     string `json:"RegistrationServerAddress,omitempty"`
-	RegistrationServerPort        int    `json:"RegistrationSeverPort,omitempty"`
-	RegistrationServerRefreshTiem int    `json:"RegistrationServerRefreshTime,omitempty"`
-	RequestCheckOptions           int    `json:"RequestCheckOptions,omitempty"`
-	ReregisterAlternative         string `json:"ReregisterAlternative,omitempty"`
-	RingtoneDoorStation           string `json:"RingtoneDoorStation,omitempty"`
-	RingtoneExternal              string `json:"RingtoneExternal,omitempty"`
-	RingtoneGroup                 string `json:"RingtoneGroup,omitempty"`
-	RingtoneInternal              string `json:"RingtoneInternal,omitempty"`
-	RingtoneOptional              string `json:"RingtoneOptional,omitempty"`
-	STUNEnabled                   string `json:"STUNEnabled,omitempty"`
-	STUNRefreshTime               int    `json:"STUNRefreshTime,omitempty"`
-	STUNServerAddress             string `json:"STUNServerAddress,omitempty"`
-	STUNServerPort                int    `json:"STUNServerPort,omitempty"`
-	Username                      string `json:"Username,omitempty"`
-	VoiceMailActive               string `json:"VoiceMailActive,omitempty"`
-	VoiceMailMailbox              string `json:"VoiceMailMailbox,omitempty"`
+	AccountName                   string   `json:"AccountName,omitempty"`
+	Active                        Bool     `json:"Active,omitempty"`
+	AllowRouteHeaders             Bool     `json:"AllowRouteHeaders,omitempty"`
+	AuthenaticationName           string   `json:"AuthenticationName,omitempty"`
+	AuthenticationPassword        string   `json:"AuthenticationPassword,omitempty"`
+	AutoNegOfDTMFTransmission     string   `json:"AutoNetOfDTMSTransmission,omitempty"`
+	CLIPSource                    string   `json:"CLIPSource,omitempty"`
+	CLIR                          Bool     `json:"CLIR,omitempty"`
+	CallWaiting                   Bool     `json:"CallWaiting,omitempty"`
+	CallWaitingSignal             string   `json:"CallWaitingSignal,omitempty"`
+	CountMissedAcceptedCalls      Bool     `json:"CountMissedAcceptedCalls,omitempty"`
+	DNSQuery                      Bool     `json:"DNSQuery,omitempty"`
+	DTMFTransmission              DTMFMode `json:"DTMFTransmission,omitempty"`
+	DisplayName                   string   `json:"DisplayName,omitempty"`
+	Domain                        string   `json:"Domain,omitempty"`
+	FailoverServerAddress         string   `json:"FailoverServerAddress,omitempty"`
+	FailoverServerEnabled         Bool     `json:"FailoverServerEnabled,omitempty"`
+	FailoverServerPort            int      `json:"FailoverServerPort,omitempty"`
+	HeaderDoorstation             string   `json:"HeaderDoorstation,omitempty"`
+	HeaderExternal                string   `json:"HeaderExternal,omitempty"`
+	HeaderGroup                   string   `json:"HeaderGroup,omitempty"`
+	HeaderInternal                string   `json:"HeaderInternal,omitempty"`
+	HeaderOptional                string   `json:"HeaderOptional,omitempty"`
+	ICE                           Bool     `json:"ICE,omitempty"`
+	NATRefreshTime                int      `json:"NATRefreshTime,omitempty"`
+	OutboundProxyAddress          string   `json:"OutboundProxyAddress,omitempty"`
+	OutboundProxyMode             string   `json:"OutboundProxyMode,omitempty"`
+	OutboundProxyPort             int      `json:"OutboundProxyPort,omitempty"`
+	Provider                      string   `json:"Provider,omitempty"`
+	ProxyServerAddress            string   `json:"ProxyServerAddress,omitempty"`
+	ProxyServerPort               int      `json:"ProxyServerPort,omitempty"`
+	RegistrationServerAddress     string   `json:"RegistrationServerAddress,omitempty"`
+	RegistrationServerPort        int      `json:"RegistrationSeverPort,omitempty"`
+	RegistrationServerRefreshTiem int      `json:"RegistrationServerRefreshTime,omitempty"`
+	RequestCheckOptions           int      `json:"RequestCheckOptions,omitempty"`
+	ReregisterAlternative         Bool     `json:"ReregisterAlternative,omitempty"`
+	RingtoneDoorStation           string   `json:"RingtoneDoorStation,omitempty"`
+	RingtoneExternal              string   `json:"RingtoneExternal,omitempty"`
+	RingtoneGroup                 string   `json:"RingtoneGroup,omitempty"`
+	RingtoneInternal              string   `json:"RingtoneInternal,omitempty"`
+	RingtoneOptional              string   `json:"RingtoneOptional,omitempty"`
+	STUNEnabled                   Bool     `json:"STUNEnabled,omitempty"`
+	STUNRefreshTime               int      `json:"STUNRefreshTime,omitempty"`
+	STUNServerAddress             string   `json:"STUNServerAddress,omitempty"`
+	STUNServerPort                int      `json:"STUNServerPort,omitempty"`
+	Username                      string   `json:"Username,omitempty"`
+	VoiceMailActive               Bool     `json:"VoiceMailActive,omitempty"`
+	VoiceMailMailbox              string   `json:"VoiceMailMailbox,omitempty"`
+	// Unknown keeps the settings of the account which are not modelled by a field, see Parameters.
+	Unknown map[string]json.RawMessage `json:"-"`
 }
 
 type Sips []Sip
@@ -512,7 +661,22 @@ func SipOverrideDisplayName(newName string) func(index int, sip *Sip) bool {
 }
 
 func (s *Sip) UnmarshalJSON(data []byte) error {
-	return unmarshalInternal(data, s)
+	err := unmarshalInternal(data, s)
+	if err != nil {
+		return err
+	}
+	s.Unknown = unknownFields(data, reflect.TypeOf(s).Elem())
+	return nil
+}
+
+// MarshalJSON marshals the SIP account and appends the Unknown settings in alphabetical order.
+func (s Sip) MarshalJSON() ([]byte, error) {
+	type plain Sip
+	data, err := json.Marshal(plain(s))
+	if err != nil {
+		return nil, err
+	}
+	return appendUnknown(data, s.Unknown), nil
 }
 
 type Dnd struct {
@@ -527,8 +691,8 @@ func (d *Dnd) UnmarshalJSON(data []byte) error {
 type CallDivertAll struct {
 	TargetMail string `json:"TargetMail,omitempty"`
 	Target     string `json:"Target,omitempty"`
-	VoiceMail  string `json:"VoiceMail,omitempty"`
-	Active     string `json:"Active,omitempty"`
+	VoiceMail  Bool   `json:"VoiceMail,omitempty"`
+	Active     Bool   `json:"Active,omitempty"`
 }
 
 func (c *CallDivertAll) UnmarshalJSON(data []byte) error {
@@ -570,8 +734,8 @@ func (c *CallDivertNoAnswer) UnmarshalJSON(data []byte) error {
 }
 
 type CallDivertBusy struct {
-	VoiceMail  string `json:"VoiceMail,omitempty"`
-	Active     string `json:"Active,omitempty"`
+	VoiceMail  Bool   `json:"VoiceMail,omitempty"`
+	Active     Bool   `json:"Active,omitempty"`
 	TargetMail string `json:"TargetMail,omitempty"`
 	Target     string `json:"Target,omitempty"`
 }
@@ -583,9 +747,9 @@ func (c *CallDivertBusy) UnmarshalJSON(data []byte) error {
 type DialingPlan struct {
 	PhoneNumber string `json:"PhoneNumber,omitempty"`
 	Comment     string `json:"Comment,omitempty"`
-	Active      string `json:"Active,omitempty"`
+	Active      Bool   `json:"Active,omitempty"`
 	Connection  string `json:"Connection,omitempty"`
-	UseAreaCode string `json:"UseAreaCode,omitempty"`
+	UseAreaCode Bool   `json:"UseAreaCode,omitempty"`
 }
 
 func (d *DialingPlan) UnmarshalJSON(data []byte) error {
@@ -601,7 +765,18 @@ func unmarshalInternal(data []byte, v interface{}) error {
 	structValue := reflect.ValueOf(v)
 	for index := 0; index < structValue.Elem().NumField(); index++ {
 		field := structValue.Elem().Field(index)
+		if structValue.Elem().Type().Field(index).Anonymous && field.Kind() == reflect.Struct {
+			// like encoding/json, the fields of embedded structs are read from the embedding struct
+			err = unmarshalInternal(data, field.Addr().Interface())
+			if err != nil {
+				return err
+			}
+			continue
+		}
 		jsonName := jsonFieldName(structValue.Elem().Type().Field(index))
+		if jsonName == "-" {
+			continue
+		}
 		if value, ok := raw[jsonName]; ok {
 			if reflect.TypeOf(value).Kind() == reflect.Map {
 				value = value.(map[string]interface{})["value"]
@@ -65,3 +65,91 @@ func TestSips_Transform(t *testing.T) {
 	assert.Equal(t, "", got[1].DisplayName)
 	assert.Equal(t, "222 John", got[2].DisplayName)
 }
+
+func TestParameters_Unknown(t *testing.T) {
+	data := `{
+  "TimeFormat": {"value": "24h", "flags": 8},
+  "AdminPassword": "admin",
+  "NewFirmwareKey": {"value": "on", "flags": 8, "validator": {"type": "bool"}},
+  "OtherKey": 42
+}`
+	parameters := Parameters{}
+	require.NoError(t, json.Unmarshal([]byte(data), &parameters), "no error expected")
+	assert.Equal(t, "24h", parameters.TimeFormat, "known field is wrong")
+	require.Equal(t, 2, len(parameters.Unknown), "number of unknown fields is wrong")
+	assert.JSONEq(t, `{"value": "on", "flags": 8, "validator": {"type": "bool"}}`, string(parameters.Unknown["NewFirmwareKey"]), "metadata should be kept")
+
+	got, err := json.Marshal(parameters)
+	require.NoError(t, err, "no error expected")
+	assert.JSONEq(t, `{"TimeFormat": "24h", "NewFirmwareKey": {"value": "on", "flags": 8, "validator": {"type": "bool"}}, "OtherKey": 42}`, string(got), "unknown fields should be re-emitted")
+
+	got, err = json.Marshal(parameters.WithoutMetadata())
+	require.NoError(t, err, "no error expected")
+	assert.JSONEq(t, `{"TimeFormat": "24h", "NewFirmwareKey": "on", "OtherKey": 42}`, string(got), "metadata should be removed")
+	assert.JSONEq(t, `{"value": "on", "flags": 8, "validator": {"type": "bool"}}`, string(parameters.Unknown["NewFirmwareKey"]), "original must not be altered")
+
+	got, err = json.Marshal(Parameters{Unknown: map[string]json.RawMessage{"OtherKey": json.RawMessage("1")}})
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, `{"OtherKey":1}`, string(got), "only unknown fields expected")
+}
+
+func TestParameters_UnknownEntries(t *testing.T) {
+	data := `{
+  "SIP": [{"DisplayName": {"value": "Office", "flags": 8}, "SRTPMode": {"value": "2", "flags": 8}}],
+  "FunctionKeys": [{"DisplayName": "John", "LEDMode": "blink"}]
+}`
+	parameters := Parameters{}
+	require.NoError(t, json.Unmarshal([]byte(data), &parameters), "no error expected")
+	assert.Equal(t, "Office", parameters.Sip[0].DisplayName, "known field of SIP account is wrong")
+	require.Equal(t, 1, len(parameters.Sip[0].Unknown), "number of unknown fields of SIP account is wrong")
+	assert.JSONEq(t, `{"value": "2", "flags": 8}`, string(parameters.Sip[0].Unknown["SRTPMode"]), "unknown field of SIP account should be kept")
+	require.Equal(t, 1, len(parameters.FunctionKeys[0].Unknown), "number of unknown fields of function key is wrong")
+	assert.JSONEq(t, `"blink"`, string(parameters.FunctionKeys[0].Unknown["LEDMode"]), "unknown field of function key should be kept")
+
+	got, err := json.Marshal(parameters.WithoutMetadata())
+	require.NoError(t, err, "no error expected")
+	assert.JSONEq(t, `{"SIP": [{"DisplayName": "Office", "SRTPMode": "2"}], "FunctionKeys": [{"DisplayName": "John", "LEDMode": "blink"}]}`, string(got), "unknown fields of entries should be uploaded")
+	assert.JSONEq(t, `{"value": "2", "flags": 8}`, string(parameters.Sip[0].Unknown["SRTPMode"]), "original must not be altered")
+
+	uploaded := Parameters{}
+	require.NoError(t, json.Unmarshal(got, &uploaded), "no error expected")
+	again, err := json.Marshal(uploaded)
+	require.NoError(t, err, "no error expected")
+	assert.JSONEq(t, string(got), string(again), "unknown fields of entries should survive the round trip")
+}
+
+func TestSips_WithSlots(t *testing.T) {
+	sips := Sips{{DisplayName: "John"}}
+	assert.Equal(t, Sips{{DisplayName: "John"}, {}, {}}, sips.WithSlots(3), "sips are not extended correctly")
+	assert.Equal(t, sips, sips.WithSlots(0), "sips must not be shortened")
+}
+
+func TestSipUpdateAccount(t *testing.T) {
+	sips := Sips{{DisplayName: "John", Domain: "old.example.com", Username: "20"}, {DisplayName: "Linda"}}
+	got, changed := sips.Transform(SipUpdateAccount(0, Sip{Domain: "new.example.com", Username: "20", ProxyServerPort: 5060}))
+	assert.Equal(t, []int{0}, changed, "changed indices are not correct")
+	assert.Equal(t, Sip{DisplayName: "John", Domain: "new.example.com", Username: "20", ProxyServerPort: 5060}, got[0], "account is not updated correctly")
+	assert.Equal(t, sips[1], got[1], "other accounts must not be changed")
+	assert.Equal(t, "old.example.com", sips[0].Domain, "original accounts must not be changed")
+
+	_, changed = sips.Transform(SipUpdateAccount(0, Sip{Username: "20"}))
+	assert.Equal(t, []int{}, changed, "equal values should not be reported as changed")
+}
+
+func TestSipSetActive(t *testing.T) {
+	sips := Sips{{Active: True}, {Active: False}, {}}
+	got, changed := sips.Transform(SipSetActive(true, 0, 1, 2))
+	assert.Equal(t, []int{1, 2}, changed, "changed indices are not correct")
+	assert.Equal(t, Sips{{Active: True}, {Active: True}, {Active: True}}, got, "accounts are not enabled")
+
+	got, changed = sips.Transform(SipSetActive(false, 0))
+	assert.Equal(t, []int{0}, changed, "changed indices are not correct")
+	assert.Equal(t, False, got[0].Active, "account is not disabled")
+}
+
+func TestSipRotatePassword(t *testing.T) {
+	sips := Sips{{Username: "20", AuthenticationPassword: "old"}}
+	got, changed := sips.Transform(SipRotatePassword(0, "new"))
+	assert.Equal(t, []int{0}, changed, "changed indices are not correct")
+	assert.Equal(t, Sip{Username: "20", AuthenticationPassword: "new"}, got[0], "password is not rotated")
+}
@@ -0,0 +1,222 @@
+package params
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type pathSegment struct {
+	name  string
+	index int
+}
+
+// parsePath splits a path like "SIP[0].DisplayName" into its segments. The index of
+// a segment without index is -1.
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	result := make([]pathSegment, 0)
+	for _, part := range strings.Split(path, ".") {
+		segment := pathSegment{name: part, index: -1}
+		if open := strings.Index(part, "["); open != -1 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("missing \"]\" in \"%s\"", part)
+			}
+			index, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("index of \"%s\" must be a non-negative integer", part)
+			}
+			segment = pathSegment{name: part[:open], index: index}
+		}
+		if segment.name == "" {
+			return nil, fmt.Errorf("empty field name in \"%s\"", path)
+		}
+		result = append(result, segment)
+	}
+	return result, nil
+}
+
+// fieldByJSONName returns the field of the struct with the passed JSON name. Fields of
+// embedded structs are found as well, just like encoding/json does.
+func fieldByJSONName(structValue reflect.Value, name string) (reflect.Value, bool) {
+	field, _, ok := findField(structValue, name, jsonFieldName)
+	return field, ok
+}
+
+// fieldByName is like fieldByJSONName, but also finds the field by its Go name, e.g. "Sip" instead of "SIP".
+// The JSON name of the found field is returned as well.
+func fieldByName(structValue reflect.Value, name string) (reflect.Value, string, bool) {
+	if field, jsonName, ok := findField(structValue, name, jsonFieldName); ok {
+		return field, jsonName, true
+	}
+	return findField(structValue, name, func(field reflect.StructField) string {
+		return field.Name
+	})
+}
+
+func findField(structValue reflect.Value, name string, nameOf func(reflect.StructField) string) (reflect.Value, string, bool) {
+	for index := 0; index < structValue.NumField(); index++ {
+		field := structValue.Type().Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if found, jsonName, ok := findField(structValue.Field(index), name, nameOf); ok {
+				return found, jsonName, true
+			}
+			continue
+		}
+		if nameOf(field) == name && jsonFieldName(field) != "-" {
+			return structValue.Field(index), jsonFieldName(field), true
+		}
+	}
+	return reflect.Value{}, "", false
+}
+
+// setPath sets the value at the path of the struct the target points to. Slices are
+// extended with empty entries if the index of the path is beyond their length.
+func setPath(target interface{}, path string, value interface{}) error {
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	current := reflect.ValueOf(target).Elem()
+	for position, segment := range segments {
+		if current.Kind() != reflect.Struct {
+			return fmt.Errorf("\"%s\" of path \"%s\" is not an object", segment.name, path)
+		}
+		field, ok := fieldByJSONName(current, segment.name)
+		if !ok {
+			return fmt.Errorf("unknown field \"%s\" in path \"%s\"", segment.name, path)
+		}
+		if segment.index != -1 {
+			if field.Kind() != reflect.Slice {
+				return fmt.Errorf("\"%s\" of path \"%s\" is not a list", segment.name, path)
+			}
+			for field.Len() <= segment.index {
+				field.Set(reflect.Append(field, reflect.Zero(field.Type().Elem())))
+			}
+			field = field.Index(segment.index)
+		}
+		if position == len(segments)-1 {
+			converted := reflect.ValueOf(value)
+			if !converted.IsValid() {
+				field.Set(reflect.Zero(field.Type()))
+				return nil
+			}
+			if !converted.Type().AssignableTo(field.Type()) {
+				return fmt.Errorf("value of type %v cannot be assigned to \"%s\" of type %v", converted.Type(), path, field.Type())
+			}
+			field.Set(converted)
+		}
+		current = field
+	}
+	return nil
+}
+
+// Get returns the value at the path, e.g. "FunctionKeys[3].PhoneNumber" or "Sip[0].DisplayName". The fields
+// of the path are either their JSON names or their Go names. If the path points behind the end
+// of a list, nil is returned.
+func (p *Parameters) Get(path string) (interface{}, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := reflect.ValueOf(p).Elem()
+	for _, segment := range segments {
+		if current.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("\"%s\" of path \"%s\" is not an object", segment.name, path)
+		}
+		field, _, ok := fieldByName(current, segment.name)
+		if !ok {
+			return nil, fmt.Errorf("unknown field \"%s\" in path \"%s\"", segment.name, path)
+		}
+		if segment.index != -1 {
+			if field.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("\"%s\" of path \"%s\" is not a list", segment.name, path)
+			}
+			if segment.index >= field.Len() {
+				return nil, nil
+			}
+			field = field.Index(segment.index)
+		}
+		current = field
+	}
+	return current.Interface(), nil
+}
+
+// Set sets the setting at the path (see Get) to the value, which is converted to the type of the setting.
+// Lists of numbers or strings are given as comma separated values. Lists are extended with empty entries
+// if the path points behind their end.
+func (p *Parameters) Set(path string, value string) error {
+	resolved, fieldType, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+	converted, err := convertValue(value, fieldType, path)
+	if err != nil {
+		return err
+	}
+	return setPath(p, resolved, converted.Interface())
+}
+
+// resolvePath returns the path with the JSON names of its fields, as well as the type of the setting
+// the path points to.
+func resolvePath(path string) (string, reflect.Type, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return "", nil, err
+	}
+	resolved := make([]string, 0, len(segments))
+	current := reflect.New(reflect.TypeOf(Parameters{})).Elem()
+	for _, segment := range segments {
+		if current.Kind() != reflect.Struct {
+			return "", nil, fmt.Errorf("\"%s\" of path \"%s\" is not an object", segment.name, path)
+		}
+		field, jsonName, ok := fieldByName(current, segment.name)
+		if !ok {
+			return "", nil, fmt.Errorf("unknown field \"%s\" in path \"%s\"", segment.name, path)
+		}
+		if segment.index == -1 {
+			resolved = append(resolved, jsonName)
+		} else {
+			if field.Kind() != reflect.Slice {
+				return "", nil, fmt.Errorf("\"%s\" of path \"%s\" is not a list", segment.name, path)
+			}
+			resolved = append(resolved, fmt.Sprintf("%s[%d]", jsonName, segment.index))
+			field = reflect.New(field.Type().Elem()).Elem()
+		}
+		current = field
+	}
+	return strings.Join(resolved, "."), current.Type(), nil
+}
+
+func convertValue(value string, target reflect.Type, path string) (reflect.Value, error) {
+	switch target.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(value).Convert(target), nil
+	case reflect.Int:
+		converted, err := strconv.Atoi(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("value \"%s\" of \"%s\" is not an integer", value, path)
+		}
+		return reflect.ValueOf(converted).Convert(target), nil
+	case reflect.Slice:
+		result := reflect.MakeSlice(target, 0, 0)
+		if value == "" {
+			return result, nil
+		}
+		for _, part := range strings.Split(value, ",") {
+			entry, err := convertValue(strings.TrimSpace(part), target.Elem(), path)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result = reflect.Append(result, entry)
+		}
+		return result, nil
+	}
+	return reflect.Value{}, fmt.Errorf("\"%s\" is not a single setting", path)
+}
@@ -0,0 +1,54 @@
+package params
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParameters_Get(t *testing.T) {
+	parameters := Parameters{
+		TimeServer:         "ntp.example.com",
+		Backlight:          3,
+		Sip:                Sips{{DisplayName: "John"}},
+		CallDivertNoAnswer: []CallDivertNoAnswer{{CallDivertBusy: CallDivertBusy{Target: "30"}}},
+	}
+	for path, want := range map[string]interface{}{
+		"TimeServer":                   "ntp.example.com",
+		"Backlight":                    3,
+		"SIP[0].DisplayName":           "John",
+		"Sip[0].DisplayName":           "John",
+		"Sip[1].DisplayName":           nil,
+		"CallDivertNoAnswer[0].Target": "30",
+		"FunctionKeys[3].PhoneNumber":  nil,
+	} {
+		got, err := parameters.Get(path)
+		require.NoError(t, err, "no error expected for %s", path)
+		assert.Equal(t, want, got, "value of %s is wrong", path)
+	}
+
+	_, err := parameters.Get("Sip[0].Unknown")
+	assert.EqualError(t, err, "unknown field \"Unknown\" in path \"Sip[0].Unknown\"", "error is wrong")
+	_, err = parameters.Get("TimeServer.Name")
+	assert.EqualError(t, err, "\"Name\" of path \"TimeServer.Name\" is not an object", "error is wrong")
+}
+
+func TestParameters_Set(t *testing.T) {
+	parameters := Parameters{}
+	require.NoError(t, parameters.Set("Sip[1].DisplayName", "John"), "no error expected")
+	require.NoError(t, parameters.Set("Backlight", "4"), "no error expected")
+	require.NoError(t, parameters.Set("SelectedCodecs", "1, 2,3"), "no error expected")
+	require.NoError(t, parameters.Set("FunctionKeys[0].PhoneNumber", "20"), "no error expected")
+
+	want := Parameters{
+		Sip:            Sips{{}, {DisplayName: "John"}},
+		Backlight:      4,
+		SelectedCodecs: []int{1, 2, 3},
+		FunctionKeys:   FunctionKeys{{PhoneNumber: "20"}},
+	}
+	assert.Equal(t, want, parameters, "parameters are not set correctly")
+
+	assert.EqualError(t, parameters.Set("Backlight", "bright"), "value \"bright\" of \"Backlight\" is not an integer", "error is wrong")
+	assert.EqualError(t, parameters.Set("Sip[0]", "John"), "\"Sip[0]\" is not a single setting", "error is wrong")
+	assert.EqualError(t, parameters.Set("TimeServer[0]", "x"), "\"TimeServer\" of path \"TimeServer[0]\" is not a list", "error is wrong")
+}
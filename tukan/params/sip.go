@@ -0,0 +1,51 @@
+package params
+
+import (
+	"reflect"
+)
+
+// WithSlots returns a copy of the Sip entries which is extended with empty entries to at least the number of slots.
+func (s Sips) WithSlots(slots int) Sips {
+	if len(s) > slots {
+		slots = len(s)
+	}
+	sips := make(Sips, slots)
+	copy(sips, s)
+	return sips
+}
+
+// SipUpdateAccount returns a transformer for Sips#Transform which sets all fields of the account which are
+// not empty on the Sip entry at the slot. The other fields of the entry are kept.
+func SipUpdateAccount(slot int, account Sip) func(index int, sip *Sip) bool {
+	return func(index int, sip *Sip) bool {
+		if index != slot {
+			return false
+		}
+		updated := *sip
+		mergeStruct(reflect.ValueOf(&updated).Elem(), reflect.ValueOf(account))
+		if reflect.DeepEqual(updated, *sip) {
+			return false
+		}
+		*sip = updated
+		return true
+	}
+}
+
+// SipSetActive returns a transformer for Sips#Transform which enables or disables the Sip entries at the slots.
+func SipSetActive(active bool, slots ...int) func(index int, sip *Sip) bool {
+	return func(index int, sip *Sip) bool {
+		for _, slot := range slots {
+			if slot == index && sip.Active != BoolOf(active) {
+				sip.Active = BoolOf(active)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SipRotatePassword returns a transformer for Sips#Transform which sets the AuthenticationPassword
+// of the Sip entry at the slot.
+func SipRotatePassword(slot int, password string) func(index int, sip *Sip) bool {
+	return SipUpdateAccount(slot, Sip{AuthenticationPassword: password})
+}
@@ -0,0 +1,193 @@
+package params
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// DesiredState describes how the Parameters of telephones should look like. Parameters is
+// a partial document, i.e. only the fields which are set are enforced. The Overrides are applied
+// on top of the Parameters for all telephones carrying all tags of the respective override,
+// in the order in which they are listed. In JSON, a desired state looks like this:
+//
+//	{
+//	  "parameters": {"TimeFormat": "24h"},
+//	  "overrides": [{"tags": ["floor3"], "parameters": {"PhoneLanguage": "de"}}]
+//	}
+type DesiredState struct {
+	Parameters Parameters `json:"parameters"`
+	Overrides  []Override `json:"overrides,omitempty"`
+}
+
+// Override contains Parameters which only apply to telephones carrying all of the Tags.
+type Override struct {
+	Tags       []string   `json:"tags"`
+	Parameters Parameters `json:"parameters"`
+}
+
+// For returns the desired Parameters of a telephone carrying the passed tags.
+func (d *DesiredState) For(tags ...string) Parameters {
+	result := d.Parameters
+	for _, override := range d.Overrides {
+		if containsAll(tags, override.Tags) {
+			result = Merge(&result, &override.Parameters)
+		}
+	}
+	return result
+}
+
+func containsAll(tags []string, required []string) bool {
+	present := make(map[string]bool)
+	for _, tag := range tags {
+		present[tag] = true
+	}
+	for _, tag := range required {
+		if !present[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge returns a copy of the base Parameters in which all fields set in the overlay are replaced
+// by the overlay's values. Slices like FunctionKeys or Sip are merged entry by entry, the Unknown
+// settings name by name. Neither the base nor the overlay are altered.
+func Merge(base, overlay *Parameters) Parameters {
+	result := *base
+	mergeStruct(reflect.ValueOf(&result).Elem(), reflect.ValueOf(overlay).Elem())
+	return result
+}
+
+func mergeStruct(target, overlay reflect.Value) {
+	for index := 0; index < target.NumField(); index++ {
+		field := target.Field(index)
+		value := overlay.Field(index)
+		if !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.Struct:
+			mergeStruct(field, value)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			length := field.Len()
+			if value.Len() > length {
+				length = value.Len()
+			}
+			merged := reflect.MakeSlice(field.Type(), length, length)
+			reflect.Copy(merged, field)
+			for entry := 0; entry < value.Len(); entry++ {
+				mergeStruct(merged.Index(entry), value.Index(entry))
+			}
+			if length != 0 {
+				field.Set(merged)
+			}
+		case field.Kind() == reflect.Map:
+			if value.Len() == 0 {
+				continue
+			}
+			merged := reflect.MakeMap(field.Type())
+			for _, maps := range []reflect.Value{field, value} {
+				iterator := maps.MapRange()
+				for iterator.Next() {
+					merged.SetMapIndex(iterator.Key(), iterator.Value())
+				}
+			}
+			field.Set(merged)
+		case !value.IsZero() && !(value.Kind() == reflect.Slice && value.Len() == 0):
+			field.Set(value)
+		}
+	}
+}
+
+// Patch returns the smallest Parameters which turn the current Parameters into the template (see
+// DiffTemplate) when uploaded, together with the corresponding changes. Slice entries which do not
+// change are left empty in the patch, only their index is kept.
+func Patch(current, template *Parameters) (Parameters, []Change) {
+	changes := DiffTemplate(current, template)
+	patch := Parameters{}
+	for _, change := range changes {
+		// the paths and values stem from the same type, so setting them cannot fail
+		_ = setPath(&patch, change.Path, change.New)
+	}
+	return patch, changes
+}
+
+// Restore returns the Parameters which turn the current Parameters back into the snapshot when uploaded, together
+// with the corresponding changes (see Diff). Unlike Patch, fields which are empty in the snapshot are restored as well:
+// Because empty fields are omitted when Parameters are uploaded, the restored settings are put into Unknown with their
+// values written out, including empty ones. Slice entries which do not change are left empty, function keys which did
+// not exist in the snapshot are cleared (see ClearedKey).
+func Restore(current, snapshot *Parameters) (Parameters, []Change) {
+	changes := Diff(current, snapshot)
+	restore := Parameters{Unknown: make(map[string]json.RawMessage)}
+	currentValue, snapshotValue := reflect.ValueOf(current).Elem(), reflect.ValueOf(snapshot).Elem()
+	for _, change := range changes {
+		name := strings.SplitN(strings.SplitN(change.Path, ".", 2)[0], "[", 2)[0]
+		if _, ok := restore.Unknown[name]; ok {
+			continue
+		}
+		field, _ := fieldByJSONName(snapshotValue, name)
+		old, _ := fieldByJSONName(currentValue, name)
+		var value interface{}
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+			value = explicitEntries(old, field)
+		} else {
+			value = explicitValue(field)
+		}
+		// the values stem from Parameters, so marshalling them cannot fail
+		restore.Unknown[name], _ = json.Marshal(value)
+	}
+	return restore, changes
+}
+
+// explicitEntries returns the entries of the snapshot which differ from the current ones with all of their fields.
+func explicitEntries(current, snapshot reflect.Value) []interface{} {
+	length := current.Len()
+	if snapshot.Len() > length {
+		length = snapshot.Len()
+	}
+	entries := make([]interface{}, length)
+	for index := 0; index < length; index++ {
+		switch {
+		case index >= snapshot.Len() && snapshot.Type() == reflect.TypeOf(FunctionKeys{}):
+			entries[index] = explicitValue(reflect.ValueOf(ClearedKey))
+		case index >= snapshot.Len():
+			entries[index] = explicitValue(reflect.Zero(snapshot.Type().Elem()))
+		case index < current.Len() && reflect.DeepEqual(current.Index(index).Interface(), snapshot.Index(index).Interface()):
+			entries[index] = map[string]interface{}{}
+		default:
+			entries[index] = explicitValue(snapshot.Index(index))
+		}
+	}
+	return entries
+}
+
+// explicitValue returns the value in a form whose JSON contains all fields, including empty ones. Like encoding/json,
+// the fields of embedded structs are written as fields of the embedding struct, unless the latter has fields of the same name.
+func explicitValue(value reflect.Value) interface{} {
+	if value.Kind() != reflect.Struct {
+		return value.Interface()
+	}
+	fields := make(map[string]interface{})
+	embedded := make(map[string]interface{})
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.PkgPath != "" || jsonFieldName(field) == "-" {
+			continue
+		}
+		if _, tagged := field.Tag.Lookup("json"); field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			for name, fieldValue := range explicitValue(value.Field(index)).(map[string]interface{}) {
+				embedded[name] = fieldValue
+			}
+			continue
+		}
+		fields[jsonFieldName(field)] = explicitValue(value.Field(index))
+	}
+	for name, fieldValue := range embedded {
+		if _, ok := fields[name]; !ok {
+			fields[name] = fieldValue
+		}
+	}
+	return fields
+}
@@ -0,0 +1,111 @@
+package params
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := &Parameters{TimeFormat: "24h", PhoneLanguage: "en", Sip: Sips{{DisplayName: "Office", Domain: "example.com"}}}
+	overlay := &Parameters{PhoneLanguage: "de", Sip: Sips{{Domain: "example.org"}, {DisplayName: "Home"}}}
+
+	got := Merge(base, overlay)
+
+	want := Parameters{TimeFormat: "24h", PhoneLanguage: "de", Sip: Sips{{DisplayName: "Office", Domain: "example.org"}, {DisplayName: "Home"}}}
+	assert.Equal(t, want, got, "merged parameters are wrong")
+	assert.Equal(t, "example.com", base.Sip[0].Domain, "base must not be altered")
+}
+
+func TestDesiredState_For(t *testing.T) {
+	data := `{
+  "parameters": {"TimeFormat": "24h", "PhoneLanguage": "en"},
+  "overrides": [
+    {"tags": ["floor3"], "parameters": {"PhoneLanguage": "de"}},
+    {"tags": ["floor3", "sales"], "parameters": {"Backlight": 5}}
+  ]
+}`
+	state := DesiredState{}
+	require.NoError(t, json.Unmarshal([]byte(data), &state), "no error expected")
+
+	assert.Equal(t, Parameters{TimeFormat: "24h", PhoneLanguage: "en"}, state.For(), "without tags, only the base should be used")
+	assert.Equal(t, Parameters{TimeFormat: "24h", PhoneLanguage: "de"}, state.For("floor3"), "first override should be applied")
+	assert.Equal(t, Parameters{TimeFormat: "24h", PhoneLanguage: "de", Backlight: 5}, state.For("sales", "floor3"), "both overrides should be applied")
+}
+
+func TestPatch(t *testing.T) {
+	current := &Parameters{
+		TimeFormat:   "24h",
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: FunctionKeys{{DisplayName: "John", PhoneNumber: "20"}, {DisplayName: "Mary", PhoneNumber: "30"}},
+	}
+	template := &Parameters{
+		TimeFormat:   "24h",
+		FunctionKeys: FunctionKeys{{}, {DisplayName: "Linda"}, {DisplayName: "Hugh", PhoneNumber: "40"}},
+	}
+
+	patch, changes := Patch(current, template)
+
+	want := Parameters{FunctionKeys: FunctionKeys{{}, {DisplayName: "Linda"}, {DisplayName: "Hugh", PhoneNumber: "40"}}}
+	assert.Equal(t, want, patch, "patch is wrong")
+	assert.Equal(t, 2, len(changes), "number of changes is wrong")
+
+	patch, changes = Patch(current, &Parameters{TimeFormat: "24h"})
+	assert.Equal(t, Parameters{}, patch, "patch should be empty")
+	assert.Empty(t, changes, "no changes expected")
+}
+
+func TestSetPath(t *testing.T) {
+	parameters := Parameters{}
+	require.NoError(t, setPath(&parameters, "CallDivertNoAnswer[1].Target", "30"), "no error expected")
+	assert.Equal(t, []CallDivertNoAnswer{{}, {CallDivertBusy: CallDivertBusy{Target: "30"}}}, parameters.CallDivertNoAnswer, "value is not set correctly")
+
+	assert.EqualError(t, setPath(&parameters, "Unknown", "x"), "unknown field \"Unknown\" in path \"Unknown\"", "error is wrong")
+	assert.EqualError(t, setPath(&parameters, "TimeFormat[0]", "x"), "\"TimeFormat\" of path \"TimeFormat[0]\" is not a list", "error is wrong")
+	assert.EqualError(t, setPath(&parameters, "Backlight", "x"), "value of type string cannot be assigned to \"Backlight\" of type int", "error is wrong")
+	assert.EqualError(t, setPath(&parameters, "SIP[x]", "x"), "index of \"SIP[x]\" must be a non-negative integer", "error is wrong")
+}
+
+func TestRestore(t *testing.T) {
+	current := &Parameters{
+		TimeServer:   "ntp.example.com",
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: FunctionKeys{{DisplayName: "John", PhoneNumber: "20"}, {Type: KeyTypeSpeedDial, DisplayName: "Mary", PhoneNumber: "30"}},
+	}
+	snapshot := &Parameters{
+		PhoneModel:   "Phone ABC",
+		FunctionKeys: FunctionKeys{{DisplayName: "John", PhoneNumber: "20"}},
+	}
+
+	restore, changes := Restore(current, snapshot)
+
+	assert.Equal(t, 2, len(changes), "number of changes is wrong")
+	data, err := json.Marshal(restore.WithoutMetadata())
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, string(data), `"TimeServer":""`, "empty field should be restored")
+	assert.Contains(t, string(data), `"FunctionKeys":[{},{`, "unchanged key should be left empty")
+	assert.Contains(t, string(data), `"Type":"-1"`, "key which did not exist should be cleared")
+	assert.NotContains(t, string(data), "PhoneModel", "unchanged fields must not be restored")
+
+	restored := Parameters{}
+	require.NoError(t, json.Unmarshal(data, &restored), "no error expected")
+	assert.Equal(t, "", restored.TimeServer, "time server should be empty")
+	assert.Equal(t, ClearedKey.Type, restored.FunctionKeys[1].Type, "second key should be cleared")
+}
+
+func TestRestore_EmbeddedFields(t *testing.T) {
+	current := &Parameters{CallDivertNoAnswer: []CallDivertNoAnswer{{CallDivertBusy: CallDivertBusy{Target: "30"}, Delay: "10"}}}
+	snapshot := &Parameters{CallDivertNoAnswer: []CallDivertNoAnswer{{Delay: "10"}}}
+
+	restore, _ := Restore(current, snapshot)
+
+	data, err := json.Marshal(restore.WithoutMetadata())
+	require.NoError(t, err, "no error expected")
+	assert.Contains(t, string(data), `"Target":""`, "fields of embedded structs should be written flat")
+	assert.NotContains(t, string(data), `"CallDivertBusy"`, "embedded struct must not be written as object")
+
+	restored := Parameters{}
+	require.NoError(t, json.Unmarshal(data, &restored), "no error expected")
+	assert.Equal(t, snapshot.CallDivertNoAnswer, restored.CallDivertNoAnswer, "call divert should be restored")
+}
@@ -0,0 +1,52 @@
+package params
+
+import (
+	"reflect"
+)
+
+// MapStrings returns a copy of the parameters in which every string setting that is set, including those
+// of FunctionKeys, Sip and the other lists, is replaced by the result of the mapping. It is used to expand
+// templated values for every phone. The first error of the mapping is returned.
+func (p Parameters) MapStrings(mapping func(value string) (string, error)) (Parameters, error) {
+	result := p
+	err := mapStrings(reflect.ValueOf(&result).Elem(), mapping)
+	return result, err
+}
+
+func mapStrings(value reflect.Value, mapping func(string) (string, error)) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		for index := 0; index < value.NumField(); index++ {
+			if !value.Field(index).CanSet() {
+				continue
+			}
+			err := mapStrings(value.Field(index), mapping)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if value.Len() == 0 || value.Type().Elem().Kind() != reflect.Struct && value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(copied, value)
+		for index := 0; index < copied.Len(); index++ {
+			err := mapStrings(copied.Index(index), mapping)
+			if err != nil {
+				return err
+			}
+		}
+		value.Set(copied)
+	case reflect.String:
+		if value.String() == "" {
+			return nil
+		}
+		mapped, err := mapping(value.String())
+		if err != nil {
+			return err
+		}
+		value.SetString(mapped)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package params
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParameters_MapStrings(t *testing.T) {
+	parameters := Parameters{TimeServer: "ntp", Backlight: 3, Sip: Sips{{DisplayName: "John"}, {}}}
+
+	got, err := parameters.MapStrings(func(value string) (string, error) {
+		return strings.ToUpper(value), nil
+	})
+
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, Parameters{TimeServer: "NTP", Backlight: 3, Sip: Sips{{DisplayName: "JOHN"}, {}}}, got, "strings are not mapped correctly")
+	assert.Equal(t, "John", parameters.Sip[0].DisplayName, "original must not be altered")
+
+	_, err = parameters.MapStrings(func(value string) (string, error) {
+		return "", fmt.Errorf("mapping of %s failed", value)
+	})
+	assert.EqualError(t, err, "mapping of John failed", "error is wrong")
+}
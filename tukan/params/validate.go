@@ -0,0 +1,263 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// FieldError describes a setting with an invalid value. Path is the path of the setting (see Change).
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", f.Path, f.Message)
+}
+
+// ValidationError contains all invalid settings found by Validate.
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, field := range v {
+		messages = append(messages, field.Error())
+	}
+	return fmt.Sprintf("invalid parameters: %s", strings.Join(messages, "; "))
+}
+
+var hostnamePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+type validator struct {
+	errors ValidationError
+}
+
+func (v *validator) fail(name string, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Path: name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) port(name string, value int) {
+	if value != 0 && (value < 1 || value > 65535) {
+		v.fail(name, "port %d is not between 1 and 65535", value)
+	}
+}
+
+func (v *validator) vlan(name string, value int) {
+	if value != 0 && (value < 1 || value > 4094) {
+		v.fail(name, "VLAN ID %d is not between 1 and 4094", value)
+	}
+}
+
+func (v *validator) ipv4(name string, value string) {
+	if value != "" && (net.ParseIP(value) == nil || net.ParseIP(value).To4() == nil) {
+		v.fail(name, "\"%s\" is not an IPv4 address", value)
+	}
+}
+
+func (v *validator) host(name string, value string) {
+	if value == "" {
+		return
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if net.ParseIP(host) == nil && !hostnamePattern.MatchString(value) {
+		v.fail(name, "\"%s\" is neither an IP address nor a host name", value)
+	}
+}
+
+func (v *validator) oneOf(name string, value string, allowed ...string) {
+	if value != "" && !contains(allowed, value) {
+		v.fail(name, "\"%s\" is not allowed, must be one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+var boolType = reflect.TypeOf(Bool(""))
+
+// flags checks all settings of type Bool in the struct.
+func (v *validator) flags(structValue interface{}) {
+	value := reflect.ValueOf(structValue)
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.Type != boolType {
+			continue
+		}
+		v.oneOf(jsonFieldName(field), value.Field(index).String(), string(False), string(True))
+	}
+}
+
+func (v *validator) nested(name string, index int, errors ValidationError) {
+	for _, field := range errors {
+		v.errors = append(v.errors, FieldError{Path: fmt.Sprintf("%s[%d].%s", name, index, field.Path), Message: field.Message})
+	}
+}
+
+func (v *validator) result() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// Validate checks the parameters for values the phones do not accept, e.g. ports out of range, malformed
+// addresses, or flags other than "0" and "1". Empty settings are not checked, so that partial
+// parameters can be validated as well. The returned error is a ValidationError listing all invalid settings.
+func (p *Parameters) Validate() error {
+	v := &validator{}
+	v.port("HTTPPort", p.HTTPPort)
+	v.port("HTTPSPort", p.HTTPSPort)
+	v.port("LDAPServerPort", p.LDAPServerPort)
+	v.port("ProxyServerPort", p.ProxyServerPort)
+	v.port("SIPPort", p.SIPPort)
+	v.port("SIPRtpPort", p.SIPRtpPort)
+	v.port("SIPRtpRTCXRServerPort", p.SIPRtpRTCPXRServerPort)
+	v.vlan("VLANIdentifierLAN", p.VLANIdentifierLAN)
+	v.vlan("VLANIdentifierPC", p.VLANIdentifierPC)
+	v.oneOf("VLANPriorityLAN", p.VLANPriorityLAN, "0", "1", "2", "3", "4", "5", "6", "7")
+	v.oneOf("VLANPriorityDC", p.VLANPriorityPC, "0", "1", "2", "3", "4", "5", "6", "7")
+	v.ipv4("IPv4Address", p.IPv4Address)
+	v.ipv4("IPv4SubnetMask", p.IPv4SubnetMask)
+	v.ipv4("IPv4StandardGateway", p.IPv4StandardGateway)
+	v.ipv4("IPv4PreferredDNSServer", p.IPv4PreferredDNSServer)
+	v.ipv4("IPv4AlternateDNSServer", p.IPv4AlternateDNSServer)
+	v.host("LDAPServerAddress", p.LDAPServerAddress)
+	v.host("ProxyServerAddress", p.ProxyServerAddress)
+	v.host("SIPRtpRTCPXRServerAddress", p.SIPRtpRTCPXRServerAddress)
+	v.host("TimeServer", p.TimeServer)
+	v.flags(*p)
+	v.oneOf("SIPTransportProtocol", string(p.SIPTransportProtocol), string(TransportUDP), string(TransportTCP), string(TransportTLS))
+	v.oneOf("IPAddressType", string(p.IPAddressType), string(IPAddressTypeIPv4), string(IPAddressTypeIPv6), string(IPAddressTypeDualStack))
+	if p.ProxyServerActive.IsTrue() && p.ProxyServerAddress == "" {
+		v.fail("ProxyServerActive", "an active proxy server requires a ProxyServerAddress")
+	}
+	if p.LDAPEnable.IsTrue() && p.LDAPServerAddress == "" {
+		v.fail("LDAPEnable", "LDAP requires an LDAPServerAddress")
+	}
+	for index, sip := range p.Sip {
+		v.nested("SIP", index, validationErrors(sip.Validate()))
+	}
+	for index, key := range p.FunctionKeys {
+		v.nested("FunctionKeys", index, validationErrors(key.Validate()))
+	}
+	for index, plan := range p.DialingPlans {
+		v.nested("DialingPlans", index, validationErrors(plan.Validate()))
+	}
+	return v.result()
+}
+
+// Validate checks the SIP account like Parameters.Validate. Additionally, an active account needs
+// a registration server or a domain, and an enabled failover or STUN server needs an address.
+func (s *Sip) Validate() error {
+	v := &validator{}
+	v.port("FailoverServerPort", s.FailoverServerPort)
+	v.port("OutboundProxyPort", s.OutboundProxyPort)
+	v.port("ProxyServerPort", s.ProxyServerPort)
+	v.port("RegistrationSeverPort", s.RegistrationServerPort)
+	v.port("STUNServerPort", s.STUNServerPort)
+	v.host("FailoverServerAddress", s.FailoverServerAddress)
+	v.host("OutboundProxyAddress", s.OutboundProxyAddress)
+	v.host("ProxyServerAddress", s.ProxyServerAddress)
+	v.host("RegistrationServerAddress", s.RegistrationServerAddress)
+	v.host("STUNServerAddress", s.STUNServerAddress)
+	v.flags(*s)
+	v.oneOf("DTMFTransmission", string(s.DTMFTransmission), string(DTMFInBand), string(DTMFRFC2833), string(DTMFSIPInfo))
+	if s.Active.IsTrue() && s.RegistrationServerAddress == "" && s.Domain == "" {
+		v.fail("Active", "an active account requires a RegistrationServerAddress or a Domain")
+	}
+	if s.FailoverServerEnabled.IsTrue() && s.FailoverServerAddress == "" {
+		v.fail("FailoverServerEnabled", "an enabled failover server requires a FailoverServerAddress")
+	}
+	if s.STUNEnabled.IsTrue() && s.STUNServerAddress == "" {
+		v.fail("STUNEnabled", "STUN requires a STUNServerAddress")
+	}
+	return v.result()
+}
+
+// Validate checks the function key like Parameters.Validate.
+func (f *FunctionKey) Validate() error {
+	v := &validator{}
+	v.flags(*f)
+	return v.result()
+}
+
+// Validate checks the dialing plan like Parameters.Validate.
+func (d *DialingPlan) Validate() error {
+	v := &validator{}
+	v.flags(*d)
+	return v.result()
+}
+
+func validationErrors(err error) ValidationError {
+	if err == nil {
+		return nil
+	}
+	return err.(ValidationError)
+}
+
+// ValidatePatch validates the parameters which result from uploading the patch to the phone that
+// sent these parameters. Only invalid settings which the patch changes are reported, so that settings the
+// phone already had are not in the way of unrelated changes.
+func (p *Parameters) ValidatePatch(patch *Parameters) error {
+	merged := Merge(p, patch)
+	err := merged.Validate()
+	if err == nil {
+		return nil
+	}
+	return onlyChanged(err.(ValidationError), DiffTemplate(p, patch))
+}
+
+// ValidateRestore validates the parameters which result from uploading the restore payload (see Restore) to the phone
+// that sent these parameters. Like ValidatePatch, only invalid settings which the payload changes are reported, where
+// a list entry counts as changed as a whole.
+func (p *Parameters) ValidateRestore(restore *Parameters) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	restored := Parameters{}
+	err = json.Unmarshal(data, &restored)
+	if err != nil {
+		return err
+	}
+	structValue := reflect.ValueOf(&restored).Elem()
+	for name, value := range restore.Unknown {
+		field, ok := fieldByJSONName(structValue, name)
+		if !ok {
+			continue
+		}
+		// entries of lists are decoded onto the current ones, so that empty entries keep them like on the phone
+		err = json.Unmarshal(value, field.Addr().Interface())
+		if err != nil {
+			return fmt.Errorf("could not read restored field \"%s\": %v", name, err)
+		}
+	}
+	err = restored.Validate()
+	if err == nil {
+		return nil
+	}
+	changes := Diff(p, &restored)
+	for index := range changes {
+		// changed list entries are uploaded with all of their fields
+		changes[index].Path = strings.SplitN(changes[index].Path, ".", 2)[0]
+	}
+	return onlyChanged(err.(ValidationError), changes)
+}
+
+// onlyChanged returns the errors of fields which are changed, either directly or as part of a changed list entry.
+func onlyChanged(errors ValidationError, changes []Change) error {
+	result := make(ValidationError, 0)
+	for _, field := range errors {
+		for _, change := range changes {
+			if field.Path == change.Path || strings.HasPrefix(field.Path, change.Path+".") {
+				result = append(result, field)
+				break
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package params
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParameters_Validate(t *testing.T) {
+	assert.NoError(t, (&Parameters{}).Validate(), "empty parameters should be valid")
+	valid := Parameters{
+		HTTPPort:                           8080,
+		VLANIdentifierLAN:                  4094,
+		IPv4Address:                        "10.20.30.40",
+		TimeServer:                         "ntp.example.com",
+		LDAPServerAddress:                  "[fd00::1]",
+		MenuNetwork:                        "1",
+		SIPTransportProtocol:               TransportTLS,
+		ProgrammableKeysDNDActionURLEnable: "http://pbx.example.com/dnd/on",
+		ProgrammableKeysDNDFACDisable:      "*79",
+		Sip:                                Sips{{Active: "1", Domain: "example.com"}, {FailoverServerEnabled: "1", FailoverServerAddress: "10.20.30.41"}},
+	}
+	assert.NoError(t, valid.Validate(), "parameters should be valid")
+
+	invalid := Parameters{
+		HTTPPort:          70000,
+		VLANIdentifierLAN: 9000,
+		IPv4Address:       "10.20.30",
+		TimeServer:        "ntp example",
+		MenuNetwork:       "yes",
+		LDAPEnable:        "1",
+		IPAddressType:     "3",
+		Sip:               Sips{{}, {Active: "1", FailoverServerEnabled: "1", RegistrationServerPort: 70000, DTMFTransmission: "4"}},
+		FunctionKeys:      FunctionKeys{{Silent: "2"}},
+	}
+	err := invalid.Validate()
+	assert.EqualError(t, err, "invalid parameters: "+
+		"HTTPPort: port 70000 is not between 1 and 65535; "+
+		"VLANIdentifierLAN: VLAN ID 9000 is not between 1 and 4094; "+
+		"IPv4Address: \"10.20.30\" is not an IPv4 address; "+
+		"TimeServer: \"ntp example\" is neither an IP address nor a host name; "+
+		"MenuNetwork: \"yes\" is not allowed, must be one of 0, 1; "+
+		"IPAddressType: \"3\" is not allowed, must be one of 0, 1, 2; "+
+		"LDAPEnable: LDAP requires an LDAPServerAddress; "+
+		"SIP[1].RegistrationSeverPort: port 70000 is not between 1 and 65535; "+
+		"SIP[1].DTMFTransmission: \"4\" is not allowed, must be one of 0, 1, 2; "+
+		"SIP[1].Active: an active account requires a RegistrationServerAddress or a Domain; "+
+		"SIP[1].FailoverServerEnabled: an enabled failover server requires a FailoverServerAddress; "+
+		"FunctionKeys[0].Silent: \"2\" is not allowed, must be one of 0, 1", "error is wrong")
+	assert.Equal(t, 12, len(err.(ValidationError)), "number of errors is wrong")
+}
+
+func TestParameters_Validate_Phone(t *testing.T) {
+	file, err := ioutil.ReadFile("../mock/mockdata/parameters.json")
+	require.NoError(t, err, "no error expected")
+	parameters := Parameters{}
+	require.NoError(t, json.Unmarshal(file, &parameters), "no error expected")
+	patch := &Parameters{Sip: Sips{{DisplayName: "John"}}, FunctionKeys: FunctionKeys{{DisplayName: "Mary", PhoneNumber: "20"}}}
+	assert.NoError(t, parameters.ValidatePatch(patch), "the settings of a real phone should not be in the way of changes")
+}
+
+func TestParameters_ValidatePatch(t *testing.T) {
+	current := &Parameters{Sip: Sips{{Active: "1", FailoverServerEnabled: "1", Domain: "example.com"}, {}}}
+
+	assert.NoError(t, current.ValidatePatch(&Parameters{Sip: Sips{{DisplayName: "John"}}}), "existing problems should not be reported")
+	assert.EqualError(t, current.ValidatePatch(&Parameters{HTTPPort: 0, Sip: Sips{{}, {Active: "1"}}}),
+		"invalid parameters: SIP[1].Active: an active account requires a RegistrationServerAddress or a Domain", "error is wrong")
+	assert.NoError(t, current.ValidatePatch(&Parameters{Sip: Sips{{}, {Active: "1", RegistrationServerAddress: "pbx.example.com"}}}), "patch should be valid")
+}
+
+func TestParameters_ValidateRestore(t *testing.T) {
+	current := &Parameters{TimeServer: "ntp.example.com", Sip: Sips{{Active: "1", Domain: "example.com"}, {FailoverServerEnabled: "1"}}}
+
+	snapshot := &Parameters{Sip: Sips{{Active: "1"}, {FailoverServerEnabled: "1"}}}
+	restore, _ := Restore(current, snapshot)
+	assert.EqualError(t, current.ValidateRestore(&restore),
+		"invalid parameters: SIP[0].Active: an active account requires a RegistrationServerAddress or a Domain", "cleared fields should be validated")
+
+	snapshot = &Parameters{Sip: Sips{{Active: "1", Domain: "example.com"}, {FailoverServerEnabled: "1"}}}
+	restore, _ = Restore(current, snapshot)
+	assert.NoError(t, current.ValidateRestore(&restore), "existing problems should not be reported")
+}
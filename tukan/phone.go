@@ -2,41 +2,90 @@ package tukan
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/fafeitsch/Tukan/tukan/params"
-	"math"
-	"net"
 	"net/http"
-	"strconv"
-	"strings"
 	"sync"
+	"time"
 )
 
 // A connector is used to obtain a login token from a telephone and
 // perform REST actions on the telephone. The connector can be used to either
 // connect to only one telephone or to a bunch of telephones at the same time.
+//
+// When running on a bunch of telephones, Parallel limits the number of telephones that are
+// processed at the same time; zero or a negative value means no limit. Additionally, SubnetParallel
+// limits the number of telephones processed at the same time within one subnet, where the subnet
+// is determined by the first SubnetPrefix bits of the telephone's IP address (24 for IPv4 and 64
+// for IPv6 if SubnetPrefix is zero). Telephones addressed by host name are their own subnet.
+//
+// The UserName and Password are used to log in to all telephones, except those
+// which have their own entry in Credentials, which maps addresses to credentials.
+//
+// Failed requests are repeated according to the Retry policy, both by the connector
+// and by the phones created by the connector.
+//
+// If ReadOnly is set, the phones created by the connector refuse all requests which would
+// change the telephone (see Phone#ReadOnly).
+//
+// Once the Stop channel is closed, no further telephones are processed. In contrast to
+// canceling the context of RunContext, the telephones being processed are finished normally.
 type Connector struct {
-	Client    *http.Client
-	UserName  string
-	Password  string
-	Addresses []string
+	Client         *http.Client
+	UserName       string
+	Password       string
+	Credentials    map[string]params.Credentials
+	Addresses      []string
+	Retry          RetryPolicy
+	Parallel       int
+	SubnetParallel int
+	SubnetPrefix   int
+	Stop           <-chan struct{}
+	ReadOnly       bool
 }
 
+// ErrStopped is reported to the login callback for telephones which have not been
+// processed because the Stop channel of the Connector was closed.
+var ErrStopped = errors.New("skipped because the run was stopped")
+
+// ErrReadOnly is returned by all methods of a read-only Phone which would change the telephone.
+var ErrReadOnly = errors.New("request refused because the phone is read-only")
+
 // Tries to log in to a specific telephone identified by its Address.
 // On success, returns a phone Client, otherwise, an error is returned.
 func (c *Connector) SingleConnect(address string) (*Phone, error) {
+	return c.SingleConnectContext(context.Background(), address)
+}
+
+// SingleConnectContext is like SingleConnect, but the login request is bound to the context.
+func (c *Connector) SingleConnectContext(ctx context.Context, address string) (*Phone, error) {
+	phone, _, err := c.login(ctx, address)
+	return phone, err
+}
+
+func (c *Connector) login(ctx context.Context, address string) (*Phone, int, error) {
 	url := fmt.Sprintf("%s/Login", address)
 	credentials := params.Credentials{
 		Login:    c.UserName,
 		Password: c.Password,
 	}
+	if own, ok := c.Credentials[address]; ok {
+		credentials = own
+	}
 	payload, _ := json.Marshal(credentials)
-	reader := bytes.NewBuffer(payload)
-	resp, err := c.Client.Post(url, "application/json", reader)
-	err = checkResponse(resp, err)
+	resp, attempts, err := c.Retry.do(ctx, c.Client, true, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Add("Content-Type", "application/json")
+		return request, nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, attempts, err
 	}
 	defer resp.Body.Close()
 	tokenResp := struct {
@@ -44,138 +93,233 @@ func (c *Connector) SingleConnect(address string) (*Phone, error) {
 	}{}
 	err = json.NewDecoder(resp.Body).Decode(&tokenResp)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal token from %s: %v", address, err)
+		return nil, attempts, &DecodeError{Address: address, Content: "token", Err: err}
 	}
 	return &Phone{
-		client:  c.Client,
-		token:   tokenResp.Token,
-		Address: address,
-	}, nil
-}
-
-// Expands IP Addresses. If an passed Address cannot be parsed, then it is returned as is.
-func ExpandAddresses(protocol string, addresses ...string) []string {
-	result := make([]string, 0, len(addresses))
-	for _, originalAddress := range addresses {
-		address, port, number := splitAddress(originalAddress)
-		created := CreateAddresses(protocol, address, port, number+1)
-		for _, expanded := range created {
-			result = append(result, expanded)
-		}
-		if len(created) == 0 {
-			result = append(result, originalAddress)
-		}
-	}
-	return result
-}
-
-func splitAddress(address string) (string, int, int) {
-	modifier := strings.Index(address, "+")
-	number := 0
-	if modifier != -1 {
-		number, _ = strconv.Atoi(address[modifier+1:])
-		number = int(math.Max(float64(number), 0))
-	} else {
-		modifier = len(address)
-	}
-	colon := strings.Index(address, ":")
-	port := 80
-	if colon != -1 {
-		port, _ = strconv.Atoi(address[colon+1 : modifier])
-	} else {
-		colon = modifier
-	}
-	return address[0:colon], port, number
-}
-
-// Creates number addresses to connect to phones. This first Address is given by startIp, which
-// is always inclusive. If the startIp is not a valid IP, then an empty slice is returned.
-// The resulting slice can be used in the MultipleConnect function of the Connector.
-//
-// Attention: This method does not contain any IP sub net logic, it just increments the ip addresses
-// "stupidly": IP addresses like 10.20.30.255 or 10.20.255.0 may occur, and depending on the subnet
-// these are valid host IP addresses or not. The method never returns syntactically wrong IP addresses.
-func CreateAddresses(protocol, startIp string, port, number int) []string {
-	incrementIp := func(ip net.IP) {
-		for j := len(ip) - 1; j >= 0; j-- {
-			ip[j]++
-			if ip[j] > 0 {
-				break
-			}
-		}
-	}
-	currentIp := net.ParseIP(startIp)
-	if currentIp == nil {
-		return []string{}
-	}
-	result := make([]string, 0, number)
-	for i := 0; i < number; i++ {
-		address := fmt.Sprintf("%s://%s:%d", protocol, currentIp.String(), port)
-		result = append(result, address)
-		incrementIp(currentIp)
-	}
-	return result
+		client:   c.Client,
+		retry:    c.Retry,
+		token:    tokenResp.Token,
+		Address:  address,
+		attempts: attempts,
+		readOnly: c.ReadOnly,
+	}, attempts, nil
 }
 
+// PhoneResult is the outcome of an action on a telephone. Attempts is the number
+// of requests needed for the action (see RetryPolicy), and Duration is the time the
+// action took; both are zero if they are unknown.
 type PhoneResult struct {
-	Address string
-	Error   error
+	Address  string
+	Error    error
+	Attempts int
+	Duration time.Duration
 }
 
 type ResultCallback func(p *PhoneResult)
 
 type PhoneAction func(p *Phone)
 
+// Run logs in to all Addresses of the Connector, performs the operation on every telephone
+// and logs out again. The callbacks are called with the result of the login and logout, respectively.
+// The telephones are processed concurrently by a pool of Parallel workers; additionally, at most
+// SubnetParallel telephones of the same subnet are processed at the same time (see Connector).
 func (c *Connector) Run(loginCallback ResultCallback, operation PhoneAction, logoutCallback ResultCallback) {
+	c.RunContext(context.Background(), loginCallback, operation, logoutCallback)
+}
+
+// RunContext is like Run, but stops as soon as the context is done: Telephones which have not been
+// logged in yet are reported to the login callback with the context's error, and pending login requests
+// are aborted. The operation should use the context for its requests, too.
+// Likewise, telephones which have not been logged in when the Stop channel is closed are reported with ErrStopped.
+// Telephones that have been logged in successfully are always logged out, even if the context is done.
+func (c *Connector) RunContext(ctx context.Context, loginCallback ResultCallback, operation PhoneAction, logoutCallback ResultCallback) {
+	workers := c.Parallel
+	if workers <= 0 || workers > len(c.Addresses) {
+		workers = len(c.Addresses)
+	}
+	limiter := newSubnetLimiter(c.SubnetParallel, c.SubnetPrefix)
+	jobs := make(chan job)
 	var wg sync.WaitGroup
-	for index, address := range c.Addresses {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(index int, address string) {
+		go func() {
 			defer wg.Done()
-			phone, err := c.SingleConnect(address)
-			loginCallback(&PhoneResult{Address: address, Error: err})
-			if err != nil || phone == nil {
-				return
+			for job := range jobs {
+				c.runSingle(ctx, job.address, loginCallback, operation, logoutCallback)
+				job.release()
+			}
+		}()
+	}
+	// every subnet is dispatched on its own, so that a worker never waits for a busy subnet
+	// while telephones of other subnets could be processed
+	var dispatchers sync.WaitGroup
+	for _, group := range limiter.groups(c.Addresses) {
+		dispatchers.Add(1)
+		go func(addresses []string) {
+			defer dispatchers.Done()
+			for _, address := range addresses {
+				c.dispatch(ctx, limiter, jobs, address, loginCallback)
 			}
-			defer func() {
-				err = phone.Logout()
-				logoutCallback(&PhoneResult{Address: address, Error: err})
-			}()
-			operation(phone)
-		}(index, address)
+		}(group)
 	}
+	dispatchers.Wait()
+	close(jobs)
 	wg.Wait()
 }
 
+type job struct {
+	address string
+	release func()
+}
+
+// dispatch waits for a free slot in the subnet of the address and passes the address to a worker.
+// If the run is stopped or the context is done before, the address is reported to the login callback.
+func (c *Connector) dispatch(ctx context.Context, limiter *subnetLimiter, jobs chan<- job, address string, loginCallback ResultCallback) {
+	if c.stopped() {
+		loginCallback(&PhoneResult{Address: address, Error: ErrStopped})
+		return
+	}
+	release, err := limiter.acquire(ctx, address)
+	if err != nil {
+		loginCallback(&PhoneResult{Address: address, Error: err})
+		return
+	}
+	select {
+	case jobs <- job{address: address, release: release}:
+	case <-ctx.Done():
+		release()
+		loginCallback(&PhoneResult{Address: address, Error: ctx.Err()})
+	case <-c.Stop:
+		release()
+		loginCallback(&PhoneResult{Address: address, Error: ErrStopped})
+	}
+}
+
+func (c *Connector) runSingle(ctx context.Context, address string, loginCallback ResultCallback, operation PhoneAction, logoutCallback ResultCallback) {
+	if ctx.Err() != nil {
+		loginCallback(&PhoneResult{Address: address, Error: ctx.Err()})
+		return
+	}
+	if c.stopped() {
+		loginCallback(&PhoneResult{Address: address, Error: ErrStopped})
+		return
+	}
+	start := time.Now()
+	phone, attempts, err := c.login(ctx, address)
+	loginCallback(&PhoneResult{Address: address, Error: err, Attempts: attempts, Duration: time.Since(start)})
+	if err != nil || phone == nil {
+		return
+	}
+	defer func() {
+		// the logout must not be bound to ctx, otherwise, no logout would happen after a cancellation
+		err = phone.Logout()
+		logoutCallback(phone.Result(err))
+	}()
+	operation(phone)
+}
+
+func (c *Connector) stopped() bool {
+	select {
+	case <-c.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
 // A phone represents a http Client that talks to exactly on
 // physical telephone. A phone needs to be created with a Connector (see example).
 // It is strongly recommended to defer calling the method Phone#Logout() because
 // most IP620/630 only allow one active token at a time.
 type Phone struct {
-	client  *http.Client
-	token   string
-	Address string
-	invalid bool
+	client   *http.Client
+	retry    RetryPolicy
+	token    string
+	Address  string
+	invalid  bool
+	attempts int
+	elapsed  time.Duration
+	readOnly bool
+	// number of function keys sent with the last downloaded parameters
+	functionKeys int
+}
+
+// ReadOnly returns true if the phone refuses all requests which would change the telephone,
+// that is all requests except GET requests and the logout. Such requests return ErrReadOnly
+// without contacting the telephone.
+func (p *Phone) ReadOnly() bool {
+	return p.readOnly
+}
+
+// FunctionKeys returns the number of function keys of the telephone, including the empty keys at the end which
+// DownloadParameters removes. It is known only after the parameters have been downloaded, otherwise, it is zero.
+func (p *Phone) FunctionKeys() int {
+	return p.functionKeys
+}
+
+// Attempts returns the number of attempts the last request to the telephone needed. It is zero after Result
+// has been called.
+func (p *Phone) Attempts() int {
+	return p.attempts
+}
+
+// Result creates a PhoneResult for the telephone with the passed error as well as with the attempts
+// and the duration of the last request. The attempts and the duration are only attached once, so that
+// a later result, e.g. for an error which was not caused by a request, does not carry them again.
+func (p *Phone) Result(err error) *PhoneResult {
+	result := &PhoneResult{Address: p.Address, Error: err, Attempts: p.attempts, Duration: p.elapsed}
+	p.attempts, p.elapsed = 0, 0
+	return result
+}
+
+// do sends an authorized request to the telephone and repeats it according to the retry policy.
+// Only GET requests are repeated after they have been sent.
+// If the returned error is nil, the response has a successful status code and its body must be closed.
+func (p *Phone) do(ctx context.Context, method string, path string, body []byte, contentType string) (*http.Response, error) {
+	p.attempts, p.elapsed = 0, 0
+	if p.readOnly && method != "GET" && path != "Logout" {
+		return nil, ErrReadOnly
+	}
+	url := fmt.Sprintf("%s/%s", p.Address, path)
+	start := time.Now()
+	resp, attempts, err := p.retry.do(ctx, p.client, method == "GET", func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			request.Header.Add("Content-Type", contentType)
+		}
+		request.Header.Add("Authorization", "Bearer "+p.token)
+		return request, nil
+	})
+	p.attempts = attempts
+	p.elapsed = time.Since(start)
+	return resp, err
 }
 
+// Resets the telephone to its factory settings. Because the telephone restarts
+// afterwards, the phone is logged out by this method.
 func (p *Phone) Reset() error {
-	url := fmt.Sprintf("%s/State", p.Address)
-	request, _ := http.NewRequest("POST", url, strings.NewReader("{\"System.Reset\":5}"))
-	request.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(request)
-	err = checkResponse(resp, err)
+	return p.ResetContext(context.Background())
+}
+
+// ResetContext is like Reset, but the requests are bound to the context.
+func (p *Phone) ResetContext(ctx context.Context) error {
+	resp, err := p.do(ctx, "POST", "State", []byte("{\"System.Reset\":5}"), "")
 	if err != nil {
 		return err
 	}
-	err = p.Logout()
+	_ = resp.Body.Close()
+	err = p.LogoutContext(ctx)
 	if err != nil {
 		return err
 	}
-	url = fmt.Sprintf("%s/State/.System.Reset", p.Address)
-	request, _ = http.NewRequest("GET", url, nil)
-	request.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err = p.client.Do(request)
-	return checkResponse(resp, err)
+	resp, err = p.do(ctx, "GET", "State/.System.Reset", nil, "")
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	return err
 }
 
 // Sends a logout request to the phone. If the request passes without error
@@ -183,12 +327,14 @@ func (p *Phone) Reset() error {
 // will most likely not work. If and error is returned, then the token stored
 // in this telephone may or may not be used again, depending on the error.
 func (p *Phone) Logout() error {
-	url := fmt.Sprintf("%s/Logout", p.Address)
-	request, _ := http.NewRequest("POST", url, nil)
-	request.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(request)
-	err = checkResponse(resp, err)
+	return p.LogoutContext(context.Background())
+}
+
+// LogoutContext is like Logout, but the request is bound to the context.
+func (p *Phone) LogoutContext(ctx context.Context) error {
+	resp, err := p.do(ctx, "POST", "Logout", nil, "")
 	if err == nil {
+		_ = resp.Body.Close()
 		p.token = ""
 	}
 	return err
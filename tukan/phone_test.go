@@ -1,11 +1,16 @@
 package tukan
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/params"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 )
 
@@ -41,30 +46,6 @@ func TestConnector_SingleConnect(t *testing.T) {
 	})
 }
 
-func ExampleCreateAddresses() {
-	addresses := CreateAddresses("http", "10.1.254.254", 8081, 3)
-	fmt.Printf("Length of addresses is %d.\n", len(addresses))
-	fmt.Printf("%s\n", addresses[0])
-	fmt.Printf("%s\n", addresses[1])
-	fmt.Printf("%s\n", addresses[2])
-	// Output: Length of addresses is 3.
-	// http://10.1.254.254:8081
-	// http://10.1.254.255:8081
-	// http://10.1.255.0:8081
-}
-
-func TestCreateAddresses(t *testing.T) {
-	t.Run("invalid Address", func(t *testing.T) {
-		addresses := CreateAddresses("http", "not an ip", 8080, 4)
-		assert.Empty(t, addresses, "addresses should be empty if the ip is not valid")
-	})
-	t.Run("success", func(t *testing.T) {
-		addresses := CreateAddresses("http", "10.1.254.253", 8081, 5)
-		want := []string{"http://10.1.254.253:8081", "http://10.1.254.254:8081", "http://10.1.254.255:8081", "http://10.1.255.0:8081", "http://10.1.255.1:8081"}
-		assert.Equal(t, want, addresses, "generated addresses not correct")
-	})
-}
-
 func TestPhone_Logout(t *testing.T) {
 	handler, _ := mock.CreatePhone(username, password)
 	server := httptest.NewServer(handler)
@@ -89,17 +70,80 @@ func TestPhone_Logout(t *testing.T) {
 	})
 }
 
-func ExampleExpandAddresses() {
-	addresses := ExpandAddresses("http", "127.0.0.1", "not an ip", "10.20.30.40+2", "20.20.20.20:8080+1", "30.30.30.30:1234")
-	for _, address := range addresses {
-		fmt.Printf("%s\n", address)
-	}
-	// Output: http://127.0.0.1:80
-	// not an ip
-	// http://10.20.30.40:80
-	// http://10.20.30.41:80
-	// http://10.20.30.42:80
-	// http://20.20.20.20:8080
-	// http://20.20.20.21:8080
-	// http://30.30.30.30:1234
+func TestConnector_RunContext(t *testing.T) {
+	handler, telephone := mock.CreatePhone(username, password)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Addresses: []string{server.URL}}
+	t.Run("canceled before login", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var login *PhoneResult
+		operation := func(p *Phone) { assert.Fail(t, "operation must not be called") }
+		connector.RunContext(ctx, func(p *PhoneResult) { login = p }, operation, func(p *PhoneResult) {})
+		require.NotNil(t, login, "login callback should have been called")
+		assert.Equal(t, context.Canceled, login.Error, "login should report the cancellation")
+		assert.Nil(t, telephone.Token, "telephone should not have been logged in")
+	})
+	t.Run("canceled during operation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var download, logout error
+		operation := func(p *Phone) {
+			cancel()
+			_, download = p.DownloadParametersContext(ctx)
+		}
+		connector.RunContext(ctx, func(p *PhoneResult) {}, operation, func(p *PhoneResult) { logout = p.Error })
+		assert.True(t, errors.Is(download, context.Canceled), "download should have been canceled")
+		assert.NoError(t, logout, "logout should happen despite the cancellation")
+		assert.Nil(t, telephone.Token, "telephone should have been logged out")
+	})
+}
+
+func TestConnector_Run_Stop(t *testing.T) {
+	handler1, _ := mock.CreatePhone(username, password)
+	server1 := httptest.NewServer(handler1)
+	defer server1.Close()
+	handler2, telephone2 := mock.CreatePhone(username, password)
+	server2 := httptest.NewServer(handler2)
+	defer server2.Close()
+	stop := make(chan struct{})
+	connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Addresses: []string{server1.URL, server2.URL}, Parallel: 1, Stop: stop}
+	logins := make(map[string]error)
+	logouts := make(map[string]error)
+	var mutex sync.Mutex
+	operation := func(p *Phone) { close(stop) }
+	connector.Run(func(p *PhoneResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logins[p.Address] = p.Error
+	}, operation, func(p *PhoneResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logouts[p.Address] = p.Error
+	})
+	assert.NoError(t, logins[server1.URL], "first telephone should have been logged in")
+	assert.NoError(t, logouts[server1.URL], "first telephone should have been logged out after the stop")
+	assert.Equal(t, ErrStopped, logins[server2.URL], "second telephone should have been skipped")
+	assert.Nil(t, telephone2.Token, "second telephone should not have been logged in")
+}
+
+func TestPhone_ReadOnly(t *testing.T) {
+	handler, telephone := mock.CreatePhone(username, password)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, ReadOnly: true}
+	phone, err := connector.SingleConnect(server.URL)
+	require.NoError(t, err, "login should be possible")
+	assert.True(t, phone.ReadOnly(), "phone should be read-only")
+
+	parameters, err := phone.DownloadParameters()
+	require.NoError(t, err, "downloading should be possible")
+	assert.Equal(t, ErrReadOnly, phone.UploadParameters(params.Parameters{PhoneModel: "changed"}), "uploading parameters should be refused")
+	assert.Equal(t, parameters.PhoneModel, telephone.Parameters.PhoneModel, "parameters must not be changed")
+	assert.Equal(t, ErrReadOnly, phone.UploadPhoneBook("<changed/>"), "uploading a phone book should be refused")
+	assert.Equal(t, ErrReadOnly, phone.Restore([]byte("changed")), "restoring should be refused")
+	assert.Equal(t, ErrReadOnly, phone.Reset(), "resetting should be refused")
+	assert.NoError(t, phone.Logout(), "logout should be possible")
+	assert.Nil(t, telephone.Token, "phone should be logged out")
 }
@@ -2,10 +2,11 @@ package tukan
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
+	"github.com/fafeitsch/Tukan/tukan/phonebook"
 	"math/rand"
-	"net/http"
 	"strings"
 )
 
@@ -22,7 +23,11 @@ Content-Type: text/xml
 // rather uploads it and leaves the parsing to the telephone.
 // If an error occurs, or the response does not carry a successful status, an non-nil error is returned.
 func (p *Phone) UploadPhoneBook(payload string) error {
-	url := fmt.Sprintf("%s/LocalPhonebook", p.Address)
+	return p.UploadPhoneBookContext(context.Background(), payload)
+}
+
+// UploadPhoneBookContext is like UploadPhoneBook, but the request is bound to the context.
+func (p *Phone) UploadPhoneBookContext(ctx context.Context, payload string) error {
 	var delimiter string
 	for ok := true; ok; ok = len(delimiter) == 0 || strings.Contains(payload, delimiter) {
 		randomBytes := make([]byte, 16)
@@ -30,34 +35,70 @@ func (p *Phone) UploadPhoneBook(payload string) error {
 		delimiter = hex.EncodeToString(randomBytes)
 	}
 	multipartFormData := fmt.Sprintf(payloadTemplate, delimiter, payload, delimiter)
-	req, _ := http.NewRequest("POST", url, strings.NewReader(multipartFormData))
-	req.Header.Add("Authorization", "Bearer "+p.token)
 	multipartHeader := fmt.Sprintf("multipart/form-data; boundary=%s", delimiter)
-	req.Header.Add("Content-Type", multipartHeader)
-	resp, err := p.client.Do(req)
+	resp, err := p.do(ctx, "POST", "LocalPhonebook", []byte(multipartFormData), multipartHeader)
 	if err == nil {
-		defer resp.Body.Close()
+		_ = resp.Body.Close()
 	}
-	err = checkResponse(resp, err)
 	return err
 }
 
 // Downloads the phone book from the telephone. In case of an error
 // the returned string is nil.
 func (p *Phone) DownloadPhoneBook() (*string, error) {
-	url := fmt.Sprintf("%s/SaveLocalPhonebook", p.Address)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("Authorization", "Bearer "+p.token)
-	resp, err := p.client.Do(req)
-	if err == nil {
-		defer resp.Body.Close()
-	}
-	err = checkResponse(resp, err)
+	return p.DownloadPhoneBookContext(context.Background())
+}
+
+// DownloadPhoneBookContext is like DownloadPhoneBook, but the request is bound to the context.
+func (p *Phone) DownloadPhoneBookContext(ctx context.Context) (*string, error) {
+	resp, err := p.do(ctx, "GET", "SaveLocalPhonebook", nil, "")
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	buf := new(bytes.Buffer)
 	_, _ = buf.ReadFrom(resp.Body)
 	result := buf.String()
 	return &result, nil
 }
+
+// DownloadLocalPhonebook downloads the phone book from the telephone and parses it (see phonebook.Parse).
+// An empty response is returned as empty phone book. If the phone book cannot be parsed, a DecodeError is returned.
+func (p *Phone) DownloadLocalPhonebook() (*phonebook.Phonebook, error) {
+	return p.DownloadLocalPhonebookContext(context.Background())
+}
+
+// DownloadLocalPhonebookContext is like DownloadLocalPhonebook, but the request is bound to the context.
+func (p *Phone) DownloadLocalPhonebookContext(ctx context.Context) (*phonebook.Phonebook, error) {
+	payload, err := p.DownloadPhoneBookContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(*payload) == "" {
+		return &phonebook.Phonebook{}, nil
+	}
+	book, err := phonebook.Parse([]byte(*payload))
+	if err != nil {
+		return nil, &DecodeError{Address: p.Address, Content: "phone book", Err: err}
+	}
+	return book, nil
+}
+
+// UploadLocalPhonebook validates the phone book (see phonebook.Phonebook.Validate) and uploads it to the telephone.
+// Invalid phone books are not uploaded; the returned error is a phonebook.ValidationError then.
+func (p *Phone) UploadLocalPhonebook(book *phonebook.Phonebook) error {
+	return p.UploadLocalPhonebookContext(context.Background(), book)
+}
+
+// UploadLocalPhonebookContext is like UploadLocalPhonebook, but the request is bound to the context.
+func (p *Phone) UploadLocalPhonebookContext(ctx context.Context, book *phonebook.Phonebook) error {
+	err := book.Validate()
+	if err != nil {
+		return err
+	}
+	payload, err := book.Serialize()
+	if err != nil {
+		return err
+	}
+	return p.UploadPhoneBookContext(ctx, string(payload))
+}
@@ -0,0 +1,426 @@
+package phonebook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Import contains the contacts read from a directory export, e.g. a CSV file or vCards of a groupware.
+// The numbers of the entries are still written as in the export; Phonebook normalizes them.
+type Import struct {
+	Entries []Imported
+	Skipped []Skipped
+}
+
+// Imported is an entry of an export together with the number of its record.
+type Imported struct {
+	Entry
+	Record int
+}
+
+// Skipped is a record of an export which does not become an entry, e.g. because it has no number.
+// Records are counted from 1; the header of a CSV file is not counted.
+type Skipped struct {
+	Record int
+	Name   string
+	Reason string
+}
+
+func (s Skipped) String() string {
+	if s.Name == "" {
+		return fmt.Sprintf("record %d: %s", s.Record, s.Reason)
+	}
+	return fmt.Sprintf("record %d (%s): %s", s.Record, s.Name, s.Reason)
+}
+
+// add appends the entry, or skips it if it has no name or no number.
+func (i *Import) add(record int, entry Entry) {
+	groups := make([]string, 0, len(entry.Groups))
+	seen := make(map[string]bool)
+	for _, group := range entry.Groups {
+		group = strings.TrimSpace(group)
+		if group != "" && !seen[group] {
+			seen[group] = true
+			groups = append(groups, group)
+		}
+	}
+	entry.Groups = groups
+	switch {
+	case entry.Name() == "":
+		i.Skipped = append(i.Skipped, Skipped{Record: record, Reason: "the entry has no name"})
+	case len(entry.Numbers) == 0:
+		i.Skipped = append(i.Skipped, Skipped{Record: record, Name: entry.Name(), Reason: "the entry has no number"})
+	default:
+		i.Entries = append(i.Entries, Imported{Entry: entry, Record: record})
+	}
+}
+
+// Phonebook normalizes the numbers of the entries (see Normalizer) and returns the phone book with all entries and
+// the groups they refer to. Entries with numbers which cannot be normalized are skipped. The returned skipped records
+// include those of the import and are sorted by record.
+func (i *Import) Phonebook(normalizer Normalizer) (*Phonebook, []Skipped) {
+	book := &Phonebook{}
+	skipped := append(make([]Skipped, 0, len(i.Skipped)), i.Skipped...)
+	known := make(map[string]bool)
+	for _, imported := range i.Entries {
+		entry := imported.Entry
+		entry.Numbers = make([]Number, 0, len(imported.Numbers))
+		var err error
+		for _, number := range imported.Numbers {
+			number.Number, err = normalizer.Normalize(number.Number)
+			if err != nil {
+				break
+			}
+			entry.Numbers = append(entry.Numbers, number)
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{Record: imported.Record, Name: entry.Name(), Reason: err.Error()})
+			continue
+		}
+		for _, group := range entry.Groups {
+			if !known[group] {
+				known[group] = true
+				book.Groups = append(book.Groups, Group{Name: group})
+			}
+		}
+		book.Entries = append(book.Entries, entry)
+	}
+	sort.SliceStable(skipped, func(a, b int) bool { return skipped[a].Record < skipped[b].Record })
+	return book, skipped
+}
+
+// The fields of a Mapping besides the number types.
+const (
+	FieldFirstName = "FirstName"
+	FieldLastName  = "LastName"
+	FieldCompany   = "Company"
+	FieldGroup     = "Group"
+)
+
+// Mapping assigns the columns of a CSV file to the fields of the entries. The keys are FieldFirstName, FieldLastName,
+// FieldCompany, FieldGroup and the number types, the values are the headers of the columns.
+type Mapping map[string]string
+
+// ParseMapping reads a mapping like "LastName=Surname,mobile=Cell Phone".
+func ParseMapping(value string) (Mapping, error) {
+	mapping := make(Mapping)
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		split := strings.SplitN(part, "=", 2)
+		field, column := strings.TrimSpace(split[0]), ""
+		if len(split) == 2 {
+			column = strings.TrimSpace(split[1])
+		}
+		if column == "" {
+			return nil, fmt.Errorf("the mapping \"%s\" has no column", part)
+		}
+		if !knownField(field) {
+			return nil, fmt.Errorf("the field \"%s\" is unknown, expected one of %s, %s, %s, %s, %s, %s, %s or %s", field,
+				FieldFirstName, FieldLastName, FieldCompany, FieldGroup, NumberOffice, NumberMobile, NumberHome, NumberOther)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+func knownField(field string) bool {
+	return field == FieldFirstName || field == FieldLastName || field == FieldCompany || field == FieldGroup || NumberType(field).Valid()
+}
+
+// ImportCSV reads contacts from a CSV file with a header. The columns are assigned to the fields by the mapping; fields
+// which are not mapped are read from columns named like the field, e.g. "LastName" or "mobile", if there are any.
+// Headers are compared case-insensitively. The separator is "," or ";", whichever the header contains more often.
+// Several groups in one column are separated by ";" or "|".
+func ImportCSV(reader io.Reader, mapping Mapping) (*Import, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV file: %v", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	header := string(data)
+	if index := strings.IndexByte(header, '\n'); index >= 0 {
+		header = header[:index]
+	}
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		csvReader.Comma = ';'
+	}
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV file: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the CSV file has no header")
+	}
+	columns, err := mapColumns(records[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+	result := &Import{}
+	for index, record := range records[1:] {
+		value := func(field string) string {
+			column, ok := columns[field]
+			if !ok || column >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[column])
+		}
+		entry := Entry{FirstName: value(FieldFirstName), LastName: value(FieldLastName), Company: value(FieldCompany)}
+		for _, numberType := range []NumberType{NumberOffice, NumberMobile, NumberHome, NumberOther} {
+			if number := value(string(numberType)); number != "" {
+				entry.Numbers = append(entry.Numbers, Number{Type: numberType, Number: number})
+			}
+		}
+		entry.Groups = strings.FieldsFunc(value(FieldGroup), func(r rune) bool { return r == ';' || r == '|' })
+		result.add(index+1, entry)
+	}
+	return result, nil
+}
+
+// mapColumns returns the indices of the columns of the fields. Mapped columns must exist.
+func mapColumns(header []string, mapping Mapping) (map[string]int, error) {
+	indices := make(map[string]int)
+	for index, name := range header {
+		indices[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	columns := make(map[string]int)
+	for _, field := range []string{FieldFirstName, FieldLastName, FieldCompany, FieldGroup, string(NumberOffice), string(NumberMobile), string(NumberHome), string(NumberOther)} {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		index, ok := indices[strings.ToLower(name)]
+		if !ok && mapped {
+			return nil, fmt.Errorf("the column \"%s\" mapped to %s does not exist", name, field)
+		}
+		if ok {
+			columns[field] = index
+		}
+	}
+	return columns, nil
+}
+
+// contentLine is a line of a vCard or an attribute of an LDIF record.
+type contentLine struct {
+	name   string
+	params []string
+	value  string
+}
+
+// unfold joins lines which are continued on the next line by a leading space or tab.
+func unfold(reader io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) != 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// ImportVCard reads contacts from vCards (versions 2.1 to 4.0). The names are taken from N, or from FN if N is empty,
+// the company from ORG, the groups from CATEGORIES and the numbers from TEL, whose types "work", "cell" and "home"
+// become office, mobile and home numbers.
+func ImportVCard(reader io.Reader) (*Import, error) {
+	lines, err := unfold(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read vCard file: %v", err)
+	}
+	result := &Import{}
+	var card []contentLine
+	record := 0
+	for number, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("could not read vCard file: line %d has no value", number+1)
+		}
+		names := strings.Split(split[0], ";")
+		name := strings.ToUpper(names[0])
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			name = name[dot+1:]
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(split[1], "VCARD"):
+			record++
+			card = make([]contentLine, 0)
+		case name == "END" && strings.EqualFold(split[1], "VCARD"):
+			if card == nil {
+				return nil, fmt.Errorf("could not read vCard file: line %d ends a vCard which was not begun", number+1)
+			}
+			result.add(record, vCardEntry(card))
+			card = nil
+		case card != nil:
+			card = append(card, contentLine{name: name, params: names[1:], value: split[1]})
+		}
+	}
+	if card != nil {
+		return nil, fmt.Errorf("could not read vCard file: the last vCard is not ended")
+	}
+	return result, nil
+}
+
+func vCardEntry(card []contentLine) Entry {
+	entry := Entry{}
+	var fullName string
+	for _, line := range card {
+		switch line.name {
+		case "N":
+			components := splitEscaped(line.value, ';')
+			entry.LastName = components[0]
+			if len(components) > 1 {
+				entry.FirstName = components[1]
+			}
+		case "FN":
+			fullName = unescape(line.value)
+		case "ORG":
+			entry.Company = splitEscaped(line.value, ';')[0]
+		case "CATEGORIES":
+			entry.Groups = append(entry.Groups, splitEscaped(line.value, ',')...)
+		case "TEL":
+			number := strings.TrimPrefix(unescape(line.value), "tel:")
+			if strings.TrimSpace(number) != "" {
+				entry.Numbers = append(entry.Numbers, Number{Type: vCardNumberType(line.params), Number: number})
+			}
+		}
+	}
+	if entry.FirstName == "" && entry.LastName == "" && fullName != entry.Company {
+		entry.LastName = fullName
+	}
+	return entry
+}
+
+func vCardNumberType(params []string) NumberType {
+	types := strings.ToLower(strings.Join(params, ";"))
+	switch {
+	case strings.Contains(types, "cell"):
+		return NumberMobile
+	case strings.Contains(types, "home"):
+		return NumberHome
+	case strings.Contains(types, "work"):
+		return NumberOffice
+	}
+	return NumberOther
+}
+
+// splitEscaped splits a vCard value at the separator unless it is escaped with a backslash,
+// and unescapes the components.
+func splitEscaped(value string, separator rune) []string {
+	components := make([]string, 0)
+	var current strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == separator:
+			components = append(components, unescape(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(components, unescape(current.String()))
+}
+
+func unescape(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
+
+// ImportLDIF reads contacts from an LDIF export of a directory server. The names are taken from givenName and sn, or
+// from cn if both are empty, the company from o, the groups from ou, and the numbers from telephoneNumber, mobile and
+// homePhone. Records without any of these attributes, like organizational units, are skipped.
+func ImportLDIF(reader io.Reader) (*Import, error) {
+	lines, err := unfold(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read LDIF file: %v", err)
+	}
+	result := &Import{}
+	record := make([]contentLine, 0)
+	count := 0
+	flush := func() {
+		if len(record) == 0 {
+			return
+		}
+		if len(record) != 1 || record[0].name != "version" {
+			count++
+			result.add(count, ldifEntry(record))
+		}
+		record = make([]contentLine, 0)
+	}
+	for number, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("could not read LDIF file: line %d has no value", number+1)
+		}
+		name := strings.ToLower(strings.SplitN(split[0], ";", 2)[0])
+		value := split[1]
+		if strings.HasPrefix(value, ":") {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("could not read LDIF file: line %d: %v", number+1, err)
+			}
+			value = string(decoded)
+		}
+		record = append(record, contentLine{name: name, value: strings.TrimSpace(value)})
+	}
+	flush()
+	return result, nil
+}
+
+func ldifEntry(record []contentLine) Entry {
+	entry := Entry{}
+	var commonName string
+	for _, line := range record {
+		switch line.name {
+		case "givenname":
+			entry.FirstName = line.value
+		case "sn", "surname":
+			entry.LastName = line.value
+		case "cn", "commonname":
+			commonName = line.value
+		case "o", "organizationname":
+			entry.Company = line.value
+		case "ou", "organizationalunitname":
+			entry.Groups = append(entry.Groups, line.value)
+		case "telephonenumber":
+			entry.Numbers = append(entry.Numbers, Number{Type: NumberOffice, Number: line.value})
+		case "mobile", "mobiletelephonenumber":
+			entry.Numbers = append(entry.Numbers, Number{Type: NumberMobile, Number: line.value})
+		case "homephone", "hometelephonenumber":
+			entry.Numbers = append(entry.Numbers, Number{Type: NumberHome, Number: line.value})
+		}
+	}
+	if entry.FirstName == "" && entry.LastName == "" {
+		entry.LastName = commonName
+	}
+	return entry
+}
@@ -0,0 +1,100 @@
+package phonebook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	data := "\xef\xbb\xbfVorname;Nachname;Firma;Telefon;Handy;Abteilung\n" +
+		"John;Doe;ACME;030 1234567;0170 1234567;Sales|Support\n" +
+		";;;030 7654321;;\n" +
+		"Mary;Major;;;;Sales\n" +
+		";;Pizza Service;0301111;;\n"
+	mapping, err := ParseMapping("FirstName=Vorname, LastName=Nachname,Company=Firma,office=Telefon,mobile=Handy,Group=Abteilung")
+	require.NoError(t, err, "no error expected")
+
+	result, err := ImportCSV(strings.NewReader(data), mapping)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 2, len(result.Entries), "number of entries is wrong")
+	john := result.Entries[0]
+	assert.Equal(t, 1, john.Record, "record is wrong")
+	assert.Equal(t, Entry{FirstName: "John", LastName: "Doe", Company: "ACME", Groups: []string{"Sales", "Support"},
+		Numbers: []Number{{Type: NumberOffice, Number: "030 1234567"}, {Type: NumberMobile, Number: "0170 1234567"}}}, john.Entry, "entry is wrong")
+	assert.Equal(t, "Pizza Service", result.Entries[1].Name(), "name of second entry is wrong")
+	assert.Equal(t, []Skipped{{Record: 2, Reason: "the entry has no name"}, {Record: 3, Name: "Major, Mary", Reason: "the entry has no number"}}, result.Skipped, "skipped records are wrong")
+
+	t.Run("default columns", func(t *testing.T) {
+		result, err := ImportCSV(strings.NewReader("lastname,mobile\nDoe,0170\n"), nil)
+		require.NoError(t, err, "no error expected")
+		require.Equal(t, 1, len(result.Entries), "number of entries is wrong")
+		assert.Equal(t, []Number{{Type: NumberMobile, Number: "0170"}}, result.Entries[0].Numbers, "numbers are wrong")
+	})
+	t.Run("missing column", func(t *testing.T) {
+		_, err := ImportCSV(strings.NewReader("LastName,Phone\n"), Mapping{"office": "Telephone"})
+		assert.EqualError(t, err, "the column \"Telephone\" mapped to office does not exist", "error message is wrong")
+	})
+}
+
+func TestParseMapping(t *testing.T) {
+	_, err := ParseMapping("fax=Fax")
+	assert.EqualError(t, err, "the field \"fax\" is unknown, expected one of FirstName, LastName, Company, Group, office, mobile, home or other", "error message is wrong")
+	_, err = ParseMapping("LastName")
+	assert.EqualError(t, err, "the mapping \"LastName\" has no column", "error message is wrong")
+}
+
+func TestImportVCard(t *testing.T) {
+	data := "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Doe;John;;;\r\nFN:John Doe\r\nORG:ACME\\, Inc.;Sales\r\n" +
+		"TEL;TYPE=WORK,VOICE:+49 30 1234567\r\nitem1.TEL;TYPE=CELL:0170\r\n 1234567\r\nCATEGORIES:Sales,Support\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\nVERSION:2.1\nFN:Mary Major\nTEL;HOME:030 7654321\nEND:VCARD\n" +
+		"BEGIN:VCARD\nVERSION:4.0\nFN:Nobody\nEMAIL:nobody@example.com\nEND:VCARD\n"
+
+	result, err := ImportVCard(strings.NewReader(data))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 2, len(result.Entries), "number of entries is wrong")
+	assert.Equal(t, Entry{FirstName: "John", LastName: "Doe", Company: "ACME, Inc.", Groups: []string{"Sales", "Support"},
+		Numbers: []Number{{Type: NumberOffice, Number: "+49 30 1234567"}, {Type: NumberMobile, Number: "01701234567"}}}, result.Entries[0].Entry, "first entry is wrong")
+	assert.Equal(t, Entry{LastName: "Mary Major", Groups: []string{}, Numbers: []Number{{Type: NumberHome, Number: "030 7654321"}}}, result.Entries[1].Entry, "second entry is wrong")
+	assert.Equal(t, []Skipped{{Record: 3, Name: "Nobody", Reason: "the entry has no number"}}, result.Skipped, "skipped records are wrong")
+
+	t.Run("not ended", func(t *testing.T) {
+		_, err := ImportVCard(strings.NewReader("BEGIN:VCARD\nFN:John\n"))
+		assert.EqualError(t, err, "could not read vCard file: the last vCard is not ended", "error message is wrong")
+	})
+}
+
+func TestImportLDIF(t *testing.T) {
+	data := "version: 1\n\n# people\ndn: ou=people,dc=example,dc=com\nobjectClass: organizationalUnit\nou: people\n\n" +
+		"dn: cn=John Doe,ou=people,dc=example,dc=com\ngivenName: John\nsn: Doe\nou: Sales\ntelephoneNumber: +49 30\n 1234567\nmobile: 0170 1234567\n\n" +
+		"dn: cn=Mary Major,ou=people,dc=example,dc=com\ncn:: TWFyeSBNYWpvcg==\nhomePhone: 030 7654321\n"
+
+	result, err := ImportLDIF(strings.NewReader(data))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 2, len(result.Entries), "number of entries is wrong")
+	assert.Equal(t, Entry{FirstName: "John", LastName: "Doe", Groups: []string{"Sales"},
+		Numbers: []Number{{Type: NumberOffice, Number: "+49 301234567"}, {Type: NumberMobile, Number: "0170 1234567"}}}, result.Entries[0].Entry, "first entry is wrong")
+	assert.Equal(t, 2, result.Entries[0].Record, "record is wrong")
+	assert.Equal(t, "Mary Major", result.Entries[1].Name(), "name should be taken from cn")
+	assert.Equal(t, []Skipped{{Record: 1, Reason: "the entry has no name"}}, result.Skipped, "skipped records are wrong")
+}
+
+func TestImport_Phonebook(t *testing.T) {
+	result := &Import{
+		Entries: []Imported{
+			{Record: 1, Entry: Entry{LastName: "Doe", Groups: []string{"Sales"}, Numbers: []Number{{Type: NumberOffice, Number: "030 1234567"}}}},
+			{Record: 3, Entry: Entry{LastName: "Major", Numbers: []Number{{Type: NumberOffice, Number: "20"}, {Type: NumberMobile, Number: "n/a"}}}},
+			{Record: 4, Entry: Entry{Company: "ACME", Groups: []string{"Sales", "Suppliers"}, Numbers: []Number{{Type: NumberOffice, Number: "1111"}}}},
+		},
+		Skipped: []Skipped{{Record: 2, Reason: "the entry has no name"}},
+	}
+	book, skipped := result.Phonebook(Normalizer{CountryCode: "49", AreaCode: "30", InternationalPrefix: "00", TrunkPrefix: "0", ExtensionLength: 2})
+	assert.Equal(t, []Group{{Name: "Sales"}, {Name: "Suppliers"}}, book.Groups, "groups are wrong")
+	require.Equal(t, 2, len(book.Entries), "number of entries is wrong")
+	assert.Equal(t, []Number{{Type: NumberOffice, Number: "+49301234567"}}, book.Entries[0].Numbers, "numbers of first entry are wrong")
+	assert.Equal(t, []Number{{Type: NumberOffice, Number: "+49301111"}}, book.Entries[1].Numbers, "numbers of second entry are wrong")
+	assert.Equal(t, []Skipped{{Record: 2, Reason: "the entry has no name"}, {Record: 3, Name: "Major", Reason: "the number \"n/a\" is not dialable"}}, skipped, "skipped records are wrong")
+	assert.NoError(t, book.Validate(), "imported phone book should be valid")
+	assert.Equal(t, "record 3 (Major): the number \"n/a\" is not dialable", skipped[1].String(), "string of skipped record is wrong")
+}
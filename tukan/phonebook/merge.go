@@ -0,0 +1,166 @@
+package phonebook
+
+import (
+	"strings"
+)
+
+// Overlay returns a phone book with the groups and entries of the base and of the overlays, which are put on top
+// of the base in order. Groups with the same name are only taken once. The attributes and unknown elements of the
+// base are kept. None of the passed phone books is changed. Usually, the result is deduplicated (see Deduplicate),
+// so that entries of the overlays replace entries of lower layers with the same numbers.
+func Overlay(base *Phonebook, overlays ...*Phonebook) *Phonebook {
+	result := &Phonebook{XMLName: base.XMLName, Attrs: base.Attrs, Unknown: base.Unknown}
+	known := make(map[string]bool)
+	for _, book := range append([]*Phonebook{base}, overlays...) {
+		for _, group := range book.Groups {
+			if !known[group.Name] {
+				known[group.Name] = true
+				result.Groups = append(result.Groups, group)
+			}
+		}
+		result.Entries = append(result.Entries, book.Entries...)
+	}
+	return result
+}
+
+// Deduplicate removes numbers which occur in several entries from all but the last of these entries. Entries without
+// numbers left are removed. Numbers are compared without spaces, dashes, slashes, dots and brackets. The number
+// of removed numbers is returned. The numbers of the entries are replaced, not changed.
+func (p *Phonebook) Deduplicate() int {
+	removed := 0
+	taken := make(map[string]bool)
+	entries := make([]Entry, 0, len(p.Entries))
+	for index := len(p.Entries) - 1; index >= 0; index-- {
+		entry := p.Entries[index]
+		numbers := make([]Number, 0, len(entry.Numbers))
+		for _, number := range entry.Numbers {
+			key := numberKey(number.Number)
+			if taken[key] {
+				removed++
+				continue
+			}
+			taken[key] = true
+			numbers = append(numbers, number)
+		}
+		if len(numbers) != 0 {
+			entry.Numbers = numbers
+			entries = append(entries, entry)
+		}
+	}
+	for left, right := 0, len(entries)-1; left < right; left, right = left+1, right-1 {
+		entries[left], entries[right] = entries[right], entries[left]
+	}
+	p.Entries = entries
+	return removed
+}
+
+// numberKey removes the formatting from the number, so that numbers can be compared.
+func numberKey(number string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" \t-/.()", r) {
+			return -1
+		}
+		return r
+	}, number)
+}
+
+// ConflictRule decides which entry keeps a number if an entry of a phone and an entry of the central directory have it.
+type ConflictRule string
+
+const (
+	// CentralWins removes the number from the entry of the phone.
+	CentralWins ConflictRule = "central"
+	// LocalWins removes the number from the entry of the central directory.
+	LocalWins ConflictRule = "local"
+	// KeepBoth keeps the number in both entries.
+	KeepBoth ConflictRule = "both"
+)
+
+// Valid returns true if the rule is one of the constants.
+func (c ConflictRule) Valid() bool {
+	return c == CentralWins || c == LocalWins || c == KeepBoth
+}
+
+// Merge merges the phone book of a phone with the central directory, so that contacts which were added on the phone
+// are kept. Entries of the phone which are equal to an entry of the central directory (see Remove) are copies of an
+// earlier upload and are replaced by the central directory. If a number occurs in the central directory as well as in
+// the remaining entries of the phone, the rule decides which entry keeps it; entries without numbers left are dropped.
+// The attributes and unknown elements of the phone's book are kept. The number of conflicting numbers is returned.
+// None of the passed phone books is changed.
+func Merge(central *Phonebook, local *Phonebook, rule ConflictRule) (*Phonebook, int) {
+	result := Overlay(local, central)
+	private := &Phonebook{Entries: local.Entries}
+	private.Remove(central)
+	centralNumbers := numberSet(central.Entries)
+	privateNumbers := numberSet(private.Entries)
+	conflicts := 0
+	for key := range privateNumbers {
+		if centralNumbers[key] {
+			conflicts++
+		}
+	}
+	switch rule {
+	case CentralWins:
+		result.Entries = append(append([]Entry{}, central.Entries...), withoutNumbers(private.Entries, centralNumbers)...)
+	case LocalWins:
+		result.Entries = append(withoutNumbers(central.Entries, privateNumbers), private.Entries...)
+	default:
+		result.Entries = append(append([]Entry{}, central.Entries...), private.Entries...)
+	}
+	return result, conflicts
+}
+
+// Remove removes the entries which are equal to an entry of the other phone book and returns how many were removed.
+// Entries are equal if they have the same name and the same numbers of the same types, regardless of the formatting
+// of the numbers. The entries are replaced, not changed.
+func (p *Phonebook) Remove(other *Phonebook) int {
+	keys := make(map[string]bool)
+	for _, entry := range other.Entries {
+		keys[entryKey(entry)] = true
+	}
+	entries := make([]Entry, 0, len(p.Entries))
+	for _, entry := range p.Entries {
+		if !keys[entryKey(entry)] {
+			entries = append(entries, entry)
+		}
+	}
+	removed := len(p.Entries) - len(entries)
+	p.Entries = entries
+	return removed
+}
+
+func entryKey(entry Entry) string {
+	key := entry.Name()
+	for _, number := range entry.Numbers {
+		key += "\x00" + string(number.Type) + ":" + numberKey(number.Number)
+	}
+	return key
+}
+
+func numberSet(entries []Entry) map[string]bool {
+	result := make(map[string]bool)
+	for _, entry := range entries {
+		for _, number := range entry.Numbers {
+			result[numberKey(number.Number)] = true
+		}
+	}
+	return result
+}
+
+// withoutNumbers returns copies of the entries without the numbers. Entries without numbers left are dropped.
+func withoutNumbers(entries []Entry, removed map[string]bool) []Entry {
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		numbers := make([]Number, 0, len(entry.Numbers))
+		for _, number := range entry.Numbers {
+			if !removed[numberKey(number.Number)] {
+				numbers = append(numbers, number)
+			}
+		}
+		if len(numbers) != 0 {
+			entry.Numbers = numbers
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package phonebook
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	base := &Phonebook{
+		Attrs:   testAttrs(),
+		Groups:  []Group{{Name: "Sales"}},
+		Entries: []Entry{{LastName: "Doe", Numbers: []Number{{Type: NumberOffice, Number: "+49 30 1234567"}, {Type: NumberMobile, Number: "+491701234567"}}}, {Company: "ACME", Numbers: []Number{{Number: "0301111"}}}},
+	}
+	overlay := &Phonebook{
+		Groups:  []Group{{Name: "Sales", Attrs: testAttrs()}, {Name: "Private"}},
+		Entries: []Entry{{LastName: "Doe", FirstName: "John", Numbers: []Number{{Type: NumberOffice, Number: "+49301234567"}}}, {LastName: "Mom", Numbers: []Number{{Number: "030-1111"}}}},
+	}
+	book := Overlay(base, overlay)
+	assert.Equal(t, base.Attrs, book.Attrs, "attributes of base should be kept")
+	assert.Equal(t, []Group{{Name: "Sales"}, {Name: "Private"}}, book.Groups, "groups are wrong")
+	require.Equal(t, 4, len(book.Entries), "number of entries is wrong")
+
+	removed := book.Deduplicate()
+	assert.Equal(t, 2, removed, "number of removed numbers is wrong")
+	assert.Equal(t, []Entry{
+		{LastName: "Doe", Numbers: []Number{{Type: NumberMobile, Number: "+491701234567"}}},
+		{LastName: "Doe", FirstName: "John", Numbers: []Number{{Type: NumberOffice, Number: "+49301234567"}}},
+		{LastName: "Mom", Numbers: []Number{{Number: "030-1111"}}},
+	}, book.Entries, "entries are wrong")
+	assert.Equal(t, []Number{{Type: NumberOffice, Number: "+49 30 1234567"}, {Type: NumberMobile, Number: "+491701234567"}}, base.Entries[0].Numbers, "base must not be changed")
+}
+
+func testAttrs() []xml.Attr {
+	return []xml.Attr{{Name: xml.Name{Local: "version"}, Value: "2"}}
+}
+
+func TestMerge(t *testing.T) {
+	central := &Phonebook{
+		Groups: []Group{{Name: "Sales"}},
+		Entries: []Entry{
+			{LastName: "Doe", Numbers: []Number{{Type: NumberOffice, Number: "+49301234567"}}, Groups: []string{"Sales"}},
+			{Company: "Reception", Numbers: []Number{{Type: NumberOffice, Number: "10"}, {Type: NumberOther, Number: "11"}}},
+		},
+	}
+	local := &Phonebook{
+		Attrs: testAttrs(),
+		Entries: []Entry{
+			{LastName: "Doe", Numbers: []Number{{Type: NumberOffice, Number: "+49 30 1234567"}}},
+			{LastName: "Mom", Numbers: []Number{{Type: NumberHome, Number: "0301111"}}},
+			{LastName: "Front desk", Numbers: []Number{{Type: NumberOffice, Number: "10"}}},
+		},
+	}
+	names := func(entries []Entry) []string {
+		result := make([]string, 0)
+		for _, entry := range entries {
+			result = append(result, entry.Name())
+		}
+		return result
+	}
+
+	t.Run("central wins", func(t *testing.T) {
+		book, conflicts := Merge(central, local, CentralWins)
+		assert.Equal(t, 1, conflicts, "number of conflicts is wrong")
+		assert.Equal(t, local.Attrs, book.Attrs, "attributes of phone should be kept")
+		assert.Equal(t, []Group{{Name: "Sales"}}, book.Groups, "groups are wrong")
+		assert.Equal(t, []string{"Doe", "Reception", "Mom"}, names(book.Entries), "entries are wrong")
+	})
+	t.Run("local wins", func(t *testing.T) {
+		book, conflicts := Merge(central, local, LocalWins)
+		assert.Equal(t, 1, conflicts, "number of conflicts is wrong")
+		assert.Equal(t, []string{"Doe", "Reception", "Mom", "Front desk"}, names(book.Entries), "entries are wrong")
+		assert.Equal(t, []Number{{Type: NumberOther, Number: "11"}}, book.Entries[1].Numbers, "conflicting number should be removed from central entry")
+		assert.Equal(t, 2, len(central.Entries[1].Numbers), "central directory must not be changed")
+	})
+	t.Run("keep both", func(t *testing.T) {
+		book, conflicts := Merge(central, local, KeepBoth)
+		assert.Equal(t, 1, conflicts, "number of conflicts is wrong")
+		assert.Equal(t, []string{"Doe", "Reception", "Mom", "Front desk"}, names(book.Entries), "entries are wrong")
+	})
+	assert.Equal(t, 3, len(local.Entries), "phone book of phone must not be changed")
+}
+
+func TestPhonebook_Remove(t *testing.T) {
+	book := &Phonebook{Entries: []Entry{
+		{LastName: "Doe", Numbers: []Number{{Type: NumberOffice, Number: "030 1234567"}}},
+		{LastName: "Doe", Numbers: []Number{{Type: NumberMobile, Number: "0301234567"}}},
+		{LastName: "Mom", Numbers: []Number{{Type: NumberHome, Number: "0301111"}}},
+	}}
+	previous := &Phonebook{Entries: []Entry{{LastName: "Doe", Numbers: []Number{{Type: NumberOffice, Number: "030-1234567"}}}}}
+	removed := book.Remove(previous)
+	assert.Equal(t, 1, removed, "number of removed entries is wrong")
+	assert.Equal(t, []Number{{Type: NumberMobile, Number: "0301234567"}}, book.Entries[0].Numbers, "entry with different type should be kept")
+	assert.Equal(t, 2, len(book.Entries), "number of entries is wrong")
+}
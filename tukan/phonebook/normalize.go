@@ -0,0 +1,49 @@
+package phonebook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Normalizer converts phone numbers as they are written in directories, e.g. "030 / 123 45-67" or "+49 (0)30 1234567",
+// into the international format "+49301234567".
+type Normalizer struct {
+	// CountryCode is the country calling code of the phones, e.g. "49" or "+49". Without it, numbers are only cleaned up.
+	CountryCode string
+	// AreaCode is the area code of the phones without trunk prefix, e.g. "30". It is prepended to local numbers.
+	AreaCode string
+	// InternationalPrefix is dialed before international numbers, usually "00".
+	InternationalPrefix string
+	// TrunkPrefix is dialed before national numbers, usually "0".
+	TrunkPrefix string
+	// ExtensionLength is the maximum length of internal extensions, which are kept as they are.
+	ExtensionLength int
+}
+
+// Normalize removes spaces, dashes, slashes, dots and brackets from the number and converts it into the
+// international format. Numbers which are international already, internal extensions and numbers with
+// "*" or "#" are only cleaned up. An error is returned if the number is not dialable.
+func (n Normalizer) Normalize(number string) (string, error) {
+	cleaned := strings.TrimSpace(number)
+	if strings.HasPrefix(cleaned, "+") {
+		cleaned = strings.Replace(cleaned, "(0)", "", 1)
+	}
+	cleaned = numberKey(cleaned)
+	if !numberPattern.MatchString(cleaned) {
+		return "", fmt.Errorf("the number \"%s\" is not dialable", number)
+	}
+	country := strings.TrimPrefix(n.CountryCode, "+")
+	switch {
+	case strings.HasPrefix(cleaned, "+"), strings.ContainsAny(cleaned, "*#"):
+		return cleaned, nil
+	case n.InternationalPrefix != "" && strings.HasPrefix(cleaned, n.InternationalPrefix):
+		return "+" + strings.TrimPrefix(cleaned, n.InternationalPrefix), nil
+	case country == "" || len(cleaned) <= n.ExtensionLength:
+		return cleaned, nil
+	case n.TrunkPrefix != "" && strings.HasPrefix(cleaned, n.TrunkPrefix):
+		return "+" + country + strings.TrimPrefix(cleaned, n.TrunkPrefix), nil
+	case n.AreaCode != "":
+		return "+" + country + strings.TrimPrefix(n.AreaCode, n.TrunkPrefix) + cleaned, nil
+	}
+	return cleaned, nil
+}
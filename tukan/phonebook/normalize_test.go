@@ -0,0 +1,38 @@
+package phonebook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	normalizer := Normalizer{CountryCode: "49", AreaCode: "030", InternationalPrefix: "00", TrunkPrefix: "0", ExtensionLength: 4}
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{number: "+49 (0)30 123 45-67", want: "+49301234567"},
+		{number: "0043 1 234567", want: "+431234567"},
+		{number: "030 / 1234567", want: "+49301234567"},
+		{number: "0170.1234567", want: "+491701234567"},
+		{number: "1234567", want: "+49301234567"},
+		{number: "2045", want: "2045"},
+		{number: "*8#20", want: "*8#20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			got, err := normalizer.Normalize(tt.number)
+			assert.NoError(t, err, "no error expected")
+			assert.Equal(t, tt.want, got, "number not normalized correctly")
+		})
+	}
+	t.Run("without country code", func(t *testing.T) {
+		got, err := Normalizer{InternationalPrefix: "00", TrunkPrefix: "0"}.Normalize("030 1234567")
+		assert.NoError(t, err, "no error expected")
+		assert.Equal(t, "0301234567", got, "number should only be cleaned up")
+	})
+	t.Run("not dialable", func(t *testing.T) {
+		_, err := normalizer.Normalize("ask reception")
+		assert.EqualError(t, err, "the number \"ask reception\" is not dialable", "error message is wrong")
+	})
+}
@@ -0,0 +1,152 @@
+// Package phonebook models the local phone book of the telephones, which they exchange as XML file
+// named LocalPhonebook.xml:
+//
+//	<?xml version="1.0" encoding="UTF-8"?>
+//	<LocalPhonebook>
+//	  <Group name="Sales"/>
+//	  <Entry>
+//	    <FirstName>John</FirstName>
+//	    <LastName>Doe</LastName>
+//	    <Company>ACME</Company>
+//	    <Number type="office">+49301234567</Number>
+//	    <Number type="mobile">+491701234567</Number>
+//	    <Group>Sales</Group>
+//	  </Entry>
+//	</LocalPhonebook>
+//
+// Elements and attributes which are not modelled are kept, so that a phone book downloaded from a phone
+// can be changed and uploaded again without losing anything.
+package phonebook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NumberType is the kind of a phone number, e.g. office or mobile.
+type NumberType string
+
+const (
+	NumberOffice NumberType = "office"
+	NumberMobile NumberType = "mobile"
+	NumberHome   NumberType = "home"
+	NumberOther  NumberType = "other"
+)
+
+// Valid returns true if the type is one of the constants.
+func (n NumberType) Valid() bool {
+	return n == NumberOffice || n == NumberMobile || n == NumberHome || n == NumberOther
+}
+
+// Phonebook is the local phone book of a telephone.
+type Phonebook struct {
+	XMLName xml.Name   `xml:"LocalPhonebook"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Groups  []Group    `xml:"Group"`
+	Entries []Entry    `xml:"Entry"`
+	Unknown []Element  `xml:",any"`
+}
+
+// Group is a group of entries, e.g. a department. Entries refer to groups by their name.
+type Group struct {
+	Name    string     `xml:"name,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Unknown []Element  `xml:",any"`
+}
+
+// Entry is a contact of the phone book with its numbers.
+type Entry struct {
+	Attrs     []xml.Attr `xml:",any,attr"`
+	FirstName string     `xml:"FirstName,omitempty"`
+	LastName  string     `xml:"LastName,omitempty"`
+	Company   string     `xml:"Company,omitempty"`
+	Numbers   []Number   `xml:"Number"`
+	Groups    []string   `xml:"Group"`
+	Unknown   []Element  `xml:",any"`
+}
+
+// Number is a phone number of an entry.
+type Number struct {
+	Type   NumberType `xml:"type,attr,omitempty"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Number string     `xml:",chardata"`
+}
+
+// Element is an XML element which is not modelled. It is kept verbatim.
+type Element struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",innerxml"`
+}
+
+// Name returns the name of the entry as it is shown by the phones: "Doe, John" or the company
+// if the entry has neither first nor last name.
+func (e *Entry) Name() string {
+	switch {
+	case e.FirstName != "" && e.LastName != "":
+		return e.LastName + ", " + e.FirstName
+	case e.FirstName != "" || e.LastName != "":
+		return e.LastName + e.FirstName
+	}
+	return e.Company
+}
+
+// Parse reads a phone book as returned by the telephones. Besides UTF-8, the encoding ISO-8859-1 is supported.
+func Parse(data []byte) (*Phonebook, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	book := &Phonebook{}
+	err := decoder.Decode(book)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse phone book: %v", err)
+	}
+	return book, nil
+}
+
+// Serialize writes the phone book as UTF-8 encoded XML, which the telephones accept as LocalPhonebook.xml.
+func (p *Phonebook) Serialize() ([]byte, error) {
+	data, err := xml.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize phone book: %v", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "latin-1":
+		return &latin1Reader{source: bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding \"%s\"", charset)
+}
+
+// latin1Reader converts ISO-8859-1 to UTF-8, in which the first 256 code points are the same.
+type latin1Reader struct {
+	source  *bufio.Reader
+	pending []byte
+}
+
+func (l *latin1Reader) Read(buffer []byte) (int, error) {
+	count := 0
+	for count < len(buffer) {
+		if len(l.pending) != 0 {
+			buffer[count] = l.pending[0]
+			l.pending = l.pending[1:]
+			count++
+			continue
+		}
+		value, err := l.source.ReadByte()
+		if err != nil {
+			if count > 0 {
+				return count, nil
+			}
+			return 0, err
+		}
+		l.pending = []byte(string(rune(value)))
+	}
+	return count, nil
+}
@@ -0,0 +1,73 @@
+package phonebook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testBook = `<?xml version="1.0" encoding="UTF-8"?>
+<LocalPhonebook version="2">
+  <Group name="Sales" ringtone="3"></Group>
+  <Entry>
+    <FirstName>John</FirstName>
+    <LastName>Doe</LastName>
+    <Number type="office">+49301234567</Number>
+    <Number type="mobile" speedDial="1">+491701234567</Number>
+    <Group>Sales</Group>
+    <Picture format="png">aGVsbG8=</Picture>
+  </Entry>
+  <Entry>
+    <Company>ACME</Company>
+    <Number type="office">0301111</Number>
+  </Entry>
+  <Settings><Sorting>last</Sorting></Settings>
+</LocalPhonebook>
+`
+
+func TestParse(t *testing.T) {
+	book, err := Parse([]byte(testBook))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, 1, len(book.Groups), "number of groups is wrong")
+	assert.Equal(t, "Sales", book.Groups[0].Name, "name of group is wrong")
+	require.Equal(t, 2, len(book.Entries), "number of entries is wrong")
+	john := book.Entries[0]
+	assert.Equal(t, "Doe, John", john.Name(), "name is wrong")
+	assert.Equal(t, []Number{{Type: NumberOffice, Number: "+49301234567"}, {Type: NumberMobile, Number: "+491701234567", Attrs: john.Numbers[1].Attrs}}, john.Numbers, "numbers are wrong")
+	assert.Equal(t, []string{"Sales"}, john.Groups, "groups are wrong")
+	require.Equal(t, 1, len(john.Unknown), "unknown element should be kept")
+	assert.Equal(t, "Picture", john.Unknown[0].XMLName.Local, "unknown element is wrong")
+	assert.Equal(t, "ACME", book.Entries[1].Name(), "company should be the name")
+	require.Equal(t, 1, len(book.Unknown), "unknown element should be kept")
+	assert.Equal(t, "<Sorting>last</Sorting>", book.Unknown[0].Content, "unknown content is wrong")
+
+	_, err = Parse([]byte("this is not a phone book"))
+	assert.EqualError(t, err, "could not parse phone book: EOF", "error is wrong")
+	_, err = Parse([]byte("<Phonebook></Phonebook>"))
+	assert.EqualError(t, err, "could not parse phone book: expected element type <LocalPhonebook> but have <Phonebook>", "error is wrong")
+}
+
+func TestPhonebook_Serialize(t *testing.T) {
+	book, err := Parse([]byte(testBook))
+	require.NoError(t, err, "no error expected")
+	data, err := book.Serialize()
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, testBook, string(data), "phone book should be serialized as it was parsed")
+
+	again, err := Parse(data)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, book, again, "phone book should survive a round trip")
+}
+
+func TestParse_Latin1(t *testing.T) {
+	data := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<LocalPhonebook><Entry><LastName>M\xfcller</LastName></Entry></LocalPhonebook>")
+	book, err := Parse(data)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, "Müller", book.Entries[0].LastName, "umlaut is wrong")
+}
+
+func TestEntry_Name(t *testing.T) {
+	assert.Equal(t, "Doe", (&Entry{LastName: "Doe", Company: "ACME"}).Name(), "last name is wrong")
+	assert.Equal(t, "John", (&Entry{FirstName: "John"}).Name(), "first name is wrong")
+	assert.Equal(t, "", (&Entry{}).Name(), "empty name is wrong")
+}
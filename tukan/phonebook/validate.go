@@ -0,0 +1,82 @@
+package phonebook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// numberPattern matches the numbers the telephones can dial: digits with an optional leading "+",
+// as well as "*" and "#" for service codes.
+var numberPattern = regexp.MustCompile(`^\+?[0-9*#]+$`)
+
+// FieldError describes an invalid part of a phone book. Path locates the part, e.g. "Entry[3].Number[0]".
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", f.Path, f.Message)
+}
+
+// ValidationError contains all problems found by Validate.
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, field := range v {
+		messages = append(messages, field.Error())
+	}
+	return fmt.Sprintf("invalid phone book: %s", strings.Join(messages, "; "))
+}
+
+// Validate checks the phone book against the schema the telephones expect before it is uploaded: Every group has
+// a unique name, every entry has a name or a company and at least one number, numbers are dialable and have a
+// known type or none, and entries only refer to existing groups. Numbers are checked without their formatting
+// (see Deduplicate), because the telephones accept and keep numbers like "030 / 123 45-67".
+// The returned error is a ValidationError listing all problems.
+func (p *Phonebook) Validate() error {
+	errors := make(ValidationError, 0)
+	fail := func(path string, format string, args ...interface{}) {
+		errors = append(errors, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	groups := make(map[string]bool)
+	for index, group := range p.Groups {
+		path := fmt.Sprintf("Group[%d]", index)
+		switch {
+		case strings.TrimSpace(group.Name) == "":
+			fail(path, "the group has no name")
+		case groups[group.Name]:
+			fail(path, "the group \"%s\" is defined twice", group.Name)
+		}
+		groups[group.Name] = true
+	}
+	for index, entry := range p.Entries {
+		path := fmt.Sprintf("Entry[%d]", index)
+		if strings.TrimSpace(entry.Name()) == "" {
+			fail(path, "the entry has neither a name nor a company")
+		}
+		if len(entry.Numbers) == 0 {
+			fail(path, "the entry has no number")
+		}
+		for position, number := range entry.Numbers {
+			numberPath := fmt.Sprintf("%s.Number[%d]", path, position)
+			if !numberPattern.MatchString(numberKey(strings.TrimSpace(number.Number))) {
+				fail(numberPath, "\"%s\" is not a dialable number", number.Number)
+			}
+			if number.Type != "" && !number.Type.Valid() {
+				fail(numberPath, "unknown type \"%s\", must be one of %s, %s, %s, %s", number.Type, NumberOffice, NumberMobile, NumberHome, NumberOther)
+			}
+		}
+		for position, group := range entry.Groups {
+			if !groups[group] {
+				fail(fmt.Sprintf("%s.Group[%d]", path, position), "the group \"%s\" is not defined", group)
+			}
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
@@ -0,0 +1,52 @@
+package phonebook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPhonebook_Validate(t *testing.T) {
+	book, err := Parse([]byte(testBook))
+	require.NoError(t, err, "no error expected")
+	assert.NoError(t, book.Validate(), "phone book should be valid")
+	assert.NoError(t, (&Phonebook{}).Validate(), "empty phone book should be valid")
+
+	invalid := Phonebook{
+		Groups: []Group{{Name: "Sales"}, {Name: "Sales"}, {}},
+		Entries: []Entry{
+			{FirstName: "John", Numbers: []Number{{Type: NumberOffice, Number: "030 12a4"}, {Type: "fax", Number: "*21#"}}, Groups: []string{"Sales", "Support"}},
+			{},
+		},
+	}
+	err = invalid.Validate()
+	assert.EqualError(t, err, "invalid phone book: "+
+		"Group[1]: the group \"Sales\" is defined twice; "+
+		"Group[2]: the group has no name; "+
+		"Entry[0].Number[0]: \"030 12a4\" is not a dialable number; "+
+		"Entry[0].Number[1]: unknown type \"fax\", must be one of office, mobile, home, other; "+
+		"Entry[0].Group[1]: the group \"Support\" is not defined; "+
+		"Entry[1]: the entry has neither a name nor a company; "+
+		"Entry[1]: the entry has no number", "error is wrong")
+	assert.Equal(t, 7, len(err.(ValidationError)), "number of errors is wrong")
+
+	lenient := Phonebook{Entries: []Entry{{LastName: "Doe", Numbers: []Number{{Number: " +49 (30) 123 45-67"}, {Type: NumberHome, Number: "030/1234.56"}}}}}
+	assert.NoError(t, lenient.Validate(), "formatted numbers and numbers without type should be valid")
+}
+
+func TestPhonebook_Validate_Phone(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "LocalPhonebook.xml"))
+	require.NoError(t, err, "no error expected")
+	book, err := Parse(data)
+	require.NoError(t, err, "no error expected")
+	assert.NoError(t, book.Validate(), "the phone book of a phone should be valid")
+
+	serialized, err := book.Serialize()
+	require.NoError(t, err, "no error expected")
+	again, err := Parse(serialized)
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, book, again, "phone book should survive a round trip")
+	assert.NoError(t, again.Validate(), "phone book should still be valid after the round trip")
+}
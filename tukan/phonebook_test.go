@@ -1,11 +1,15 @@
 package tukan
 
 import (
+	"errors"
 	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/fafeitsch/Tukan/tukan/phonebook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 )
 
@@ -46,3 +50,45 @@ func TestPhone_DownloadPhoneBook(t *testing.T) {
 		assert.Nil(t, book, "result should be nil in case of an error")
 	})
 }
+
+func TestPhone_LocalPhonebook(t *testing.T) {
+	handler, telephone := mock.CreatePhone(username, password)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	connector := Connector{Client: http.DefaultClient, UserName: username, Password: password}
+	phone, err := connector.SingleConnect(server.URL)
+	require.NoError(t, err, "no error expected")
+	defer func() { _ = phone.Logout() }()
+
+	book := &phonebook.Phonebook{Entries: []phonebook.Entry{{LastName: "Doe", Numbers: []phonebook.Number{{Type: phonebook.NumberOffice, Number: "20"}}}}}
+	require.NoError(t, phone.UploadLocalPhonebook(book), "no error expected")
+	downloaded, err := phone.DownloadLocalPhonebook()
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, book.Entries, downloaded.Entries, "entries should survive upload and download")
+
+	uploaded := telephone.Phonebook
+	invalid := &phonebook.Phonebook{Entries: []phonebook.Entry{{LastName: "Doe"}}}
+	err = phone.UploadLocalPhonebook(invalid)
+	assert.EqualError(t, err, "invalid phone book: Entry[0]: the entry has no number", "invalid phone book should be refused")
+	assert.Equal(t, uploaded, telephone.Phonebook, "invalid phone book must not be uploaded")
+
+	telephone.Phonebook = ""
+	downloaded, err = phone.DownloadLocalPhonebook()
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, 0, len(downloaded.Entries), "empty phone book should have no entries")
+
+	telephone.Phonebook = "no xml"
+	_, err = phone.DownloadLocalPhonebook()
+	var decodeError *DecodeError
+	assert.True(t, errors.As(err, &decodeError), "decode error expected")
+
+	captured, err := ioutil.ReadFile(filepath.Join("phonebook", "testdata", "LocalPhonebook.xml"))
+	require.NoError(t, err, "no error expected")
+	telephone.Phonebook = string(captured)
+	downloaded, err = phone.DownloadLocalPhonebook()
+	require.NoError(t, err, "no error expected")
+	assert.NoError(t, phone.UploadLocalPhonebook(downloaded), "the phone book of a phone should be uploaded again")
+	again, err := phone.DownloadLocalPhonebook()
+	require.NoError(t, err, "no error expected")
+	assert.Equal(t, downloaded, again, "phone book should survive the round trip")
+}
@@ -0,0 +1,88 @@
+package tukan
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// subnetLimiter restricts the number of telephones of the same subnet that are
+// processed concurrently. A limiter with a non-positive limit does not restrict anything.
+type subnetLimiter struct {
+	limit  int
+	prefix int
+	mutex  sync.Mutex
+	slots  map[string]chan struct{}
+}
+
+func newSubnetLimiter(limit int, prefix int) *subnetLimiter {
+	return &subnetLimiter{limit: limit, prefix: prefix, slots: make(map[string]chan struct{})}
+}
+
+// groups splits the addresses by subnet, keeping the order of the addresses. The groups are ordered
+// by their first address. If the limiter does not restrict anything, all addresses form one group.
+func (s *subnetLimiter) groups(addresses []string) [][]string {
+	if s.limit <= 0 {
+		return [][]string{addresses}
+	}
+	result := make([][]string, 0)
+	indices := make(map[string]int)
+	for _, address := range addresses {
+		key := subnet(address, s.prefix)
+		index, ok := indices[key]
+		if !ok {
+			index = len(result)
+			indices[key] = index
+			result = append(result, nil)
+		}
+		result[index] = append(result[index], address)
+	}
+	return result
+}
+
+// acquire blocks until the address may be processed and returns a function which must be
+// called when the processing is finished. If the context is done before, its error is returned.
+func (s *subnetLimiter) acquire(ctx context.Context, address string) (func(), error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if s.limit <= 0 {
+		return func() {}, nil
+	}
+	key := subnet(address, s.prefix)
+	s.mutex.Lock()
+	slot, ok := s.slots[key]
+	if !ok {
+		slot = make(chan struct{}, s.limit)
+		s.slots[key] = slot
+	}
+	s.mutex.Unlock()
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func subnet(address string, prefix int) string {
+	host := address
+	if parsed, err := url.Parse(address); err == nil && parsed.Host != "" {
+		host = parsed.Hostname()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		if prefix <= 0 || prefix > 32 {
+			prefix = 24
+		}
+		return ipv4.Mask(net.CIDRMask(prefix, 32)).String()
+	}
+	if prefix <= 0 || prefix > 128 {
+		prefix = 64
+	}
+	return ip.Mask(net.CIDRMask(prefix, 128)).String()
+}
@@ -0,0 +1,106 @@
+package tukan
+
+import (
+	"context"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnector_Run_Parallel(t *testing.T) {
+	addresses := make([]string, 0, 6)
+	for i := 0; i < 6; i++ {
+		handler, _ := mock.CreatePhone(username, password)
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		addresses = append(addresses, server.URL)
+	}
+	tests := []struct {
+		name           string
+		parallel       int
+		subnetParallel int
+		other          bool
+		want           int32
+	}{
+		{name: "unlimited", parallel: 0, subnetParallel: 0, want: 6},
+		{name: "parallel", parallel: 2, subnetParallel: 0, want: 2},
+		{name: "subnet", parallel: 4, subnetParallel: 1, want: 1},
+		{name: "other subnet", parallel: 2, subnetParallel: 1, other: true, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := addresses
+			if tt.other {
+				// host names are their own subnet, so that the last telephone must not wait for the others
+				addresses = append(append([]string{}, addresses[:2]...), strings.Replace(addresses[2], "127.0.0.1", "localhost", 1))
+			}
+			connector := Connector{
+				Client:         http.DefaultClient,
+				UserName:       username,
+				Password:       password,
+				Addresses:      addresses,
+				Parallel:       tt.parallel,
+				SubnetParallel: tt.subnetParallel,
+			}
+			var current, max int32
+			var mutex sync.Mutex
+			logins := make([]string, 0, len(addresses))
+			operation := func(p *Phone) {
+				now := atomic.AddInt32(&current, 1)
+				mutex.Lock()
+				if now > max {
+					max = now
+				}
+				mutex.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				atomic.AddInt32(&current, -1)
+			}
+			loginCallback := func(p *PhoneResult) {
+				assert.NoError(t, p.Error, "no error expected")
+				mutex.Lock()
+				logins = append(logins, p.Address)
+				mutex.Unlock()
+			}
+			connector.Run(loginCallback, operation, func(p *PhoneResult) {})
+			assert.ElementsMatch(t, addresses, logins, "every telephone should have been logged in")
+			assert.Equal(t, tt.want, max, "maximum number of concurrent operations is wrong")
+		})
+	}
+}
+
+func TestSubnet(t *testing.T) {
+	assert.Equal(t, "10.20.30.0", subnet("http://10.20.30.40:80", 0), "default IPv4 subnet is wrong")
+	assert.Equal(t, "10.20.0.0", subnet("http://10.20.30.40:80", 16), "IPv4 subnet is wrong")
+	assert.Equal(t, "fd00::", subnet("https://[fd00::1:2]:443", 0), "default IPv6 subnet is wrong")
+	assert.Equal(t, "phone.example.com", subnet("http://phone.example.com", 24), "host names should be their own subnet")
+}
+
+func TestSubnetLimiter_acquire(t *testing.T) {
+	limiter := newSubnetLimiter(1, 24)
+	release, err := limiter.acquire(context.Background(), "http://10.20.30.40")
+	assert.NoError(t, err, "no error expected")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "http://10.20.30.41")
+	assert.Equal(t, context.DeadlineExceeded, err, "acquire should stop waiting when the context is done")
+	other, err := limiter.acquire(ctx, "http://10.20.31.40")
+	assert.Error(t, err, "acquire should fail if the context is done")
+	assert.Nil(t, other, "release function should be nil in case of an error")
+	release()
+	release, err = limiter.acquire(context.Background(), "http://10.20.30.41")
+	assert.NoError(t, err, "slot should be free again")
+	release()
+}
+
+func TestSubnetLimiter_groups(t *testing.T) {
+	addresses := []string{"http://10.0.0.1", "http://10.0.1.1", "http://10.0.0.2"}
+	assert.Equal(t, [][]string{addresses}, newSubnetLimiter(0, 24).groups(addresses), "unlimited limiter should not group")
+	want := [][]string{{"http://10.0.0.1", "http://10.0.0.2"}, {"http://10.0.1.1"}}
+	assert.Equal(t, want, newSubnetLimiter(1, 24).groups(addresses), "groups are wrong")
+}
@@ -0,0 +1,69 @@
+package tukan
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy determines how often and how fast failed requests to a telephone are repeated.
+// Only transient failures are retried (see IsTransient). Permanent failures, like authentication
+// errors or other 4xx status codes, are never retried. Requests which change the telephone, like uploads
+// or a reset, are only retried if they failed before they were sent, e.g. because the connection was refused;
+// otherwise, the telephone might process them twice. The zero value does not retry at all.
+type RetryPolicy struct {
+	// Maximum number of attempts for a single request, including the first one.
+	MaxAttempts int
+	// Time to wait before the second attempt. The wait time doubles with every further attempt.
+	InitialBackoff time.Duration
+	// Upper bound for the time to wait between two attempts. Zero means no upper bound.
+	MaxBackoff time.Duration
+	// Fraction between 0 and 1 by which the wait time is randomly shortened, so that
+	// many telephones failing at the same time are not retried at the same time.
+	Jitter float64
+}
+
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := r.InitialBackoff
+	for i := 1; i < attempt && (r.MaxBackoff == 0 || delay < r.MaxBackoff); i++ {
+		delay = delay * 2
+	}
+	if r.MaxBackoff != 0 && delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	if r.Jitter > 0 {
+		delay = delay - time.Duration(rand.Float64()*r.Jitter*float64(delay))
+	}
+	return delay
+}
+
+// do sends the request created by newRequest and repeats it according to the policy as long
+// as it fails transiently. It returns the response of the last attempt, which has already been
+// checked with checkResponse, as well as the number of attempts made. If the returned error is
+// not nil, the response is nil, too. Requests which are not idempotent are only repeated if they
+// have not been sent.
+func (r *RetryPolicy) do(ctx context.Context, client *http.Client, idempotent bool, newRequest func() (*http.Request, error)) (*http.Response, int, error) {
+	attempt := 0
+	for {
+		attempt = attempt + 1
+		request, err := newRequest()
+		if err != nil {
+			return nil, attempt, err
+		}
+		resp, err := client.Do(request)
+		err = checkResponse(resp, err)
+		if err != nil && resp != nil {
+			_ = resp.Body.Close()
+			resp = nil
+		}
+		if err == nil || attempt >= r.MaxAttempts || !IsTransient(err) || (!idempotent && !isUnsent(err)) {
+			return resp, attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, attempt, ctx.Err()
+		case <-time.After(r.backoff(attempt)):
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tukan
+
+import (
+	"errors"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	handler, _ := mock.CreatePhone(username, password)
+	failures := 0
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = requests + 1
+		if failures > 0 {
+			failures = failures - 1
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("transient failures", func(t *testing.T) {
+		failures, requests = 2, 0
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Addresses: []string{server.URL}, Retry: policy}
+		var login, logout *PhoneResult
+		connector.Run(func(p *PhoneResult) { login = p }, func(p *Phone) {}, func(p *PhoneResult) { logout = p })
+		require.NotNil(t, login, "login callback should have been called")
+		assert.NoError(t, login.Error, "no error expected")
+		assert.Equal(t, 3, login.Attempts, "login should need three attempts")
+		require.NotNil(t, logout, "logout callback should have been called")
+		assert.Equal(t, 1, logout.Attempts, "logout should need one attempt")
+	})
+	t.Run("too many transient failures", func(t *testing.T) {
+		failures, requests = 3, 0
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Retry: policy}
+		phone, err := connector.SingleConnect(server.URL)
+		assert.EqualError(t, err, "unexpected status code: 503 with message \"503 Service Unavailable\"", "error message is wrong")
+		assert.Nil(t, phone, "phone should be nil in case of an error")
+		assert.Equal(t, 3, requests, "number of requests is wrong")
+	})
+	t.Run("permanent failure", func(t *testing.T) {
+		failures, requests = 0, 0
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: "wrong", Retry: policy}
+		_, err := connector.SingleConnect(server.URL)
+		assert.Error(t, err, "error expected")
+		assert.Equal(t, 1, requests, "authentication errors must not be retried")
+	})
+	t.Run("connection refused", func(t *testing.T) {
+		closed := httptest.NewServer(handler)
+		closed.Close()
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Addresses: []string{closed.URL}, Retry: policy}
+		var login *PhoneResult
+		connector.Run(func(p *PhoneResult) { login = p }, func(p *Phone) {}, func(p *PhoneResult) {})
+		require.NotNil(t, login, "login callback should have been called")
+		assert.Error(t, login.Error, "error expected")
+		assert.Equal(t, 3, login.Attempts, "refused connections should be retried")
+	})
+	t.Run("changing requests", func(t *testing.T) {
+		failures, requests = 0, 0
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Retry: policy}
+		phone, err := connector.SingleConnect(server.URL)
+		require.NoError(t, err, "no error expected")
+		failures, requests = 2, 0
+		err = phone.UploadPhoneBook("book")
+		assert.Error(t, err, "error expected")
+		assert.Equal(t, 1, requests, "sent uploads must not be retried")
+
+		failures, requests = 2, 0
+		_, err = phone.DownloadPhoneBook()
+		assert.NoError(t, err, "no error expected")
+		assert.Equal(t, 3, requests, "downloads should be retried")
+
+		closed := httptest.NewServer(handler)
+		closed.Close()
+		phone.Address = closed.URL
+		err = phone.UploadPhoneBook("book")
+		assert.Error(t, err, "error expected")
+		assert.Equal(t, 3, phone.Attempts(), "uploads should be retried if the connection was refused")
+	})
+	t.Run("result", func(t *testing.T) {
+		failures, requests = 0, 0
+		connector := Connector{Client: http.DefaultClient, UserName: username, Password: password, Retry: policy}
+		phone, err := connector.SingleConnect(server.URL)
+		require.NoError(t, err, "no error expected")
+		failures = 1
+		_, err = phone.DownloadPhoneBook()
+		require.NoError(t, err, "no error expected")
+		result := phone.Result(err)
+		assert.Equal(t, 2, result.Attempts, "attempts of the download are wrong")
+		assert.NotZero(t, result.Duration, "duration of the download is missing")
+		result = phone.Result(errors.New("local error"))
+		assert.Equal(t, 0, result.Attempts, "attempts must not be attached twice")
+		assert.Zero(t, result.Duration, "duration must not be attached twice")
+		phone.readOnly = true
+		err = phone.UploadPhoneBook("book")
+		assert.Equal(t, ErrReadOnly, err, "read-only error expected")
+		assert.Equal(t, 0, phone.Result(err).Attempts, "refused requests have no attempts")
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, policy.backoff(1), "first backoff is wrong")
+	assert.Equal(t, 2*time.Second, policy.backoff(2), "second backoff is wrong")
+	assert.Equal(t, 4*time.Second, policy.backoff(3), "third backoff is wrong")
+	assert.Equal(t, 5*time.Second, policy.backoff(4), "backoff should be limited")
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		got := policy.backoff(2)
+		assert.True(t, got > time.Second && got <= 2*time.Second, "backoff %v with jitter out of range", got)
+	}
+}
@@ -0,0 +1,98 @@
+package tukan
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TLSOptions describes how the certificates of telephones are verified if they are
+// reached via https. The zero value verifies the certificates against the system's root CAs.
+type TLSOptions struct {
+	// PEM encoded certificates which are trusted in addition to the system's root CAs.
+	RootCAs []byte
+	// Maps the address (host:port) of a telephone to the SHA-256 fingerprint of its certificate. An address
+	// without port, i.e. only the host, applies to all ports of the host. The fingerprint is hex encoded; colons and the case of the letters are ignored.
+	// If a fingerprint is pinned for a telephone, its certificate is accepted if and only if
+	// the fingerprint matches, regardless of the certificate chain.
+	Fingerprints map[string]string
+	// Accepts all certificates of telephones without pinned fingerprint, including self-signed ones.
+	AllowSelfSigned bool
+}
+
+// Transport creates a http transport which verifies the certificates of the telephones
+// according to the options. The transport can be used in the http.Client of a Connector.
+// An error is returned if the RootCAs contain no valid certificates.
+func (t *TLSOptions) Transport() (*http.Transport, error) {
+	config := &tls.Config{InsecureSkipVerify: t.AllowSelfSigned}
+	if len(t.RootCAs) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(t.RootCAs) {
+			return nil, fmt.Errorf("no valid certificate found in the provided root CAs")
+		}
+		config.RootCAs = pool
+	}
+	fingerprints := make(map[string]string)
+	for address, fingerprint := range t.Fingerprints {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+		}
+		fingerprints[address] = normalizeFingerprint(fingerprint)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	if len(fingerprints) == 0 {
+		return transport, nil
+	}
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		connConfig := config.Clone()
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil {
+			connConfig.ServerName = host
+		}
+		fingerprint, ok := fingerprints[addr]
+		if !ok && err == nil {
+			fingerprint, ok = fingerprints[host]
+		}
+		if ok {
+			connConfig.InsecureSkipVerify = true
+			connConfig.VerifyPeerCertificate = verifyFingerprint(addr, fingerprint)
+		}
+		dialer := tls.Dialer{Config: connConfig}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport, nil
+}
+
+// Fingerprint returns the hex encoded SHA-256 fingerprint of the certificate as it
+// is expected in TLSOptions.Fingerprints.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func verifyFingerprint(address, fingerprint string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("%s did not present a certificate", address)
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		if got := hex.EncodeToString(sum[:]); got != fingerprint {
+			return fmt.Errorf("certificate fingerprint of %s is \"%s\", but \"%s\" is pinned", address, got, fingerprint)
+		}
+		return nil
+	}
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
@@ -0,0 +1,56 @@
+package tukan
+
+import (
+	"encoding/pem"
+	"github.com/fafeitsch/Tukan/tukan/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTLSOptions_Transport(t *testing.T) {
+	handler, _ := mock.CreatePhone(username, password)
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+	fingerprint := Fingerprint(server.Certificate())
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tests := []struct {
+		name    string
+		options TLSOptions
+		wantErr string
+	}{
+		{name: "unknown authority", options: TLSOptions{}, wantErr: "certificate signed by unknown authority"},
+		{name: "self signed allowed", options: TLSOptions{AllowSelfSigned: true}},
+		{name: "trusted root CA", options: TLSOptions{RootCAs: certificate}},
+		{name: "pinned fingerprint", options: TLSOptions{Fingerprints: map[string]string{address: strings.ToUpper(fingerprint)}}},
+		{name: "pinned fingerprint of host", options: TLSOptions{Fingerprints: map[string]string{"127.0.0.1": fingerprint}}},
+		{name: "wrong fingerprint of host", options: TLSOptions{Fingerprints: map[string]string{"127.0.0.1": "abcdef"}, AllowSelfSigned: true}, wantErr: "but \"abcdef\" is pinned"},
+		{name: "wrong fingerprint", options: TLSOptions{Fingerprints: map[string]string{address: "abcdef"}, AllowSelfSigned: true}, wantErr: "but \"abcdef\" is pinned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := tt.options.Transport()
+			require.NoError(t, err, "no error expected")
+			connector := Connector{Client: &http.Client{Transport: transport}, UserName: username, Password: password}
+			phone, err := connector.SingleConnect(server.URL)
+			if tt.wantErr == "" {
+				require.NoError(t, err, "no error expected")
+				assert.NoError(t, phone.Logout(), "no error expected")
+			} else {
+				require.Error(t, err, "error expected")
+				assert.Contains(t, err.Error(), tt.wantErr, "error message is wrong")
+			}
+		})
+	}
+	t.Run("invalid root CAs", func(t *testing.T) {
+		options := TLSOptions{RootCAs: []byte("not a certificate")}
+		transport, err := options.Transport()
+		assert.EqualError(t, err, "no valid certificate found in the provided root CAs", "error message is wrong")
+		assert.Nil(t, transport, "transport should be nil in case of an error")
+	})
+}
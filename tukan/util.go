@@ -1,26 +1,26 @@
 package tukan
 
 import (
-	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 )
 
 // Returns an error if either the error parameter is not nil or
 // the status code is not a "successful" status code.
-// Depending on the condition, the error contains more detailed information.
-// Otherwise, nil is returned.
+// Depending on the condition, the error is a *TransportError, an *AuthError
+// or an *HTTPStatusError. Otherwise, nil is returned.
 func checkResponse(resp *http.Response, err error) error {
 	if err != nil {
-		return err
+		return &TransportError{Err: err}
 	}
-	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
-		data, _ := ioutil.ReadAll(resp.Body)
-		msg := string(data)
-		return fmt.Errorf("authentication error, status code: %d with message \"%s\" and content \"%s\"", resp.StatusCode, resp.Status, msg)
+	if resp.StatusCode <= 299 {
+		return nil
 	}
-	if resp.StatusCode > 299 {
-		return fmt.Errorf("unexpected status code: %d with message \"%s\"", resp.StatusCode, resp.Status)
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	statusErr := HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(data)}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		return &AuthError{HTTPStatusError: statusErr}
 	}
-	return nil
+	return &statusErr
 }